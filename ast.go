@@ -0,0 +1,228 @@
+package bf
+
+import (
+	"fmt"
+)
+
+// Pos is the position of a character in the program source. pos is
+// its byte offset, and lno and linepos its line and column, all
+// counting from 1. For a program parsed from several files, file is
+// the one the character is in, and the offset counts from the start
+// of the first.
+type Pos struct {
+	pos int
+	lno int
+	linepos int
+	file string
+}
+
+// Offset returns the byte offset of the position, counting from 1.
+func (p Pos) Offset() int {
+	return p.pos
+}
+
+// Line returns the line of the position, counting from 1.
+func (p Pos) Line() int {
+	return p.lno
+}
+
+// Col returns the column of the position, counting from 1.
+func (p Pos) Col() int {
+	return p.linepos
+}
+
+// File returns the name of the file the position is in, when the
+// program came from several files, or else "".
+func (p Pos) File() string {
+	return p.file
+}
+
+// String formats the position as line:col, or file:line:col if it
+// has a file.
+func (p Pos) String() string {
+	if p.file != "" {
+		return fmt.Sprintf("%s:%d:%d", p.file, p.lno, p.linepos)
+	}
+	return fmt.Sprintf("%d:%d", p.lno, p.linepos)
+}
+
+// Runner is a node of a parsed program, which the tree interpreter
+// runs by calling Run.
+type Runner interface {
+	Run(rt *Runtime) error
+}
+
+// Block is a sequence of commands. A parsed program is a Block.
+type Block struct {
+	pos Pos
+	seq []Runner
+}
+
+// Add appends a command to the block.
+func (r *Block) Add(x Runner) {
+	r.seq = append(r.seq, x)
+}
+
+func (r *Block) Run(rt *Runtime) error {
+	for _, cmd := range r.seq {
+		if err := cmd.Run(rt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Loop runs its block while the current cell is nonzero. pos is the
+// position of its [ and end of its ].
+type Loop struct {
+	pos Pos
+	block *Block
+	end Pos
+}
+
+func (r *Loop) Run(rt *Runtime) error {
+	for {
+		if err := rt.step(1, r.pos); err != nil {
+			return err
+		}
+		if rt.trace {
+			rt.traceRun(r, r.pos)
+		}
+		if rt.zero(rt.pos) {
+			break
+		}
+		if err := r.block.Run(rt); err != nil {
+			return inLoop(err, r.pos)
+		}
+	}
+	return nil
+}
+
+// Move moves the pointer by dir cells.
+type Move struct {
+	pos Pos
+	dir int
+}
+
+func (r *Move) Run(rt *Runtime) error {
+	err := rt.move(r.dir, r.pos)
+	if rt.trace {
+		rt.traceRun(r, r.pos)
+	}
+	return err
+}
+
+// Scan is a loop like [>] or [<<] that moves the pointer by dir
+// until it reaches a zero cell.
+type Scan struct {
+	pos Pos
+	dir int
+}
+
+func (r *Scan) Run(rt *Runtime) error {
+	err := rt.scan(r.dir, r.pos)
+	if rt.trace {
+		rt.traceRun(r, r.pos)
+	}
+	return err
+}
+
+// Update adds n to the cell off cells from the pointer.
+type Update struct {
+	pos Pos
+	n int
+	off int
+}
+
+func (r *Update) Run(rt *Runtime) error {
+	err := rt.update(r.off, r.n, r.pos)
+	if rt.trace {
+		rt.traceRun(r, r.pos)
+	}
+	return err
+}
+
+// Set stores value in a cell. step is the +1 or -1 of the clear loop
+// it replaced, or 0 for a plain assignment.
+type Set struct {
+	pos Pos
+	value byte
+	off int
+	step int
+}
+
+func (r *Set) Run(rt *Runtime) error {
+	err := rt.set(r.off, r.value, r.step, r.pos)
+	if rt.trace {
+		rt.traceRun(r, r.pos)
+	}
+	return err
+}
+
+// MulTerm adds factor times the source cell to the cell at off.
+type MulTerm struct {
+	off int
+	factor int
+}
+
+// MulAdd is a balanced loop that adds multiples of the current cell
+// to nearby cells and then clears it. min and max are the extent of
+// the offsets the original loop visited, which must all be in range.
+type MulAdd struct {
+	pos Pos
+	terms []MulTerm
+	min int
+	max int
+}
+
+func (r *MulAdd) Run(rt *Runtime) error {
+	err := rt.mulAdd(r.terms, r.min, r.max, r.pos)
+	if rt.trace {
+		rt.traceRun(r, r.pos)
+	}
+	return err
+}
+
+// Getchar reads a byte of input into the cell off cells from the
+// pointer.
+type Getchar struct {
+	pos Pos
+	off int
+}
+
+func (r *Getchar) Run(rt *Runtime) error {
+	err := rt.getchar(r.off, r.pos)
+	if rt.trace {
+		rt.traceRun(r, r.pos)
+	}
+	return err
+}
+
+// Putchar writes the cell off cells from the pointer as a byte of
+// output.
+type Putchar struct {
+	pos Pos
+	off int
+}
+
+func (r *Putchar) Run(rt *Runtime) error {
+	err := rt.putchar(r.off, r.pos)
+	if rt.trace {
+		rt.traceRun(r, r.pos)
+	}
+	return err
+}
+
+// Dump is the # debugging extension, which prints a snapshot of the
+// tape to stderr.
+type Dump struct {
+	pos Pos
+}
+
+func (r *Dump) Run(rt *Runtime) error {
+	err := rt.dump(r.pos)
+	if rt.trace {
+		rt.traceRun(r, r.pos)
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package bf
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// onlyReader hides all but Read, so Parse buffers it itself.
+type onlyReader struct{ io.Reader }
+
+// bangParse parses src with Bang set, through ParseBytes, through
+// Parse on a bufio.Reader and through Parse on a plain io.Reader, and
+// checks each gives the tree want and leaves rest for Rest.
+func bangParse(t *testing.T, p *Parser, src, want, rest string) {
+	t.Helper()
+	for name, parse := range map[string]func() (Runner, error){
+		"ParseBytes": func() (Runner, error) { return p.ParseBytes([]byte(src)) },
+		"bufio": func() (Runner, error) { return p.Parse(bufio.NewReader(strings.NewReader(src))) },
+		"reader": func() (Runner, error) { return p.Parse(onlyReader{strings.NewReader(src)}) },
+	} {
+		prog, err := parse()
+		if err != nil {
+			t.Errorf("%q %s: %v", src, name, err)
+			continue
+		}
+		if got := treeString(t, prog); got != want {
+			t.Errorf("%q %s: got:\n%s\nwant:\n%s", src, name, got, want)
+		}
+		r := p.Rest()
+		if r == nil {
+			t.Errorf("%q %s: no input after the program", src, name)
+			continue
+		}
+		if got, err := io.ReadAll(r); err != nil || string(got) != rest {
+			t.Errorf("%q %s: rest %q, error %v, want %q", src, name, got, err, rest)
+		}
+	}
+}
+
+func TestBang(t *testing.T) {
+	p := &Parser{Bang: true}
+	bangParse(t, p, ",.!hi", ", at 1:1\n. at 1:2\n", "hi")
+	bangParse(t, p, "+!", "+ at 1:1\n", "")
+	// a ! in a comment loop is part of the comment, and the program
+	// goes on to the ! after it
+	bangParse(t, p, "[Hello!]\n,.![in]!put\n", "[ at 1:1\n] at 1:8\n, at 2:1\n. at 2:2\n", "[in]!put\n")
+	bangParse(t, p, "+[-[wow!]]!x", "+ at 1:1\n[ at 1:2\n  - at 1:3\n  [ at 1:4\n  ] at 1:9\n] at 1:10\n", "x")
+	// what follows is bytes, read as they are
+	bin := "\x00\xff\r\n!\x80]["
+	bangParse(t, p, ",[.,]!" + bin, ", at 1:1\n[ at 1:2\n  . at 1:3\n  , at 1:4\n] at 1:5\n", bin)
+}
+
+func TestBangNone(t *testing.T) {
+	p := &Parser{Bang: true}
+	for _, src := range []string{"+.", "[!]."} {
+		if _, err := p.ParseBytes([]byte(src)); err != nil {
+			t.Fatal(err)
+		}
+		if p.Rest() != nil {
+			t.Errorf("%q: input after the program", src)
+		}
+	}
+}
+
+func TestBangBuffered(t *testing.T) {
+	// the map's tokens are looked for several bytes ahead, so the
+	// parser has already read what follows the ! when it stops, and
+	// Rest starts with those bytes
+	p := &Parser{Bang: true, Map: words(t)}
+	bangParse(t, p, "up say!abcdefgh", "+ at 1:1\n. at 1:4\n", "abcdefgh")
+	// as does a bufio.Reader that was given more than the program
+	src := "+.!" + strings.Repeat("x", 100)
+	br := bufio.NewReaderSize(strings.NewReader(src), 16)
+	if _, err := br.Peek(16); err != nil {
+		t.Fatal(err)
+	}
+	q := &Parser{Bang: true}
+	if _, err := q.Parse(br); err != nil {
+		t.Fatal(err)
+	}
+	if n := br.Buffered(); n == 0 {
+		t.Errorf("nothing left buffered")
+	}
+	if got, err := io.ReadAll(q.Rest()); err != nil || !bytes.Equal(got, []byte(src[3:])) {
+		t.Errorf("rest %q, error %v", got, err)
+	}
+}
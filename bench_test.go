@@ -0,0 +1,132 @@
+package bf
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// benchProgram reads and optimizes testdata/bench/name.bf.
+func benchProgram(t testing.TB, name string) Runner {
+	t.Helper()
+	src, err := os.ReadFile(filepath.Join("testdata", "bench", name + ".bf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Optimize(parse(t, string(src)), AllPasses)
+}
+
+// catInput returns a megabyte of text for cat, or 64KB with -short.
+func catInput(t testing.TB) []byte {
+	t.Helper()
+	text, err := os.ReadFile(filepath.Join("testdata", "bench", "cat.in"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := 1 << 20
+	if testing.Short() {
+		size = 64 << 10
+	}
+	return bytes.Repeat(text, size / len(text) + 1)[:size]
+}
+
+func TestBenchPrograms(t *testing.T) {
+	for _, name := range []string{"hanoi", "mandelbrot"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			if name == "mandelbrot" && testing.Short() {
+				t.Skip("mandelbrot takes a second or two")
+			}
+			want, err := os.ReadFile(filepath.Join("testdata", "bench", name + ".out"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			rt, err := NewRuntime(WithOutput(&out))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := rt.Run(benchProgram(t, name)); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != string(want) {
+				t.Errorf("output differs from %s.out:\n%s", name, out.String())
+			}
+		})
+	}
+	input := catInput(t)
+	var out bytes.Buffer
+	rt, err := NewRuntime(WithInput(bytes.NewReader(input)), WithOutput(&out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Run(benchProgram(t, "cat")); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), input) {
+		t.Errorf("cat copied %d of %d bytes", out.Len(), len(input))
+	}
+}
+
+func TestBenchWorkloads(t *testing.T) {
+	// scan and count print nothing, so only have to end
+	for _, name := range []string{"scan", "count", "print"} {
+		var out bytes.Buffer
+		rt, err := NewRuntime(WithOutput(&out))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rt.Run(benchProgram(t, name)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if want := map[string]int{"print": 1000000}[name]; out.Len() != want || bytes.Count(out.Bytes(), []byte("\n")) != want / 100 {
+			t.Errorf("%s printed %d bytes in %d lines", name, out.Len(), bytes.Count(out.Bytes(), []byte("\n")))
+		}
+	}
+}
+
+// benchRun runs prog b.N times with input, discarding its output.
+func benchRun(b *testing.B, prog Runner, input []byte) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rt, err := NewRuntime(WithInput(bytes.NewReader(input)), WithOutput(io.Discard))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := rt.Run(prog); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMandelbrot(b *testing.B) {
+	if testing.Short() {
+		b.Skip("mandelbrot takes a second or two")
+	}
+	benchRun(b, benchProgram(b, "mandelbrot"), nil)
+}
+
+func BenchmarkHanoi(b *testing.B) {
+	benchRun(b, benchProgram(b, "hanoi"), nil)
+}
+
+func BenchmarkCatMegabyte(b *testing.B) {
+	input := catInput(b)
+	b.SetBytes(int64(len(input)))
+	benchRun(b, benchProgram(b, "cat"), input)
+}
+
+func BenchmarkScanTrail(b *testing.B) {
+	benchRun(b, benchProgram(b, "scan"), nil)
+}
+
+func BenchmarkPrintMegabyte(b *testing.B) {
+	b.SetBytes(1000000)
+	benchRun(b, benchProgram(b, "print"), nil)
+}
+
+func BenchmarkCountLoops(b *testing.B) {
+	benchRun(b, benchProgram(b, "count"), nil)
+}
@@ -0,0 +1,50 @@
+package bf_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	bf "github.com/timnewsham/gobf"
+)
+
+func TestParseAndRun(t *testing.T) {
+	src, err := os.ReadFile("testdata/rot13.bf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog, err := bf.Parse(strings.NewReader(string(src)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	rt := bf.New(strings.NewReader("Hello, World!\n"), &out)
+	if err := rt.Run(prog); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "Uryyb, Jbeyq!\n"; got != want {
+		t.Errorf("output %q, want %q", got, want)
+	}
+}
+
+func TestRuntimeState(t *testing.T) {
+	prog, err := bf.ParseString("++>+++>+<")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	rt := bf.New(strings.NewReader(""), &out)
+	if err := rt.Run(prog); err != nil {
+		t.Fatal(err)
+	}
+	if rt.Pointer() != 1 || rt.Cell(0).Int64() != 2 || rt.Cell(1).Int64() != 3 || rt.Cell(2).Int64() != 1 {
+		t.Errorf("pointer %d, cells %v %v %v", rt.Pointer(), rt.Cell(0), rt.Cell(1), rt.Cell(2))
+	}
+	if rt.Cell(-1) != nil || rt.Cell(bf.DefaultTapeSize) != nil {
+		t.Errorf("cells off the tape are not nil")
+	}
+	if out.Len() != 0 {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
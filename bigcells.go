@@ -0,0 +1,55 @@
+package bf
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// In big cell mode each cell is an arbitrary precision integer that
+// never wraps, held in bigs with nil standing for zero.
+// Putchar writes values from 0 to 255 as bytes and otherwise either
+// fails or, when bigLow is set, writes the low byte.
+
+// SetBigCells replaces the tape with zeroed arbitrary precision cells,
+// keeping its size.
+func (rt *Runtime) SetBigCells(lowByte bool) {
+	rt.bigs = make([]*big.Int, rt.size())
+	rt.store = nil
+	rt.wide = nil
+	rt.bigLow = lowByte
+	rt.pos = 0
+	rt.origin = 0
+}
+
+// big returns cell i, allocating it if needed.
+func (rt *Runtime) big(i int) *big.Int {
+	if rt.bigs[i] == nil {
+		rt.bigs[i] = new(big.Int)
+	}
+	return rt.bigs[i]
+}
+
+// bigByte returns the byte Putchar should write for cell i.
+func (rt *Runtime) bigByte(i int, at Pos) (byte, error) {
+	v := rt.big(i)
+	if v.Sign() >= 0 && v.Cmp(big.NewInt(255)) <= 0 {
+		return byte(v.Int64()), nil
+	}
+	if !rt.bigLow {
+		return 0, fmt.Errorf("cell value %s does not fit in a byte in putchar at %+v", v, at)
+	}
+	return byte(new(big.Int).And(v, big.NewInt(255)).Int64()), nil
+}
+
+// Cell returns the value of logical cell i, where 0 is the cell the
+// pointer started on, or nil if i has never been allocated.
+func (rt *Runtime) Cell(i int) *big.Int {
+	i += rt.origin
+	if i < 0 || i >= rt.size() {
+		return nil
+	}
+	if rt.bigs != nil {
+		return new(big.Int).Set(rt.big(i))
+	}
+	return new(big.Int).SetUint64(uint64(rt.get(i)))
+}
@@ -0,0 +1,358 @@
+package bf
+
+import (
+	"fmt"
+	"io"
+)
+
+// Opcode is the operation of an Instruction.
+type Opcode byte
+
+const (
+	OpMove Opcode = iota
+	OpUpdate
+	OpSet
+	OpMulAdd
+	OpScan
+	OpGetchar
+	OpPutchar
+	OpDump
+	OpOpen
+	OpClose
+	OpDefine
+	OpCall
+	OpReturn
+	OpFork
+	OpHalt
+	OpStore
+	OpRetrieve
+	OpHang
+
+	numOps // the number of opcodes
+)
+
+// Instruction is one step of a flattened program.
+// arg is the count, distance, or value for the op. For OpOpen and
+// OpClose it is the index of the matching bracket instead, and for
+// OpDefine and OpReturn the index of the other end of the procedure.
+// OpMulAdd keeps its terms and visited extent in terms, min and max,
+// and OpSet the step of the clear loop it replaced in step.
+type Instruction struct {
+	op Opcode
+	arg int
+	off int
+	pos Pos
+
+	terms []MulTerm
+	min int
+	max int
+	step int
+}
+
+// Pos returns the position of the command the instruction starts
+// with.
+func (in Instruction) Pos() Pos {
+	return in.pos
+}
+
+// Compile flattens a parsed program into bytecode, or returns the
+// code of a Compiled one.
+func Compile(prog Runner) ([]Instruction, error) {
+	if c, ok := prog.(*Compiled); ok {
+		return c.code, nil
+	}
+	var code []Instruction
+	if err := compile(prog, &code); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+func compile(r Runner, code *[]Instruction) error {
+	switch x := r.(type) {
+	case *Block:
+		for _, cmd := range x.seq {
+			if err := compile(cmd, code); err != nil {
+				return err
+			}
+		}
+	case *Loop:
+		open := len(*code)
+		in, _ := instruction(x)
+		*code = append(*code, in)
+		if err := compile(x.block, code); err != nil {
+			return err
+		}
+		close := len(*code)
+		*code = append(*code, Instruction{op: OpClose, arg: open, pos: x.end})
+		(*code)[open].arg = close
+	case *DefineProc:
+		def := len(*code)
+		in, _ := instruction(x)
+		*code = append(*code, in)
+		if err := compile(x.block, code); err != nil {
+			return err
+		}
+		ret := len(*code)
+		*code = append(*code, Instruction{op: OpReturn, arg: def, pos: x.end})
+		(*code)[def].arg = ret
+	default:
+		in, ok := instruction(r)
+		if !ok {
+			return fmt.Errorf("cannot compile %T", r)
+		}
+		*code = append(*code, in)
+	}
+	return nil
+}
+
+// instruction returns the single instruction for a node other than a
+// Block, with a Loop standing for its OpOpen and a DefineProc for its
+// OpDefine.
+func instruction(r Runner) (Instruction, bool) {
+	switch x := r.(type) {
+	case *Loop:
+		return Instruction{op: OpOpen, pos: x.pos}, true
+	case *DefineProc:
+		return Instruction{op: OpDefine, pos: x.pos}, true
+	case *CallProc:
+		return Instruction{op: OpCall, pos: x.pos}, true
+	case *Fork:
+		return Instruction{op: OpFork, pos: x.pos}, true
+	case *Halt:
+		return Instruction{op: OpHalt, pos: x.pos}, true
+	case *Store:
+		return Instruction{op: OpStore, pos: x.pos}, true
+	case *Retrieve:
+		return Instruction{op: OpRetrieve, pos: x.pos}, true
+	case *Hang:
+		return Instruction{op: OpHang, pos: x.pos}, true
+	case *Move:
+		return Instruction{op: OpMove, arg: x.dir, pos: x.pos}, true
+	case *Update:
+		return Instruction{op: OpUpdate, arg: x.n, off: x.off, pos: x.pos}, true
+	case *Set:
+		return Instruction{op: OpSet, arg: int(x.value), off: x.off, pos: x.pos, step: x.step}, true
+	case *MulAdd:
+		return Instruction{op: OpMulAdd, pos: x.pos, terms: x.terms, min: x.min, max: x.max}, true
+	case *Scan:
+		return Instruction{op: OpScan, arg: x.dir, pos: x.pos}, true
+	case *Getchar:
+		return Instruction{op: OpGetchar, off: x.off, pos: x.pos}, true
+	case *Putchar:
+		return Instruction{op: OpPutchar, off: x.off, pos: x.pos}, true
+	case *Dump:
+		return Instruction{op: OpDump, pos: x.pos}, true
+	}
+	return Instruction{}, false
+}
+
+// WriteCode writes code to w one instruction per line, with its
+// index, the position it came from, and for a bracket the index of
+// its match.
+func WriteCode(w io.Writer, code []Instruction) error {
+	for i, in := range code {
+		var err error
+		if in.op == OpOpen || in.op == OpClose || in.op == OpDefine || in.op == OpReturn {
+			_, err = fmt.Fprintf(w, "%6d  %-8s %s %d\n", i, lineCol(in.pos), in.String(), in.arg)
+		} else {
+			_, err = fmt.Fprintf(w, "%6d  %-8s %s\n", i, lineCol(in.pos), in.String())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunBytecode executes a compiled program, from the start or from
+// where a restored checkpoint left off.
+// Unlike the tree interpreter it does not support tracing, but it
+// records a Profile, HotLoops, Coverage and Stats when they are enabled,
+// stops at the breakpoints of an attached Debugger, and reports
+// changes to watched cells. Those see only the first thread of a
+// program that forks; RunBytecode returns once every thread is done.
+func (rt *Runtime) RunBytecode(code []Instruction) error {
+	pc, hooks, err := rt.begin(code)
+	if err == nil {
+		err = rt.execute(code, pc, hooks)
+	}
+	return rt.end(err)
+}
+
+// begin sets up a bytecode run of code, returning the pc to start at
+// and whether any hooks are enabled.
+func (rt *Runtime) begin(code []Instruction) (int, bool, error) {
+	if p := rt.profile; p != nil {
+		p.code = code
+		p.counts = make([]int64, len(code))
+	}
+	if h := rt.hot; h != nil {
+		h.reset(code)
+	}
+	if c := rt.cover; c != nil {
+		c.reset(code)
+	}
+	if s := rt.stats; s != nil {
+		s.reset(rt)
+	}
+	if d := rt.debug; d != nil {
+		if err := d.start(code); err != nil {
+			return 0, false, err
+		}
+	}
+	// one check per instruction when nothing is watching the run
+	hooks := rt.profile != nil || rt.hot != nil || rt.cover != nil || rt.debug != nil || rt.watches != nil || rt.stats != nil
+	rt.code = code
+	pc := 0
+	if rt.resume {
+		pc = rt.pc
+		rt.resume = false
+	} else {
+		rt.resetProcs()
+		rt.forks = nil
+	}
+	return pc, hooks, nil
+}
+
+// end finishes a bytecode run that stopped with err, waiting for any
+// threads it forked.
+func (rt *Runtime) end(err error) error {
+	if rt.forks != nil {
+		err = rt.joinThreads(err)
+	}
+	if h := rt.hot; h != nil {
+		h.finish()
+	}
+	if s := rt.stats; s != nil {
+		s.finish(rt)
+	}
+	return err
+}
+
+// execute runs code from pc to the end, calling beforeHooks for each
+// instruction if hooks is set.
+func (rt *Runtime) execute(code []Instruction, pc int, hooks bool) error {
+	var err error
+	for ; pc < len(code); pc++ {
+		in := &code[pc]
+		rt.pc = pc
+		if hooks {
+			if err := rt.beforeHooks(pc, in); err != nil {
+				return err
+			}
+		}
+		switch in.op {
+		case OpMove:
+			err = rt.move(in.arg, in.pos)
+		case OpUpdate:
+			err = rt.update(in.off, in.arg, in.pos)
+		case OpSet:
+			err = rt.set(in.off, byte(in.arg), in.step, in.pos)
+		case OpMulAdd:
+			err = rt.mulAdd(in.terms, in.min, in.max, in.pos)
+		case OpScan:
+			err = rt.scan(in.arg, in.pos)
+		case OpGetchar:
+			err = rt.getchar(in.off, in.pos)
+		case OpPutchar:
+			err = rt.putchar(in.off, in.pos)
+		case OpDump:
+			err = rt.dump(in.pos)
+		case OpOpen:
+			err = rt.step(1, in.pos)
+			if err == nil && rt.zero(rt.pos) {
+				pc = in.arg
+			} else if err == nil && rt.hot != nil {
+				rt.hot.enter(pc)
+			}
+		case OpClose:
+			err = rt.step(1, in.pos)
+			if err == nil && !rt.zero(rt.pos) {
+				pc = in.arg
+				if rt.hot != nil {
+					rt.hot.iterate()
+				}
+			} else if err == nil && rt.hot != nil {
+				rt.hot.exit()
+			}
+		case OpDefine:
+			err = rt.step(1, in.pos)
+			if err == nil {
+				err = rt.define(procedure{pc: pc + 1, end: code[in.arg].pos}, in.pos)
+				pc = in.arg
+			}
+		case OpCall:
+			var p procedure
+			err = rt.step(1, in.pos)
+			if err == nil {
+				p, err = rt.call(pc, in.pos)
+			}
+			if err == nil {
+				pc = p.pc - 1
+			}
+		case OpFork:
+			err = rt.fork(code, pc, in.pos)
+		case OpHalt:
+			err = rt.step(1, in.pos)
+			if err == nil {
+				return nil
+			}
+		case OpStore:
+			err = rt.storeReg(in.pos)
+		case OpRetrieve:
+			err = rt.retrieveReg(in.pos)
+		case OpHang:
+			err = rt.hang(in.pos)
+		case OpReturn:
+			var ret int
+			ret, err = rt.ret(in.pos)
+			if err == nil {
+				pc = ret
+			}
+		}
+		if err != nil {
+			return inLoops(err, code, pc)
+		}
+		if hooks && rt.watches != nil {
+			if err := rt.checkWatches(in); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// beforeHooks does the per-instruction work of whichever of profiling,
+// hot loops, coverage, statistics, debugging and watchpoints are
+// enabled, after stopping a RunSteps that has used up its budget.
+func (rt *Runtime) beforeHooks(pc int, in *Instruction) error {
+	if rt.sliced {
+		if rt.budget == 0 {
+			return errPaused
+		}
+		rt.budget--
+	}
+	if rt.profile != nil {
+		rt.profile.counts[pc]++
+	}
+	if rt.hot != nil {
+		rt.hot.total++
+	}
+	if rt.cover != nil {
+		rt.cover.mark(in.pos)
+	}
+	if rt.stats != nil {
+		rt.stats.before(rt, in)
+	}
+	if rt.debug != nil {
+		rt.flush()
+		if err := rt.debug.before(in); err != nil {
+			return err
+		}
+	}
+	if rt.watches != nil {
+		rt.snapshotWatches()
+	}
+	return nil
+}
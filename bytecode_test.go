@@ -0,0 +1,62 @@
+package bf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompileJumps(t *testing.T) {
+	code, err := Compile(parse(t, "+[>[-]<-]."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stack []int
+	for i, in := range code {
+		switch in.op {
+		case OpOpen:
+			stack = append(stack, i)
+		case OpClose:
+			open := stack[len(stack) - 1]
+			stack = stack[:len(stack) - 1]
+			if in.arg != open || code[open].arg != i {
+				t.Errorf("brackets %d and %d jump to %d and %d", open, i, code[open].arg, in.arg)
+			}
+		}
+	}
+}
+
+func TestBytecodeErrorPos(t *testing.T) {
+	_, _, err := runProgram(t, "+\n [<+]", "", 0)
+	if err == nil || err.Error() != "position -1 (moving -1 from 0) is out of range for 30000 cell tape at 2:3, in loop at 2:2" {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func BenchmarkTreeVsBytecode(b *testing.B) {
+	src, err := os.ReadFile(filepath.Join("testdata", "bench", "count.bf"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	prog := Optimize(parse(b, string(src)), AllPasses)
+	b.Run("tree", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			rt := New(nil, nil)
+			rt.interpret = true
+			if err := rt.Run(prog); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("bytecode", func(b *testing.B) {
+		code, err := Compile(prog)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for i := 0; i < b.N; i++ {
+			if err := New(nil, nil).RunBytecode(code); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package bf
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// Cells are 8 bits wide by default and live in store. Wider cells
+// live in wide instead, and are masked down to the cell width
+// whenever they are written, which makes arithmetic wrap at
+// 2^width. Exactly one of store, wide and bigs is in use at a time.
+
+// SetCellWidth replaces the tape with zeroed cells of the given number
+// of bits, keeping its size.
+func (rt *Runtime) SetCellWidth(bits int) error {
+	n := rt.size()
+	switch bits {
+	case 8:
+		rt.store = make([]byte, n)
+		rt.wide = nil
+	case 16, 32:
+		rt.store = nil
+		rt.wide = make([]uint32, n)
+		rt.mask = uint32(1 << uint(bits) - 1)
+	default:
+		return fmt.Errorf("cell width %d must be 8, 16 or 32", bits)
+	}
+	rt.bigs = nil
+	rt.pos = 0
+	rt.origin = 0
+	return nil
+}
+
+// checkAdd returns an error if adding delta to cell i would take it
+// outside the range of the cell width, for strict mode.
+func (rt *Runtime) checkAdd(i int, delta int64, at Pos) error {
+	old := int64(rt.get(i))
+	max := int64(0xff)
+	if rt.wide != nil {
+		max = int64(rt.mask)
+	}
+	if v := old + delta; v < 0 || v > max {
+		return rt.overflow(i, old, at)
+	}
+	return nil
+}
+
+// WrapError is returned in strict mode when a cell would overflow or
+// underflow. Cell is the logical cell, Value what it held and Pos the
+// command.
+type WrapError struct {
+	Pos Pos
+	Cell int
+	Value int64
+}
+
+func (e *WrapError) Error() string {
+	return fmt.Sprintf("cell %d with value %d would wrap at %+v", e.Cell, e.Value, e.Pos)
+}
+
+func (rt *Runtime) overflow(i int, old int64, at Pos) error {
+	return &WrapError{Pos: at, Cell: i - rt.origin, Value: old}
+}
+
+// size returns the number of cells currently allocated.
+func (rt *Runtime) size() int {
+	if rt.wide != nil {
+		return len(rt.wide)
+	}
+	if rt.bigs != nil {
+		return len(rt.bigs)
+	}
+	return len(rt.store)
+}
+
+// zero reports whether cell i is zero.
+func (rt *Runtime) zero(i int) bool {
+	if rt.store != nil {
+		return rt.store[i] == 0
+	}
+	if rt.bigs != nil {
+		return rt.bigs[i] == nil || rt.bigs[i].Sign() == 0
+	}
+	return rt.wide[i] == 0
+}
+
+// cellString formats the value of cell i.
+func (rt *Runtime) cellString(i int) string {
+	if rt.bigs != nil {
+		return rt.big(i).String()
+	}
+	return strconv.FormatUint(uint64(rt.get(i)), 10)
+}
+
+func (rt *Runtime) get(i int) uint32 {
+	if rt.wide != nil {
+		return rt.wide[i]
+	}
+	return uint32(rt.store[i])
+}
+
+func (rt *Runtime) put(i int, v uint32) {
+	if rt.wide != nil {
+		rt.wide[i] = v & rt.mask
+		return
+	}
+	rt.store[i] = byte(v)
+}
+
+// realloc resizes the tape to size cells, moving the existing cells
+// shift places to the right.
+func (rt *Runtime) realloc(shift, size int) {
+	if rt.bigs != nil {
+		bigs := make([]*big.Int, size)
+		copy(bigs[shift:], rt.bigs)
+		rt.bigs = bigs
+		return
+	}
+	if rt.wide != nil {
+		wide := make([]uint32, size)
+		copy(wide[shift:], rt.wide)
+		rt.wide = wide
+		return
+	}
+	store := make([]byte, size)
+	copy(store[shift:], rt.store)
+	rt.store = store
+}
@@ -0,0 +1,142 @@
+package bf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCellWidths(t *testing.T) {
+	tests := []struct {
+		bits int
+		src string
+		cell string
+	}{
+		{8, "-", "255"},
+		{16, "-", "65535"},
+		{32, "-", "4294967295"},
+		{8, strings.Repeat("+", 256), "0"},
+		{16, strings.Repeat("+", 256), "256"},
+		// 256 * 256 wraps 16 bit cells but not 32 bit ones
+		{16, strings.Repeat("+", 16) + "[>" + strings.Repeat("+", 16) + "[>" + strings.Repeat("+", 256) + "<-]<-]>>", "0"},
+		{32, strings.Repeat("+", 16) + "[>" + strings.Repeat("+", 16) + "[>" + strings.Repeat("+", 256) + "<-]<-]>>", "65536"},
+	}
+	for _, tt := range tests {
+		for _, passes := range []Pass{0, AllPasses} {
+			_, rt, err := runProgram(t, tt.src, "", passes, WithCellWidth(tt.bits))
+			if err != nil {
+				t.Errorf("%d bit %.12q passes %d: %v", tt.bits, tt.src, passes, err)
+				continue
+			}
+			if got := rt.Cell(rt.Pointer()).String(); got != tt.cell {
+				t.Errorf("%d bit %.12q passes %d: cell is %s, want %s", tt.bits, tt.src, passes, got, tt.cell)
+			}
+		}
+	}
+	if _, err := NewRuntime(WithCellWidth(64)); err == nil {
+		t.Errorf("64 bit cells accepted")
+	}
+}
+
+func TestWideCellIO(t *testing.T) {
+	for _, bits := range []int{16, 32} {
+		// 321 is 0x141, which prints its low byte 'A'
+		out, _, err := runProgram(t, strings.Repeat("+", 321) + ".", "", 0, WithCellWidth(bits))
+		if err != nil || out != "A" {
+			t.Errorf("%d bit: output %q, error %v", bits, out, err)
+		}
+		_, rt, err := runProgram(t, ",", "\xff", 0, WithCellWidth(bits))
+		if err != nil || rt.Cell(0).Int64() != 255 {
+			t.Errorf("%d bit: read 0xff as %v, error %v", bits, rt.Cell(0), err)
+		}
+	}
+}
+
+func BenchmarkCellWidth(b *testing.B) {
+	prog := Optimize(parse(b, loopHeavy), 0)
+	for _, bits := range []int{8, 16, 32} {
+		b.Run(fmt.Sprint(bits), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				rt, err := NewRuntime(WithOutput(io.Discard), WithCellWidth(bits))
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := rt.Run(prog); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestBigCells(t *testing.T) {
+	// multiply by 256 nine times, which takes 2^72 loop iterations
+	// unless MulAdd turns each loop into a single multiplication
+	src := "+" + strings.Repeat("[>" + strings.Repeat("+", 256) + "<-]>", 9)
+	_, rt, err := runProgram(t, src, "", AllPasses, WithBigCells(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rt.Cell(9).String(), "4722366482869645213696"; got != want {
+		t.Errorf("cell 9 is %s, want %s", got, want)
+	}
+	if got := strings.Join(tape(rt, 0, 8), ""); got != "000000000" {
+		t.Errorf("cells 0-8 are %s", got)
+	}
+	_, rt, err = runProgram(t, "--", "", 0, WithBigCells(false))
+	if err != nil || rt.Cell(0).String() != "-2" {
+		t.Errorf("-- left %v, error %v", rt.Cell(0), err)
+	}
+}
+
+func TestBigCellPutchar(t *testing.T) {
+	large := strings.Repeat("+", 321) + "."
+	if _, _, err := runProgram(t, large, "", 0, WithBigCells(false)); err == nil || err.Error() != "cell value 321 does not fit in a byte in putchar at 1:322" {
+		t.Errorf("error %v printing 321", err)
+	}
+	if _, _, err := runProgram(t, "-.", "", 0, WithBigCells(false)); err == nil {
+		t.Errorf("printed -1 without error")
+	}
+	for src, want := range map[string]string{large: "A", "-.": "\xff", "+++.": "\x03"} {
+		out, _, err := runProgram(t, src, "", 0, WithBigCells(true))
+		if err != nil || out != want {
+			t.Errorf("%.12q: output %q, error %v, want %q", src, out, err, want)
+		}
+	}
+}
+
+func TestStrictCells(t *testing.T) {
+	tests := []struct {
+		src string
+		err string
+		// optErr is the error once optimized, if it differs
+		optErr string
+	}{
+		{">-", "cell 1 with value 0 would wrap at 1:2", ""},
+		{strings.Repeat("+", 256), "cell 0 with value 255 would wrap at 1:256", ""},
+		// a MulAdd stands for the whole loop
+		{"++[>-<-]", "cell 1 with value 0 would wrap at 1:5, in loop at 1:3", "cell 1 with value 0 would wrap at 1:3"},
+		{"+[>+++<-]>[-]", "", ""},
+	}
+	for _, tt := range tests {
+		for _, passes := range []Pass{0, StrictPasses} {
+			want := tt.err
+			if passes != 0 && tt.optErr != "" {
+				want = tt.optErr
+			}
+			_, _, err := runProgram(t, tt.src, "", passes, WithStrictCells())
+			if want == "" && err != nil || want != "" && (err == nil || err.Error() != want) {
+				t.Errorf("%.12q passes %d: error %v, want %q", tt.src, passes, err, want)
+			}
+		}
+		_, _, err := runProgram(t, tt.src, "", AllPasses)
+		if err != nil {
+			t.Errorf("%.12q wrapped with an error outside strict mode: %v", tt.src, err)
+		}
+	}
+	_, rt, err := runProgram(t, "-", "", 0)
+	if err != nil || rt.Cell(0).Int64() != 255 {
+		t.Errorf("- left %v, error %v", rt.Cell(0), err)
+	}
+}
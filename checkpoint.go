@@ -0,0 +1,256 @@
+package bf
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sync/atomic"
+)
+
+// A checkpoint holds the state of a paused bytecode run, so it can be
+// resumed later. All numbers are little endian:
+//
+//	magic	"BFCK"
+//	version	uint16, currently 1
+//	hash	[32]byte sha256 of the compiled program
+//	bits	uint8 cell width, 0 for big cells
+//	origin, pos, steps, pc, input	int64 each
+//	cells	int64 count, then the cells: bytes for 8 bits, uint16 or
+//		uint32 for wider cells, and for big cells a sign byte
+//		(-1, 0 or 1), a uint32 length and the magnitude bytes
+//
+// input is how many bytes , has read. pc is the instruction to run
+// next, so resuming starts by running it again from the beginning.
+
+const (
+	checkpointMagic = "BFCK"
+	checkpointVersion = 1
+)
+
+// ErrProgramMismatch is returned when restoring a checkpoint taken
+// from a different program, or the same one compiled differently.
+var ErrProgramMismatch = errors.New("checkpoint is for a different program")
+
+// codeHash identifies a compiled program.
+func codeHash(code []Instruction) [32]byte {
+	h := sha256.New()
+	for _, in := range code {
+		fmt.Fprintf(h, "%d %d %d %d %d %d %v %d:%d\n", in.op, in.arg, in.off, in.min, in.max, in.step, in.terms, in.pos.lno, in.pos.linepos)
+	}
+	var sum [32]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// cellBits returns the cell width, or 0 for big cells.
+func (rt *Runtime) cellBits() uint8 {
+	switch {
+	case rt.bigs != nil:
+		return 0
+	case rt.wide != nil && rt.mask == 0xffff:
+		return 16
+	case rt.wide != nil:
+		return 32
+	}
+	return 8
+}
+
+// Checkpoint writes the state of the current or last bytecode run to
+// w. It should be taken between instructions: after a run stops with
+// an error such as a StepLimitError, or at a signal checkpoint.
+func (rt *Runtime) Checkpoint(w io.Writer) error {
+	if rt.code == nil {
+		return errors.New("no bytecode run to checkpoint")
+	}
+	if rt.forks != nil {
+		return errors.New("cannot checkpoint a run that has forked")
+	}
+	if rt.reg != 0 || rt.bigReg != nil {
+		return errors.New("cannot checkpoint a run that has stored a value")
+	}
+	if len(rt.procs) > 0 {
+		return errors.New("cannot checkpoint a run that has defined procedures")
+	}
+	bw := bufio.NewWriter(w)
+	hash := codeHash(rt.code)
+	put := func(v interface{}) {
+		binary.Write(bw, binary.LittleEndian, v)
+	}
+	bw.WriteString(checkpointMagic)
+	put(uint16(checkpointVersion))
+	put(hash)
+	put(rt.cellBits())
+	for _, v := range []int{rt.origin, rt.pos, int(rt.steps), rt.pc, int(rt.inputOffset), rt.size()} {
+		put(int64(v))
+	}
+	switch {
+	case rt.bigs != nil:
+		for i := range rt.bigs {
+			v := rt.big(i)
+			mag := v.Bytes()
+			put(int8(v.Sign()))
+			put(uint32(len(mag)))
+			bw.Write(mag)
+		}
+	case rt.wide != nil && rt.mask == 0xffff:
+		for _, v := range rt.wide {
+			put(uint16(v))
+		}
+	case rt.wide != nil:
+		put(rt.wide)
+	default:
+		bw.Write(rt.store)
+	}
+	return bw.Flush()
+}
+
+// Restore loads a checkpoint of code taken by Checkpoint, replacing
+// the tape, pointer, step count, and the instruction and input
+// offset to resume from, so that the next run of code continues where
+// the checkpoint left off. The Runtime must have the cell width the
+// checkpoint was taken with. Input can't be restored in general, so
+// the caller must arrange for the program's input to continue from
+// InputOffset.
+func (rt *Runtime) Restore(r io.Reader, code []Instruction) error {
+	br := bufio.NewReader(r)
+	get := func(v interface{}) error {
+		return binary.Read(br, binary.LittleEndian, v)
+	}
+	magic := make([]byte, len(checkpointMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != checkpointMagic {
+		return errors.New("not a checkpoint file")
+	}
+	var version uint16
+	if err := get(&version); err != nil {
+		return err
+	}
+	if version != checkpointVersion {
+		return fmt.Errorf("checkpoint version %d is not supported", version)
+	}
+	var hash [32]byte
+	if err := get(&hash); err != nil {
+		return err
+	}
+	if hash != codeHash(code) {
+		return ErrProgramMismatch
+	}
+	var bits uint8
+	if err := get(&bits); err != nil {
+		return err
+	}
+	if bits != rt.cellBits() {
+		return fmt.Errorf("checkpoint has %d bit cells but the runtime has %d", bits, rt.cellBits())
+	}
+	var vals [6]int64
+	if err := get(&vals); err != nil {
+		return err
+	}
+	origin, pos, steps, pc, input, n := vals[0], vals[1], vals[2], vals[3], vals[4], vals[5]
+	if n <= 0 || n > MaxTapeSize || pos < 0 || pos >= n || origin < 0 || origin >= n || pc < 0 || pc > int64(len(code)) {
+		return errors.New("checkpoint is corrupt")
+	}
+
+	switch bits {
+	case 0:
+		bigs := make([]*big.Int, n)
+		for i := range bigs {
+			var sign int8
+			var size uint32
+			if err := get(&sign); err != nil {
+				return err
+			}
+			if err := get(&size); err != nil {
+				return err
+			}
+			mag := make([]byte, size)
+			if _, err := io.ReadFull(br, mag); err != nil {
+				return err
+			}
+			if sign != 0 {
+				bigs[i] = new(big.Int).SetBytes(mag)
+				if sign < 0 {
+					bigs[i].Neg(bigs[i])
+				}
+			}
+		}
+		rt.bigs = bigs
+	case 16:
+		wide16 := make([]uint16, n)
+		if err := get(wide16); err != nil {
+			return err
+		}
+		rt.wide = make([]uint32, n)
+		for i, v := range wide16 {
+			rt.wide[i] = uint32(v)
+		}
+	case 32:
+		rt.wide = make([]uint32, n)
+		if err := get(rt.wide); err != nil {
+			return err
+		}
+	default:
+		rt.store = make([]byte, n)
+		if _, err := io.ReadFull(br, rt.store); err != nil {
+			return err
+		}
+	}
+	rt.origin = int(origin)
+	rt.pos = int(pos)
+	rt.steps = steps
+	rt.pc = int(pc)
+	rt.inputOffset = input
+	rt.code = code
+	rt.resume = true
+	return nil
+}
+
+// SaveCheckpoint writes a checkpoint to the file path.
+func (rt *Runtime) SaveCheckpoint(path string) error {
+	var buf bytes.Buffer
+	if err := rt.Checkpoint(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// InputOffset returns how many bytes of input the program has read.
+func (rt *Runtime) InputOffset() int64 {
+	return rt.inputOffset
+}
+
+// CheckpointOnSignal makes the run write a checkpoint to the file
+// path at the next cancellation check after RequestCheckpoint.
+func (rt *Runtime) CheckpointOnSignal(path string) {
+	rt.checkpointPath = path
+}
+
+// RequestCheckpoint asks a running program to write a checkpoint.
+// It is safe to call from another goroutine.
+func (rt *Runtime) RequestCheckpoint() {
+	atomic.StoreInt32(&rt.checkpointWanted, 1)
+}
+
+// signalCheckpoint writes a requested checkpoint. A failure is
+// reported on stderr, since the run itself is unaffected.
+func (rt *Runtime) signalCheckpoint() {
+	if rt.checkpointPath == "" || !atomic.CompareAndSwapInt32(&rt.checkpointWanted, 1, 0) {
+		return
+	}
+	// output from before the checkpoint must not be lost, since
+	// resuming doesn't replay it
+	err := rt.flush()
+	if err == nil {
+		err = rt.SaveCheckpoint(rt.checkpointPath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: checkpoint failed: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "checkpoint written to %s at step %d\n", rt.checkpointPath, rt.steps)
+}
@@ -0,0 +1,124 @@
+package bf
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// stopAt runs src for at most steps steps and returns the Runtime,
+// which must have stopped at the limit, and the program's bytecode.
+func stopAt(t *testing.T, src, input string, steps uint64, opts ...Option) (*Runtime, []Instruction, string) {
+	t.Helper()
+	opts = append(opts, WithMaxSteps(steps))
+	out, rt, err := runProgram(t, src, input, 0, opts...)
+	var limit *StepLimitError
+	if !errors.As(err, &limit) {
+		t.Fatalf("error %v, want the step limit", err)
+	}
+	code, err := Compile(parse(t, src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rt, code, out
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	src := "++++++++[>++++++++[>++++<-]<-]>>+[<+>-]"
+	for _, tt := range []struct {
+		name string
+		src string
+		opts []Option
+	}{
+		{"8 bit", src, nil},
+		{"16 bit", src, []Option{WithCellWidth(16)}},
+		{"32 bit", src, []Option{WithCellWidth(32)}},
+		{"big", src, []Option{WithBigCells(false)}},
+		// cells left of the start move the origin
+		{"infinite", "<<" + src, []Option{WithTapeSize(4), WithTapeMode("infinite", 1 << 20)}},
+	} {
+		opts := tt.opts
+		rt, code, _ := stopAt(t, tt.src, "", 300, opts...)
+		var saved bytes.Buffer
+		if err := rt.Checkpoint(&saved); err != nil {
+			t.Fatal(err)
+		}
+		restored, err := NewRuntime(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := restored.Restore(bytes.NewReader(saved.Bytes()), code); err != nil {
+			t.Fatal(err)
+		}
+		var again bytes.Buffer
+		if err := restored.Checkpoint(&again); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(saved.Bytes(), again.Bytes()) {
+			t.Errorf("%s: checkpoint changed after restoring it", tt.name)
+		}
+	}
+}
+
+func TestCheckpointMismatch(t *testing.T) {
+	rt, _, _ := stopAt(t, "+[>+<]", "", 100)
+	var saved bytes.Buffer
+	if err := rt.Checkpoint(&saved); err != nil {
+		t.Fatal(err)
+	}
+	other, err := Compile(parse(t, "+[>-<]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh, err := NewRuntime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fresh.Restore(bytes.NewReader(saved.Bytes()), other); err != ErrProgramMismatch {
+		t.Errorf("error %v restoring into a different program", err)
+	}
+	wide, err := NewRuntime(WithCellWidth(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := Compile(parse(t, "+[>+<]"))
+	if err := wide.Restore(bytes.NewReader(saved.Bytes()), code); err == nil {
+		t.Errorf("restored 8 bit cells into 16 bit ones")
+	}
+}
+
+func TestResume(t *testing.T) {
+	for _, p := range corpus(t, "rot13.bf") {
+		straight, _, err := runProgram(t, p.src, p.input, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, steps := range []uint64{1, 500, 5000} {
+			rt, code, before := stopAt(t, p.src, p.input, steps)
+			var saved bytes.Buffer
+			if err := rt.Checkpoint(&saved); err != nil {
+				t.Fatal(err)
+			}
+			var after bytes.Buffer
+			resumed, err := NewRuntime(WithOutput(&after))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := resumed.Restore(&saved, code); err != nil {
+				t.Fatal(err)
+			}
+			resumed.SetInput(strings.NewReader(p.input[resumed.InputOffset():]))
+			if err := resumed.RunBytecode(code); err != nil {
+				t.Fatal(err)
+			}
+			if got := before + after.String(); got != straight {
+				t.Errorf("%s stopped at step %d: output %q, want %q", p.name, steps, got, straight)
+			}
+			// the step count carries on from the checkpoint
+			if resumed.Steps() <= rt.Steps() {
+				t.Errorf("%s stopped at step %d: resumed run ended at step %d", p.name, steps, resumed.Steps())
+			}
+		}
+	}
+}
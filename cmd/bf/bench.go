@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/timnewsham/gobf"
+)
+
+// benchResult is the report of a -bench run.
+type benchResult struct {
+	Runs int `json:"runs"`
+	Min time.Duration `json:"min_ns"`
+	Median time.Duration `json:"median_ns"`
+	Mean time.Duration `json:"mean_ns"`
+	Steps int64 `json:"steps"`
+	StepsPerSec float64 `json:"steps_per_sec"`
+}
+
+// bench runs prog n times, after a warm-up run, on fresh Runtimes
+// from newRuntime reading input and writing out, and reports the
+// times to w.
+func bench(n int, prog bf.Runner, newRuntime func(io.Reader, io.Writer) (*bf.Runtime, error), input []byte, out io.Writer, asJSON bool, w io.Writer) error {
+	times := make([]time.Duration, 0, n)
+	var steps int64
+	for i := 0; i <= n; i++ {
+		rt, err := newRuntime(bytes.NewReader(input), out)
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		if err := rt.Run(prog); err != nil {
+			return err
+		}
+		if i > 0 {
+			times = append(times, time.Since(start))
+		}
+		steps = rt.Steps()
+	}
+
+	r := benchResult{Runs: n, Steps: steps}
+	var total time.Duration
+	for _, t := range times {
+		total += t
+	}
+	sort.Slice(times, func(a, b int) bool {
+		return times[a] < times[b]
+	})
+	r.Min = times[0]
+	r.Median = times[n/2]
+	r.Mean = total / time.Duration(n)
+	if r.Mean > 0 {
+		r.StepsPerSec = float64(steps) / r.Mean.Seconds()
+	}
+	if asJSON {
+		return json.NewEncoder(w).Encode(r)
+	}
+	_, err := fmt.Fprintf(w, "%d runs: min %v, median %v, mean %v, %d steps, %.0f steps per second\n", r.Runs, r.Min, r.Median, r.Mean, r.Steps, r.StepsPerSec)
+	return err
+}
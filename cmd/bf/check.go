@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/timnewsham/gobf"
+)
+
+// checkMain is the check subcommand, which runs each program name.bf
+// in a directory with the input in name.in, if there is one, and
+// compares its output with name.out and its error with name.err. A
+// program without a name.err must succeed, and one without a
+// name.out must write nothing.
+func checkMain(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	match := fs.String("run", "", "check only the programs whose names match `regexp`")
+	verbose := fs.Bool("v", false, "list each program checked, not only failures")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fmt.Fprintf(stderr, "usage: bf check [-run regexp] [-v] [dir]\n")
+		return 2
+	}
+	dir := "testdata"
+	if fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	}
+	re, err := regexp.Compile(*match)
+	if err != nil {
+		fmt.Fprintf(stderr, "error -run: %v\n", err)
+		return 2
+	}
+	fns, err := filepath.Glob(filepath.Join(dir, "*.bf"))
+	if err == nil && len(fns) == 0 {
+		err = fmt.Errorf("no programs in %s", dir)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "error %v\n", err)
+		return 1
+	}
+
+	failed, checked := 0, 0
+	for _, fn := range fns {
+		name := strings.TrimSuffix(filepath.Base(fn), ".bf")
+		if !re.MatchString(name) {
+			continue
+		}
+		checked++
+		if err := checkProgram(strings.TrimSuffix(fn, ".bf")); err != nil {
+			failed++
+			fmt.Fprintf(stdout, "FAIL %s: %v\n", name, err)
+		} else if *verbose {
+			fmt.Fprintf(stdout, "ok   %s\n", name)
+		}
+	}
+	fmt.Fprintf(stdout, "%d of %d programs failed\n", failed, checked)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// checkProgram runs the program base.bf the way bf would by default,
+// and compares what it does with the files beside it.
+func checkProgram(base string) error {
+	input, err := readOptional(base + ".in")
+	if err != nil {
+		return err
+	}
+	want, err := readOptional(base + ".out")
+	if err != nil {
+		return err
+	}
+	wantErr, err := readOptional(base + ".err")
+	if err != nil {
+		return err
+	}
+
+	prog, err := bf.ParseFile(base + ".bf")
+	var out bytes.Buffer
+	if err == nil {
+		rt := bf.New(bytes.NewReader(input), &out)
+		err = rt.Run(bf.Optimize(prog, bf.AllPasses))
+	}
+	switch {
+	case err != nil && wantErr == nil:
+		return err
+	case err == nil && wantErr != nil:
+		return fmt.Errorf("succeeded, want error %q", strings.TrimSpace(string(wantErr)))
+	case err != nil && !strings.Contains(err.Error(), strings.TrimSpace(string(wantErr))):
+		return fmt.Errorf("error %q, want %q", err, strings.TrimSpace(string(wantErr)))
+	case !bytes.Equal(out.Bytes(), want):
+		return fmt.Errorf("output %q, want %q", out.Bytes(), want)
+	}
+	return nil
+}
+
+// readOptional returns the contents of fn, or nil if it doesn't exist.
+func readOptional(fn string) ([]byte, error) {
+	b, err := os.ReadFile(fn)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return b, err
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/timnewsham/gobf"
+)
+
+// fmtMain is the fmt subcommand, which writes a program with its
+// loops indented to stdout.
+func fmtMain(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	strip := fs.Bool("strip", false, "leave out the comments instead of keeping them on lines of their own")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintf(stderr, "usage: bf fmt [-strip] program\n")
+		return 2
+	}
+	fn := fs.Arg(0)
+	src, err := os.ReadFile(fn)
+	if err == nil {
+		err = bf.Format(stdout, src, *strip)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", fn, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,1042 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/timnewsham/gobf"
+)
+
+// saveProgram writes prog to the program file path.
+func saveProgram(path string, prog bf.Runner) error {
+	var buf bytes.Buffer
+	if err := bf.SaveProgram(&buf, prog); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// resumeRun restores the checkpoint in path into rt and skips the
+// input the program had already read, which must be a file, or nil
+// for stdin.
+func resumeRun(rt *bf.Runtime, prog bf.Runner, path string, input *os.File) error {
+	if input == nil {
+		return errors.New("resuming needs the program's input given with -in")
+	}
+	code, err := bf.Compile(prog)
+	if err != nil {
+		return err
+	}
+	fp, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	if err := rt.Restore(fp, code); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	_, err = input.Seek(rt.InputOffset(), io.SeekStart)
+	return err
+}
+
+// listFlag collects the values of a flag that may be repeated.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
+
+// inputFlag is the -input-string flag, whose value has the escapes \n,
+// \t, \r, \0, \\ and \xNN for any byte.
+type inputFlag struct {
+	input []byte
+	set bool
+}
+
+func (f *inputFlag) String() string {
+	return string(f.input)
+}
+
+func (f *inputFlag) Set(s string) error {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b = append(b, s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return errors.New("ends in an unfinished escape")
+		}
+		switch s[i] {
+		case 'n':
+			b = append(b, '\n')
+		case 't':
+			b = append(b, '\t')
+		case 'r':
+			b = append(b, '\r')
+		case '0':
+			b = append(b, 0)
+		case '\\':
+			b = append(b, '\\')
+		case 'x':
+			if i + 3 > len(s) {
+				return fmt.Errorf("escape \\x%s needs two hex digits", s[i+1:])
+			}
+			n, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+			if err != nil {
+				return fmt.Errorf("escape \\x%s needs two hex digits", s[i+1:i+3])
+			}
+			b = append(b, byte(n))
+			i += 2
+		default:
+			return fmt.Errorf("unknown escape \\%c", s[i])
+		}
+	}
+	f.input, f.set = b, true
+	return nil
+}
+
+// traceFlag is the -trace flag, which may be given alone for text or
+// with a format name.
+type traceFlag struct {
+	format string
+}
+
+func (f *traceFlag) String() string {
+	return f.format
+}
+
+func (f *traceFlag) Set(s string) error {
+	switch s {
+	case "true":
+		f.format = "text"
+	case "false":
+		f.format = ""
+	default:
+		f.format = s
+	}
+	return nil
+}
+
+func (f *traceFlag) IsBoolFlag() bool {
+	return true
+}
+
+// levelPasses returns the optimizer passes for the -O level, or false
+// if there is no such level.
+func levelPasses(level int) (bf.Pass, bool) {
+	switch level {
+	case 0:
+		return 0, true
+	case 1:
+		return bf.PassDeadLoops | bf.PassCoalesce, true
+	case 2:
+		return bf.AllPasses, true
+	}
+	return 0, false
+}
+
+// randomInput returns the input -in random:seed[:n] asks for, n
+// bytes or endless ones from the seed, or nil if name is a file.
+func randomInput(name string) (*bf.RandReader, bool, error) {
+	spec, ok := strings.CutPrefix(name, "random:")
+	if !ok {
+		return nil, false, nil
+	}
+	seed, count, hasCount := strings.Cut(spec, ":")
+	n := int64(-1)
+	var err error
+	if hasCount {
+		n, err = strconv.ParseInt(count, 0, 64)
+		if err == nil && n < 0 {
+			err = errors.New("must not be negative")
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("bad -in random length %q: %v", count, err)
+		}
+	}
+	s, err := strconv.ParseInt(seed, 0, 64)
+	if err != nil {
+		return nil, false, fmt.Errorf("bad -in random seed %q: %v", seed, err)
+	}
+	return bf.NewRandReader(s, n), hasCount, nil
+}
+
+// readInput reads all the input -in names, which for random input
+// must have a length.
+func readInput(name string) ([]byte, error) {
+	r, ended, err := randomInput(name)
+	switch {
+	case err != nil:
+		return nil, err
+	case r == nil:
+		return os.ReadFile(name)
+	case !ended:
+		return nil, errors.New("-in random input is read up front here, so needs a length")
+	}
+	return io.ReadAll(r)
+}
+
+// profileTop is how many instructions -profile, or loops -hot,
+// reports.
+const profileTop = 20
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run is the bf command with arguments args, returning its exit
+// status: 0 for success, 2 for bad usage or flag values, 130 for an
+// interrupt, and 1 for a parse, runtime or any other error.
+// Diagnostics go to stderr, leaving stdout to the program. Output
+// written before an error is kept, including in an -out file.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) (status int) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "fmt":
+			return fmtMain(args[1:], stdout, stderr)
+		case "min":
+			return minMain(args[1:], stdout, stderr)
+		case "check":
+			return checkMain(args[1:], stdout, stderr)
+		case "bench":
+			return benchMain(args[1:], stdout, stderr)
+		}
+	}
+	c := &command{stdin: stdin, stdout: stdout, stderr: stderr}
+	fs := c.flags()
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if err := c.check(); err != nil {
+		fmt.Fprintf(stderr, "error %v\n", err)
+		return 2
+	}
+	if len(c.exprs) == 0 && fs.NArg() == 0 && !c.replMode && c.serve == "" {
+		fs.Usage()
+		return 2
+	}
+	if err := c.setup(); err != nil {
+		fmt.Fprintf(stderr, "error %v\n", err)
+		return 2
+	}
+	if c.serve != "" {
+		return c.runServe()
+	}
+	if status := c.load(); status != 0 {
+		return status
+	}
+	if c.dumpAST || c.dumpIR || c.emit != "" || c.saveIR != "" {
+		return c.runDump()
+	}
+	if !c.verify {
+		// -verify runs it unoptimized before optimizing it
+		c.prog = bf.Optimize(c.prog, c.passes)
+	}
+
+	output, closeOutput, err := c.openOutput()
+	if err != nil {
+		fmt.Fprintf(stderr, "error %v\n", err)
+		return 1
+	}
+	defer func() {
+		if err := closeOutput(); err != nil && status == 0 {
+			fmt.Fprintf(stderr, "error %v\n", err)
+			status = 1
+		}
+	}()
+	if c.jit {
+		if status, ok := c.runJIT(output); ok {
+			return status
+		}
+	}
+	switch {
+	case c.verify:
+		return c.runVerify(output)
+	case c.benchRuns > 0:
+		return c.runBench(output)
+	}
+	return c.runInterpreter(output)
+}
+
+// command is one run of the bf command: its flags, where it reads
+// and writes, and the program as it is loaded.
+type command struct {
+	stdin io.Reader
+	stdout, stderr io.Writer
+	fs *flag.FlagSet
+
+	exprs listFlag
+	dumpAST bool
+	dumpIR bool
+	level int
+	emit string
+	saveIR string
+	compiled bool
+	tape int
+	tapeMode string
+	cells string
+	strict bool
+	bigLow bool
+	maxCells int
+	maxMem int64
+	eof string
+	maxSteps int64
+	timeout time.Duration
+	progress time.Duration
+	profile bool
+	stats bool
+	hot bool
+	cover bool
+	breaks listFlag
+	tui bool
+	debug bool
+	numout bool
+	numoutSep string
+	transcriptFile string
+	replayFile string
+	interactive bool
+	showTape bool
+	hexout bool
+	inputString inputFlag
+	inFile string
+	outFile string
+	watches listFlag
+	dialect string
+	bang bool
+	mapFile string
+	callDepth int
+	ext string
+	checkpoint string
+	saveAt string
+	resume string
+	raw bool
+	trace traceFlag
+	traceLimit int64
+	replMode bool
+	benchRuns int
+	benchJSON bool
+	jit bool
+	detectHangs bool
+	serve string
+	maxOutput int
+	verify bool
+
+	watchCells []int // the cells -watch gives
+	parser bf.Parser
+	passes bf.Pass
+	sep string // what -numout puts between values
+	fn string // the program's name in errors, or "" if its positions name their files
+	src []byte
+	prog bf.Runner
+}
+
+// flags returns the command's flag set, which parses into c.
+func (c *command) flags() *flag.FlagSet {
+	fs := flag.NewFlagSet("bf", flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: bf [flags] program|- [program...]\n       bf [flags] -repl\n       bf [flags] -serve addr\n       bf [flags] -e program\n       bf fmt [-strip] program\n       bf min [flags] program\n       bf check [-run regexp] [-v] [dir]\n       bf bench [flags] [dir]\n\nflags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Var(&c.exprs, "e", "run `program` given on the command line instead of from a file; more than one are joined by newlines")
+	fs.BoolVar(&c.dumpAST, "dump-ast", false, "print the parsed program as a tree, one command per line, instead of running it")
+	fs.BoolVar(&c.dumpIR, "dump-ir", false, "print the bytecode that would run, after optimization, instead of running it")
+	fs.IntVar(&c.level, "O", 2, "optimization `level`: 0 for none, 1 to merge runs of commands, 2 to also rewrite common loops")
+	fs.StringVar(&c.emit, "emit", "", "translate the program to source in `lang` (go, c, wat, js, asm), or tree to show the parsed program, instead of running it")
+	fs.StringVar(&c.saveIR, "save-ir", "", "compile the program to `file`, which bf can run later, instead of running it; the optimizations depend on -cells and -strict-cells, so run it with the same ones")
+	fs.BoolVar(&c.compiled, "compiled", false, "the program is a file written by -save-ir, which is otherwise recognized by its contents")
+	fs.IntVar(&c.tape, "tape", bf.DefaultTapeSize, "number of cells on the tape")
+	fs.StringVar(&c.tapeMode, "tape-mode", "fixed", "what moving off the end of the tape does: fixed (error), grow, wrap, or infinite (grow both ways)")
+	fs.StringVar(&c.cells, "cells", "8", "cell width in bits: 8, 16 or 32, or big for cells that never wrap")
+	fs.BoolVar(&c.strict, "strict-cells", false, "make cell overflow and underflow an error instead of wrapping")
+	fs.BoolVar(&c.bigLow, "big-low-byte", false, "with -cells big, have . write the low byte of values outside 0-255 instead of failing")
+	fs.IntVar(&c.maxCells, "max-tape", bf.MaxTapeSize, "number of cells a growing tape may reach")
+	fs.Int64Var(&c.maxMem, "max-mem", 256 << 20, "number of `bytes` the cells of a growing tape may take, or 0 for no limit")
+	fs.StringVar(&c.eof, "eof", "-1", "what , stores at end of input: 0, -1 (255), or nochange")
+	fs.Int64Var(&c.maxSteps, "max-steps", 0, "stop the program after `n` steps, or 0 for no limit")
+	fs.DurationVar(&c.timeout, "timeout", 0, "stop the program after running for `d`, or 0 for no limit")
+	fs.DurationVar(&c.progress, "progress", 0, "every `d`, print the steps so far, their rate, the command running and the pointer to stderr")
+	fs.BoolVar(&c.profile, "profile", false, "print the most executed commands to stderr after the run")
+	fs.BoolVar(&c.stats, "stats", false, "print a summary of what the run did and how fast to stderr after it, unless tracing")
+	fs.BoolVar(&c.hot, "hot", false, "print the loops that did the most work to stderr after the run")
+	fs.BoolVar(&c.cover, "cover", false, "run the program unoptimized and list the commands that never ran to stderr")
+	fs.Var(&c.breaks, "break", "pause before the command at `line:col`, or only for its first n hits with line:col:n (repeatable)")
+	fs.BoolVar(&c.tui, "tui", false, "run the program in a full-screen view of its tape, source and output, stepping or running it with keys")
+	fs.BoolVar(&c.debug, "debug", false, "start paused in the debugger, reading debugger commands from stdin")
+	fs.BoolVar(&c.numout, "numout", false, "have . write the cell's value in decimal instead of as a byte")
+	fs.StringVar(&c.numoutSep, "numout-sep", "space", "with -numout, what goes between values: space or newline")
+	fs.StringVar(&c.transcriptFile, "transcript", "", "record what the program reads and writes, and at which step, to `file` as JSON lines")
+	fs.StringVar(&c.replayFile, "replay", "", "run the program on the input recorded in a -transcript `file`, failing where its output or reads differ")
+	fs.BoolVar(&c.interactive, "interactive", false, "when the program's input is a terminal, show a prompt on stderr each time , waits for it")
+	fs.BoolVar(&c.showTape, "show-tape", false, "after the run, even one that fails, print the cells from the first that is not zero to the last to stderr, with the pointer marked")
+	fs.BoolVar(&c.hexout, "hexout", false, "write the program's output as a hex dump like xxd's")
+	fs.Var(&c.inputString, "input-string", "give the program `input` inline, with the escapes \\n, \\t, \\r, \\0, \\\\ and \\xNN")
+	fs.StringVar(&c.inFile, "in", "", "read the program's input from `file` instead of stdin, or with random:seed[:n] from n pseudo-random bytes, or endless ones, made from seed")
+	fs.StringVar(&c.outFile, "out", "", "write the program's output to `file` instead of stdout, keeping whatever it wrote if it fails")
+	fs.Var(&c.watches, "watch", "report each change to the logical `cell`, pausing if debugging (repeatable)")
+	fs.StringVar(&c.dialect, "dialect", "", "the language `variant`: bf, pbrain for ( ) procedures and : calls, brainfork for Y to fork a thread, ebf1 for @ to halt and $ and ! to store and retrieve a cell, or ook for Ook!, the default for .ook files")
+	fs.BoolVar(&c.bang, "bang", false, "end the program at the first ! outside a loop, unless ! is a command as it is with -dialect=ebf1, giving what follows it to the program as input")
+	fs.StringVar(&c.mapFile, "map", "", "read the program with the eight commands spelled as the tokens in `file`, one per line in the order > < + - . , [ ] or as lines like +=token")
+	fs.IntVar(&c.callDepth, "call-depth", bf.DefaultMaxCallDepth, "with -dialect=pbrain, stop the program if procedure calls nest more than `n` deep")
+	fs.StringVar(&c.ext, "ext", "", "enable language `extensions`, separated by commas: debug, for # to dump the tape to stderr, counts, for a count before + - < or > as in 65+, and include, for %include \"file\" lines")
+	fs.StringVar(&c.checkpoint, "checkpoint-on-signal", "", "write a checkpoint of the run to `file` on SIGUSR1")
+	fs.StringVar(&c.saveAt, "checkpoint", "", "if the run stops early from -max-steps, -timeout or an interrupt, write a checkpoint to `file`")
+	fs.StringVar(&c.resume, "resume", "", "continue the run checkpointed in `file`, which needs -in with the same input")
+	fs.BoolVar(&c.raw, "raw", false, "if input is a terminal, pass each keypress to the program without waiting for Enter")
+	fs.Var(&c.trace, "trace", "print each command to stderr as it runs, as text or, with -trace=json, as JSON lines")
+	fs.Int64Var(&c.traceLimit, "trace-limit", 0, "stop tracing after `n` lines, or 0 for no limit")
+	fs.BoolVar(&c.replMode, "repl", false, "read and run a line of commands at a time from stdin, keeping the tape between lines")
+	fs.IntVar(&c.benchRuns, "bench", 0, "run the program `n` times after a warm-up run and report how long it took to stderr")
+	fs.BoolVar(&c.benchJSON, "bench-json", false, "with -bench, report as a JSON object")
+	fs.BoolVar(&c.jit, "jit", false, "compile the program to a Go plugin and run that")
+	fs.BoolVar(&c.detectHangs, "detect-hangs", false, "stop with an error when the program enters a loop that can never end, such as [] or [<>], instead of running forever")
+	fs.StringVar(&c.serve, "serve", "", "listen on `addr` for programs to run, sent as JSON by POST to /run, within -max-steps, -timeout and -max-output")
+	fs.IntVar(&c.maxOutput, "max-output", 1 << 20, "with -serve, stop a program that writes more than `n` bytes")
+	fs.BoolVar(&c.verify, "verify", false, "run the program both unoptimized and optimized, and report it to stderr if they differ in output, tape or error")
+	c.fs = fs
+	return fs
+}
+
+// check returns an error for flags that can't be used together or
+// have bad values.
+func (c *command) check() error {
+	fs := c.fs
+	switch {
+	case len(c.exprs) > 0 && fs.NArg() != 0:
+		return errors.New("-e and a program file can't be given together")
+	case c.replMode && (len(c.exprs) > 0 || fs.NArg() > 0):
+		return errors.New("-repl reads the program from stdin, so takes no other")
+	case c.inputString.set && (c.inFile != "" || c.bang || c.replMode || c.debug || c.raw || c.replayFile != "" || c.serve != ""):
+		return errors.New("-input-string gives the program's input, so can't be used with -in, -bang, -repl, -debug, -raw, -replay or -serve")
+	case c.bang && (c.inFile != "" || c.replMode || c.debug || c.raw):
+		return errors.New("-bang takes the input from after the program, so can't be used with -in, -repl, -debug or -raw")
+	case c.verify && (c.trace.format != "" || c.debug || len(c.breaks) > 0 || len(c.watches) > 0 || c.cover || c.replMode || c.resume != "" || c.benchRuns > 0 || c.jit || c.dumpAST || c.dumpIR || c.emit != "" || c.saveIR != "" || c.detectHangs):
+		return errors.New("-verify runs the program, so can't be used with -trace, -debug, -break, -watch, -cover, -repl, -resume, -bench, -jit, -dump-ast, -dump-ir, -emit, -save-ir or -detect-hangs")
+	case c.serve != "" && (len(c.exprs) > 0 || fs.NArg() > 0 || c.replMode || c.inFile != "" || c.outFile != "" || c.bang || c.debug || c.raw || c.trace.format != ""):
+		return errors.New("-serve runs the programs it is sent, so takes no other and can't be used with -repl, -in, -out, -bang, -debug, -raw or -trace")
+	case c.tui && (c.debug || len(c.breaks) > 0 || c.replMode || c.jit || c.verify || c.benchRuns > 0 || c.serve != "" || c.trace.format != "" || c.progress > 0 || c.raw || c.interactive):
+		return errors.New("-tui takes over the terminal, so can't be used with -debug, -break, -repl, -jit, -verify, -bench, -serve, -trace, -progress, -raw or -interactive")
+	case c.progress < 0:
+		return fmt.Errorf("-progress %v must not be negative", c.progress)
+	case c.transcriptFile != "" && (c.jit || c.numout || c.verify || c.benchRuns > 0 || c.serve != ""):
+		return errors.New("-transcript records a single run by the interpreter, so can't be used with -jit, -numout, -verify, -bench or -serve")
+	case c.replayFile != "" && (c.jit || c.numout || c.verify || c.benchRuns > 0 || c.serve != "" || c.replMode || c.inFile != "" || c.bang || c.debug):
+		return errors.New("-replay takes the input from the transcript for one run by the interpreter, so can't be used with -jit, -numout, -verify, -bench, -serve, -repl, -in, -bang or -debug")
+	case c.mapFile != "" && c.dialect != "":
+		return errors.New("-map and -dialect can't be given together")
+	case c.numout && c.jit:
+		return errors.New("-numout needs the interpreter, so can't be used with -jit")
+	case c.jit && c.given("tape", "tape-mode", "cells", "strict-cells", "eof", "max-steps", "timeout"):
+		// the plugin has none of the interpreter's runtime options
+		return errors.New("-jit runs the program on a fixed 30000 cell tape of bytes with no limits, so can't be used with -tape, -tape-mode, -cells, -strict-cells, -eof, -max-steps or -timeout")
+	case fs.Arg(0) == "-" && (c.debug || c.raw):
+		return errors.New("-debug and -raw need stdin, which is the program")
+	case c.debug && c.inFile == "":
+		// debugger commands and program input can't share stdin
+		return errors.New("-debug reads commands from stdin, so give the program's input with -in")
+	}
+	randIn, _, err := randomInput(c.inFile)
+	if err != nil {
+		return err
+	}
+	if randIn != nil && c.resume != "" {
+		return errors.New("-resume needs the program's input from a file, not random")
+	}
+	for _, w := range c.watches {
+		cell, err := strconv.Atoi(w)
+		if err != nil {
+			return fmt.Errorf("bad watch cell %q: %v", w, err)
+		}
+		c.watchCells = append(c.watchCells, cell)
+	}
+	return nil
+}
+
+// given reports whether any of the flags named was set on the command
+// line, even to its default.
+func (c *command) given(names ...string) bool {
+	set := false
+	c.fs.Visit(func(f *flag.Flag) {
+		for _, name := range names {
+			if f.Name == name {
+				set = true
+			}
+		}
+	})
+	return set
+}
+
+// setup makes the parser and works out the optimizer passes the flags
+// ask for.
+func (c *command) setup() error {
+	var err error
+	c.parser = bf.Parser{Bang: c.bang}
+	if c.mapFile != "" {
+		c.parser.Map, err = bf.LoadTokenMap(c.mapFile)
+		if err != nil {
+			return fmt.Errorf("-map %s: %v", c.mapFile, err)
+		}
+	}
+	if c.dialect == "" {
+		c.dialect = "bf"
+		if c.parser.Map == nil && filepath.Ext(c.fs.Arg(0)) == ".ook" {
+			c.dialect = "ook"
+		}
+	}
+	c.parser.Dialect, err = bf.ParseDialect(c.dialect)
+	if err != nil {
+		return err
+	}
+	for _, name := range strings.Split(c.ext, ",") {
+		switch name {
+		case "":
+		case "debug":
+			c.parser.Debug = true
+		case "counts":
+			c.parser.Counts = true
+		case "include":
+			c.parser.Includes = true
+		default:
+			return fmt.Errorf("unknown extension %q", name)
+		}
+	}
+	if c.serve != "" && (c.parser.Includes || c.parser.Debug) {
+		return errors.New("-serve can't be used with -ext=include or -ext=debug, which would reach the server's files and stderr")
+	}
+	if c.serve != "" && c.parser.Dialect == bf.DialectBrainfork {
+		// each thread counts its own steps and memory
+		return errors.New("-serve can't be used with -dialect=brainfork, whose threads would each get the whole step and memory limits")
+	}
+	var ok bool
+	c.sep, ok = map[string]string{"space": " ", "newline": "\n"}[c.numoutSep]
+	if !ok {
+		return errors.New("-numout-sep must be space or newline")
+	}
+	c.passes, ok = levelPasses(c.level)
+	if !ok {
+		return errors.New("-O must be 0, 1 or 2")
+	}
+	if c.cells == "big" {
+		c.passes &= bf.ExactPasses
+	} else if c.strict {
+		c.passes &= bf.StrictPasses
+	}
+	if c.cover || c.debug || c.tui || len(c.breaks) > 0 || len(c.watches) > 0 {
+		c.passes = 0
+	}
+	if c.detectHangs {
+		c.passes |= bf.PassHangs
+	}
+	return nil
+}
+
+// newRuntime makes a Runtime configured by the flags.
+func (c *command) newRuntime(input io.Reader, output io.Writer) (*bf.Runtime, error) {
+	opts := []bf.Option{bf.WithTapeSize(c.tape), bf.WithInput(input), bf.WithOutput(output)}
+	if c.trace.format != "" {
+		opts = append(opts, bf.WithTrace(c.stderr))
+	}
+	rt, err := bf.NewRuntime(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if c.trace.format != "" {
+		if err := rt.SetTraceFormat(c.trace.format); err != nil {
+			return nil, err
+		}
+		rt.SetTraceLimit(c.traceLimit)
+	}
+	if c.cells == "big" {
+		rt.SetBigCells(c.bigLow)
+	} else {
+		bits, err := strconv.Atoi(c.cells)
+		if err == nil {
+			err = rt.SetCellWidth(bits)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bad cell width %q: %v", c.cells, err)
+		}
+	}
+	rt.SetStrictCells(c.strict)
+	if err := rt.SetEOFMode(c.eof); err != nil {
+		return nil, err
+	}
+	if err := rt.SetMaxSteps(c.maxSteps); err != nil {
+		return nil, err
+	}
+	if err := rt.SetTapeMode(c.tapeMode, c.maxCells); err != nil {
+		return nil, err
+	}
+	if err := rt.SetMaxCallDepth(c.callDepth); err != nil {
+		return nil, err
+	}
+	if err := rt.SetMaxMemory(c.maxMem); err != nil {
+		return nil, err
+	}
+	if c.numout {
+		rt.SetNumericOutput(c.sep)
+	}
+	return rt, nil
+}
+
+// runServe serves programs over HTTP until the server fails.
+func (c *command) runServe() int {
+	if err := serveHTTP(c.serve, c.parser, c.passes, c.newRuntime, c.maxSteps, c.timeout, c.maxOutput, c.stderr); err != nil {
+		fmt.Fprintf(c.stderr, "error %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// load reads and parses the program and works out where its input
+// comes from, returning a status other than 0 if it can't.
+func (c *command) load() int {
+	fs, stderr := c.fs, c.stderr
+	var err error
+	c.fn = "<cmdline>"
+	if c.replMode {
+		c.fn = "<repl>"
+	} else if len(c.exprs) > 0 {
+		c.src = []byte(strings.Join(c.exprs, "\n"))
+	} else if fs.Arg(0) == "-" {
+		// the program takes stdin, leaving its input to -in
+		c.fn = "<stdin>"
+		c.src, err = io.ReadAll(c.stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "error reading the program: %v\n", err)
+			return 1
+		}
+		c.stdin = strings.NewReader("")
+	} else if fs.NArg() > 1 || c.parser.Includes {
+		// positions name their own files
+		c.fn = ""
+		if c.cover {
+			fmt.Fprintf(stderr, "error -cover needs a single program file, without includes\n")
+			return 2
+		}
+	} else {
+		c.fn = fs.Arg(0)
+		c.src, err = os.ReadFile(c.fn)
+		if err != nil {
+			fmt.Fprintf(stderr, "error %v\n", err)
+			return 1
+		}
+	}
+	if c.compiled || bf.IsProgram(c.src) {
+		if c.trace.format != "" || c.cover || c.jit || c.emit != "" || c.saveIR != "" || c.dumpAST || c.bang || c.verify || c.tui {
+			fmt.Fprintf(stderr, "error -trace, -cover, -jit, -emit, -save-ir, -dump-ast, -bang, -verify and -tui need the program's source\n")
+			return 2
+		}
+		c.prog, err = bf.LoadProgram(bytes.NewReader(c.src))
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %s\n", c.fn, err)
+			return 1
+		}
+	} else {
+		if c.fn == "" {
+			c.prog, err = c.parser.ParseFiles(fs.Args()...)
+		} else {
+			c.prog, err = c.parser.ParseBytes(c.src)
+		}
+		if err != nil {
+			errs := []error{err}
+			if joined, ok := err.(interface{ Unwrap() []error }); ok {
+				errs = joined.Unwrap()
+			}
+			for _, err := range errs {
+				if c.fn == "" {
+					fmt.Fprintf(stderr, "%s\n", err)
+				} else {
+					fmt.Fprintf(stderr, "%s: %s\n", c.fn, err)
+				}
+			}
+			return 1
+		}
+	}
+	reads := bf.CountNodes(c.prog)["Getchar"] > 0
+	if c.bang {
+		c.stdin = c.parser.Rest()
+		if c.stdin == nil {
+			c.stdin = strings.NewReader("")
+		}
+	} else if c.inputString.set {
+		c.stdin = bytes.NewReader(c.inputString.input)
+	} else if c.fn == "<stdin>" && c.inFile == "" && reads {
+		fmt.Fprintf(stderr, "error the program reads input with , but came from stdin, so give its input with -in\n")
+		return 2
+	}
+	if term, ok := c.stdin.(*os.File); ok && c.tui && c.inFile == "" && isTerminal(term) && reads {
+		fmt.Fprintf(stderr, "error -tui reads keys from the terminal, so give the program's input with -in or -input-string\n")
+		return 2
+	}
+	return 0
+}
+
+// runDump writes the program as -dump-ast, -dump-ir, -emit or
+// -save-ir asks instead of running it.
+func (c *command) runDump() int {
+	var err error
+	switch {
+	case c.dumpAST:
+		err = bf.DumpTree(c.stdout, c.prog)
+	case c.dumpIR:
+		var code []bf.Instruction
+		code, err = bf.Compile(bf.Optimize(c.prog, c.passes))
+		if err == nil {
+			err = bf.WriteCode(c.stdout, code)
+		}
+	case c.emit != "":
+		if err := bf.Emit(c.stdout, c.emit, bf.Optimize(c.prog, c.passes)); err != nil {
+			fmt.Fprintf(c.stderr, "%s: %s\n", c.fn, err)
+			return 1
+		}
+	default:
+		err = saveProgram(c.saveIR, bf.Optimize(c.prog, c.passes))
+	}
+	if err != nil {
+		fmt.Fprintf(c.stderr, "error %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// openOutput returns where the program's output goes, to -out and
+// through -hexout if they are given, and a function that finishes
+// writing it.
+func (c *command) openOutput() (io.Writer, func() error, error) {
+	output := c.stdout
+	var closers []io.Closer
+	if c.outFile != "" {
+		fp, err := os.Create(c.outFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		output = fp
+		closers = append(closers, fp)
+	}
+	if c.hexout {
+		hex := bf.NewHexWriter(output)
+		output = hex
+		closers = append(closers, hex)
+	}
+	closeOutput := func() error {
+		var err error
+		for i := len(closers) - 1; i >= 0; i-- {
+			if cerr := closers[i].Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}
+	return output, closeOutput, nil
+}
+
+// runJIT runs the program as a Go plugin, or returns false, having
+// warned, if it can't be made so the interpreter should run it.
+func (c *command) runJIT(output io.Writer) (int, bool) {
+	jitRun, err := bf.JIT(c.prog)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "warning: jit unavailable, using the interpreter: %v\n", err)
+		return 0, false
+	}
+	if err := jitRun(c.stdin, output); err != nil {
+		fmt.Fprintf(c.stderr, "error %v\n", err)
+		return 1, true
+	}
+	return 0, true
+}
+
+// runVerify runs the program unoptimized and optimized and reports
+// where they differ.
+func (c *command) runVerify(output io.Writer) int {
+	// both runs need the same input, so it is read up front
+	var input []byte
+	var err error
+	if c.inFile != "" {
+		input, err = readInput(c.inFile)
+	} else if bf.CountNodes(c.prog)["Getchar"] > 0 {
+		input, err = io.ReadAll(c.stdin)
+	}
+	if err != nil {
+		fmt.Fprintf(c.stderr, "error reading input: %v\n", err)
+		return 1
+	}
+	if err := bf.Verify(c.prog, c.passes, c.newRuntime, input, output); err != nil {
+		fmt.Fprintf(c.stderr, "error %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runBench times -bench runs of the program.
+func (c *command) runBench(output io.Writer) int {
+	var input []byte
+	if c.inFile != "" {
+		var err error
+		input, err = readInput(c.inFile)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "error -in: %v\n", err)
+			return 1
+		}
+	} else if c.bang || c.inputString.set {
+		input, _ = io.ReadAll(c.stdin)
+	} else if bf.CountNodes(c.prog)["Getchar"] > 0 {
+		fmt.Fprintf(c.stderr, "error -bench runs the program more than once, so its input must be given with -in or -input-string\n")
+		return 2
+	}
+	benchOut := io.Discard
+	if c.outFile != "" {
+		benchOut = output
+	}
+	if err := bench(c.benchRuns, c.prog, c.newRuntime, input, benchOut, c.benchJSON, c.stderr); err != nil {
+		fmt.Fprintf(c.stderr, "error %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runInterpreter runs the program on a Runtime with what the flags put
+// around it, in the REPL or the full-screen view if they ask.
+func (c *command) runInterpreter(output io.Writer) (status int) {
+	stderr := c.stderr
+	// -tui shows the output as it comes and writes it out after
+	var tuiOut bytes.Buffer
+	runOutput := output
+	if c.tui {
+		runOutput = &tuiOut
+	}
+	rt, err := c.newRuntime(c.stdin, runOutput)
+	if err != nil {
+		fmt.Fprintf(stderr, "error %v\n", err)
+		return 2
+	}
+	restore := func() {}
+	if c.raw {
+		term, ok := c.stdin.(*os.File)
+		if !ok {
+			fmt.Fprintf(stderr, "error -raw needs input from a terminal\n")
+			return 2
+		}
+		restore, err = rawTerminal(term)
+		if err != nil {
+			fmt.Fprintf(stderr, "error %v\n", err)
+			return 1
+		}
+		defer restore()
+	}
+	if c.profile {
+		rt.EnableProfile()
+	}
+	if c.hot {
+		rt.EnableHotLoops()
+	}
+	if c.stats {
+		rt.EnableStats()
+	}
+	if c.cover {
+		rt.EnableCoverage()
+	}
+	closers, status := c.attach(rt)
+	defer func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			if err := closers[i].Close(); err != nil && status == 0 {
+				fmt.Fprintf(stderr, "error %v\n", err)
+				status = 1
+			}
+		}
+	}()
+	if status != 0 {
+		return status
+	}
+	if c.replMode {
+		lines := bufio.NewReader(c.stdin)
+		if c.inFile == "" {
+			rt.SetInput(lines)
+		}
+		// the tape isn't clear at the start of each line
+		return repl(rt, &c.parser, c.passes &^ bf.PassDeadLoops, lines, stderr, restore)
+	}
+	interrupted, stop := interruptContext(context.Background(), restore)
+	defer stop()
+	ctx := interrupted
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	if c.tui {
+		tty, err := openTTY()
+		if err != nil {
+			fmt.Fprintf(stderr, "error -tui needs a terminal: %v\n", err)
+			return 1
+		}
+		defer tty.Close()
+		err = runTUI(ctx, rt, c.prog, c.src, &tuiOut, tty)
+		if _, werr := output.Write(tuiOut.Bytes()); err == nil && werr != nil {
+			err = werr
+		}
+		return c.finish(rt, err, interrupted)
+	}
+	stopProgress := func() {}
+	if c.progress > 0 {
+		term, ok := stderr.(*os.File)
+		stopProgress = reportProgress(rt, c.progress, stderr, ok && isTerminal(term))
+	}
+	err = rt.RunContext(ctx, c.prog)
+	stopProgress()
+	return c.finish(rt, err, interrupted)
+}
+
+// attach sets up rt's input, the checkpoint it resumes, its debugger,
+// watches and transcripts, returning the files to close after the run
+// and a status other than 0 if any of it fails.
+func (c *command) attach(rt *bf.Runtime) ([]io.Closer, int) {
+	stderr := c.stderr
+	var closers []io.Closer
+	var input *os.File
+	randIn, _, _ := randomInput(c.inFile)
+	if randIn != nil {
+		rt.SetInput(randIn)
+	} else if c.inFile != "" {
+		var err error
+		input, err = os.Open(c.inFile)
+		if os.IsNotExist(err) {
+			fmt.Fprintf(stderr, "error -in file %s does not exist\n", c.inFile)
+			return closers, 1
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "error -in: %v\n", err)
+			return closers, 1
+		}
+		closers = append(closers, input)
+		rt.SetInput(input)
+	}
+	if term, ok := c.stdin.(*os.File); ok && c.interactive && c.inFile == "" && !c.bang && !c.replMode && isTerminal(term) {
+		rt.SetInputPrompt("input> ", stderr)
+	}
+	if c.resume != "" {
+		if err := resumeRun(rt, c.prog, c.resume, input); err != nil {
+			fmt.Fprintf(stderr, "error %v\n", err)
+			return closers, 1
+		}
+	}
+	if c.debug || len(c.breaks) > 0 {
+		var d *bf.Debugger
+		if c.debug {
+			d = bf.NewDebugger(rt, c.stdin, stderr)
+			d.Step()
+		} else {
+			tty, err := os.Open("/dev/tty")
+			if err != nil {
+				fmt.Fprintf(stderr, "error breakpoints need a terminal: %v\n", err)
+				return closers, 1
+			}
+			closers = append(closers, tty)
+			d = bf.NewDebugger(rt, tty, stderr)
+		}
+		for _, b := range c.breaks {
+			if err := d.Break(b); err != nil {
+				fmt.Fprintf(stderr, "error %v\n", err)
+				return closers, 2
+			}
+		}
+	}
+	if len(c.watchCells) > 0 {
+		rt.Watch(c.watchCells, stderr)
+	}
+	if c.transcriptFile != "" {
+		fp, err := os.Create(c.transcriptFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "error %v\n", err)
+			return closers, 1
+		}
+		closers = append(closers, fp)
+		rt.RecordTranscript(fp)
+	}
+	if c.replayFile != "" {
+		fp, err := os.Open(c.replayFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "error %v\n", err)
+			return closers, 1
+		}
+		events, err := bf.ReadTranscript(fp)
+		fp.Close()
+		if err != nil {
+			fmt.Fprintf(stderr, "error %s: %v\n", c.replayFile, err)
+			return closers, 1
+		}
+		rt.Replay(events)
+	}
+	if c.checkpoint != "" {
+		if err := checkpointOnSignal(rt, c.checkpoint); err != nil {
+			fmt.Fprintf(stderr, "error %v\n", err)
+			return closers, 1
+		}
+	}
+	return closers, 0
+}
+
+// finish reports how a run that ended with err went, writes what
+// -show-tape, -profile, -hot, -stats and -cover ask for, even after an
+// interrupt, and returns the exit status.
+func (c *command) finish(rt *bf.Runtime, err error, interrupted context.Context) int {
+	stderr := c.stderr
+	status := 0
+	if err != nil && !errors.Is(err, bf.ErrQuit) {
+		var limit *bf.StepLimitError
+		if c.saveAt != "" && (errors.As(err, &limit) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+			if err := rt.SaveCheckpoint(c.saveAt); err != nil {
+				fmt.Fprintf(stderr, "error %v\n", err)
+			}
+		}
+		if errors.Is(err, context.Canceled) && interrupted.Err() != nil {
+			fmt.Fprintf(stderr, "error interrupted at %+v\n", rt.Position())
+			status = 130
+		} else {
+			fmt.Fprintf(stderr, "error %v\n", err)
+			status = 1
+		}
+		rt.DumpState(stderr, rt.Pointer())
+	}
+	if c.showTape {
+		rt.ShowTape(stderr)
+	}
+	if c.profile {
+		rt.Profile().Write(stderr, profileTop)
+	}
+	if c.hot {
+		rt.HotLoops().Write(stderr, profileTop)
+	}
+	if c.stats {
+		rt.Stats().Write(stderr)
+	}
+	if c.cover {
+		if err := rt.Coverage().Write(stderr, c.src); err != nil {
+			fmt.Fprintf(stderr, "error %v\n", err)
+			if status == 0 {
+				status = 1
+			}
+		}
+	}
+	return status
+}
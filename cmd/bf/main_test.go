@@ -0,0 +1,444 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/timnewsham/gobf"
+)
+
+// testdata is the repository's sample programs.
+const testdata = "../../testdata/"
+
+// runBF runs the bf command with args on stdin and returns its exit
+// status and what it wrote to stdout and stderr.
+func runBF(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+func TestRunHello(t *testing.T) {
+	status, out, errs := runBF(t, "", testdata + "hello.bf")
+	if status != 0 || out != "Hello World!\n" || errs != "" {
+		t.Errorf("status %d, output %q, stderr %q", status, out, errs)
+	}
+}
+
+func TestBadFlag(t *testing.T) {
+	status, out, errs := runBF(t, "", "-no-such-flag", testdata + "hello.bf")
+	if status != 2 || out != "" || !strings.Contains(errs, "flag provided but not defined: -no-such-flag") || !strings.Contains(errs, "usage: bf") {
+		t.Errorf("status %d, output %q, stderr %q", status, out, errs)
+	}
+	status, _, errs = runBF(t, "", "-tape", "0", testdata + "hello.bf")
+	if status != 2 || errs == "" {
+		t.Errorf("-tape 0: status %d, stderr %q", status, errs)
+	}
+	if status, _, errs = runBF(t, ""); status != 2 || !strings.Contains(errs, "usage: bf") {
+		t.Errorf("no program: status %d, stderr %q", status, errs)
+	}
+}
+
+func TestMissingFile(t *testing.T) {
+	status, out, errs := runBF(t, "", testdata + "no-such-file.bf")
+	if status != 1 || out != "" || !strings.Contains(errs, "no-such-file.bf") {
+		t.Errorf("status %d, output %q, stderr %q", status, out, errs)
+	}
+}
+
+func TestInlineProgram(t *testing.T) {
+	status, out, errs := runBF(t, "abc", "-eof", "0", "-e", ",[.,]")
+	if status != 0 || out != "abc" || errs != "" {
+		t.Errorf("status %d, output %q, stderr %q", status, out, errs)
+	}
+	// more than one -e are joined by newlines
+	status, out, _ = runBF(t, "", "-e", "++++++++[>++++++++<-]", "-e", ">+.")
+	if status != 0 || out != "A" {
+		t.Errorf("joined: status %d, output %q", status, out)
+	}
+	status, _, errs = runBF(t, "", "-e", "+", "-e", "]")
+	if status != 1 || errs != "<cmdline>: 2:1: unexpected close bracket\n" {
+		t.Errorf("syntax error: status %d, stderr %q", status, errs)
+	}
+	status, _, errs = runBF(t, "", "-e", "+", testdata + "hello.bf")
+	if status != 2 || errs != "error -e and a program file can't be given together\n" {
+		t.Errorf("with a file: status %d, stderr %q", status, errs)
+	}
+}
+
+func TestProgramFromStdin(t *testing.T) {
+	status, out, errs := runBF(t, "+++.", "-")
+	if status != 0 || out != "\x03" || errs != "" {
+		t.Errorf("status %d, output %q, stderr %q", status, out, errs)
+	}
+	in := filepath.Join(t.TempDir(), "in")
+	if err := os.WriteFile(in, []byte("Z"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	status, out, errs = runBF(t, ",.", "-in", in, "-")
+	if status != 0 || out != "Z" || errs != "" {
+		t.Errorf("with -in: status %d, output %q, stderr %q", status, out, errs)
+	}
+	status, _, errs = runBF(t, ",.", "-")
+	if status != 2 || !strings.Contains(errs, "give its input with -in") {
+		t.Errorf("reading without -in: status %d, stderr %q", status, errs)
+	}
+	status, _, errs = runBF(t, "+]", "-")
+	if status != 1 || errs != "<stdin>: 1:2: unexpected close bracket\n" {
+		t.Errorf("syntax error: status %d, stderr %q", status, errs)
+	}
+}
+
+func TestMultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	// brackets balance across the files, not within each one
+	open, body := write("open.b", "+++++++++["), write("body.b", ">+++++++<-]>.")
+	status, out, errs := runBF(t, "", open, body)
+	if status != 0 || out != "?" || errs != "" {
+		t.Errorf("status %d, output %q, stderr %q", status, out, errs)
+	}
+	part1, part2 := write("part1.b", "++\n"), write("part2.b", "+\n\n+]\n")
+	status, _, errs = runBF(t, "", part1, part2)
+	if want := part2 + ":3:2: unexpected close bracket\n"; status != 1 || errs != want {
+		t.Errorf("error in second file: status %d, stderr %q, want %q", status, errs, want)
+	}
+}
+
+func TestInputFile(t *testing.T) {
+	want, err := os.ReadFile(testdata + "rot13.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, out, errs := runBF(t, "not this", "-in", testdata + "rot13.in", testdata + "rot13.bf")
+	if status != 0 || out != string(want) || errs != "" {
+		t.Errorf("status %d, output %q, stderr %q, want output %q", status, out, errs, want)
+	}
+	status, out, _ = runBF(t, "", "-in", testdata + "rot13.in", "-eof", "0", "-e", ",.,.")
+	if status != 0 || out != "He" {
+		t.Errorf("with -e: status %d, output %q", status, out)
+	}
+	status, _, errs = runBF(t, "", "-in", testdata + "no-such.in", testdata + "rot13.bf")
+	if status != 1 || !strings.Contains(errs, "-in file " + testdata + "no-such.in does not exist") {
+		t.Errorf("missing file: status %d, stderr %q", status, errs)
+	}
+}
+
+func TestOutputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out")
+	if err := os.WriteFile(path, []byte("old contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	status, out, errs := runBF(t, "", "-out", path, testdata + "hello.bf")
+	if got, _ := os.ReadFile(path); status != 0 || out != "" || errs != "" || string(got) != "Hello World!\n" {
+		t.Errorf("status %d, stdout %q, stderr %q, file %q", status, out, errs, got)
+	}
+	// output before a runtime error is kept, the error goes to stderr only
+	status, out, errs = runBF(t, "", "-out", path, "-tape", "2", "-trace", "-e", "+++++++++.>>>>>")
+	if got, _ := os.ReadFile(path); status != 1 || out != "" || string(got) != "\t" {
+		t.Errorf("runtime error: status %d, stdout %q, file %q", status, out, got)
+	}
+	if !strings.Contains(errs, "out of range for 2 cell tape") {
+		t.Errorf("runtime error: stderr %q", errs)
+	}
+}
+
+func TestREPL(t *testing.T) {
+	// the first line leaves its loop open, the next closes it; the
+	// tape and pointer are kept for the lines after
+	script := "++++++++[>++++++++\n<-]>+.\n+.\n]\n>.\n"
+	status, out, errs := runBF(t, script, "-repl")
+	if status != 0 || out != "AB\x00" {
+		t.Errorf("status %d, output %q", status, out)
+	}
+	want := "bf> ... bf> bf> 1:1: unexpected close bracket\nbf> bf> \n"
+	if errs != want {
+		t.Errorf("stderr %q, want %q", errs, want)
+	}
+	if status, _, errs = runBF(t, "", "-repl", "-e", "+"); status != 2 || !strings.Contains(errs, "-repl reads the program from stdin") {
+		t.Errorf("with -e: status %d, stderr %q", status, errs)
+	}
+}
+
+// golden checks that running bf with args writes exactly the contents
+// of testdata/dump/name to stdout.
+func golden(t *testing.T, name string, args ...string) {
+	t.Helper()
+	want, err := os.ReadFile(testdata + "dump/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, out, errs := runBF(t, "", args...)
+	if status != 0 || errs != "" {
+		t.Fatalf("%s: status %d, stderr %q", name, status, errs)
+	}
+	if out != string(want) {
+		t.Errorf("%s: got:\n%s\nwant:\n%s", name, out, want)
+	}
+}
+
+func TestDumpAST(t *testing.T) {
+	// nested has loops six deep
+	golden(t, "nested.ast", "-dump-ast", testdata + "nested.bf")
+	golden(t, "rot13.ast", "-dump-ast", testdata + "rot13.bf")
+	if status, out, _ := runBF(t, "", "-dump-ast", "-e", "+."); status != 0 || out != "+ at 1:1\n. at 1:2\n" {
+		t.Errorf("dump ran the program: status %d, output %q", status, out)
+	}
+}
+
+func TestHexout(t *testing.T) {
+	golden(t, "hello.hex", "-hexout", testdata + "hello.bf")
+	// the partial line is written when the run fails
+	status, out, _ := runBF(t, "", "-hexout", "-e", "+.<")
+	if want := "00000000: 01" + strings.Repeat(" ", 39) + ".\n"; status != 1 || out != want {
+		t.Errorf("failing run: status %d, output %q, want %q", status, out, want)
+	}
+}
+
+func TestDumpIR(t *testing.T) {
+	for _, level := range []string{"0", "1", "2"} {
+		golden(t, "rot13.O" + level + ".ir", "-O", level, "-dump-ir", testdata + "rot13.bf")
+	}
+}
+
+func TestInterruptReports(t *testing.T) {
+	var stderr bytes.Buffer
+	c := &command{stderr: &stderr}
+	if err := c.flags().Parse([]string{"-show-tape", "-stats", "-profile", "-hot", "-e", "+[>+<]"}); err != nil {
+		t.Fatal(err)
+	}
+	rt, err := c.newRuntime(strings.NewReader(""), io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt.EnableStats()
+	rt.EnableProfile()
+	rt.EnableHotLoops()
+	prog, err := bf.Parse(strings.NewReader("+[>+<]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	interrupted, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = rt.RunContext(interrupted, prog)
+	if status := c.finish(rt, err, interrupted); status != 130 {
+		t.Errorf("status %d, want 130", status)
+	}
+	errs := stderr.String()
+	for _, want := range []string{"error interrupted at ", "instructions in ", "read 0 bytes, wrote 0"} {
+		if !strings.Contains(errs, want) {
+			t.Errorf("stderr %q does not have %q", errs, want)
+		}
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	tests := []struct {
+		args []string
+		status int
+		out string
+		errs string
+	}{
+		{[]string{"-e", "++."}, 0, "\x02", ""},
+		{[]string{"-O", "3", "-e", "+"}, 2, "", "error -O must be 0, 1 or 2\n"},
+		{[]string{"-e", "+[", "-e", "+"}, 1, "", "<cmdline>: 1:2: unclosed open bracket\n"},
+		// output before a runtime error stays on stdout, the error goes to stderr
+		{[]string{"-tape", "2", "-e", "+.>>>"}, 1, "\x01", "error position 3 (moving +3 from 0) is out of range for 2 cell tape at 1:3\n"},
+		{[]string{"-detect-hangs", "-e", "+[<>]"}, 1, "", "error infinite loop detected at 1:2\n"},
+		{[]string{"-tape-mode", "grow", "-max-mem", "65536", "-e", "+[>+]"}, 1, "", "error tape of 65536 cells cannot grow within the 65536 byte memory limit at 1:4, in loop at 1:2\n"},
+		{[]string{"-max-mem", "-1", "-e", "+"}, 2, "", "error memory limit -1 must not be negative\n"},
+		{[]string{"-numout", "-e", ".-."}, 0, "0 255\n", ""},
+		{[]string{"-numout", "-numout-sep", "newline", "-e", ".-."}, 0, "0\n255\n", ""},
+		{[]string{"-numout", "-numout-sep", "tab", "-e", "."}, 2, "", "error -numout-sep must be space or newline\n"},
+	}
+	for _, tt := range tests {
+		status, out, errs := runBF(t, "", tt.args...)
+		if status != tt.status || out != tt.out || !strings.HasPrefix(errs, tt.errs) || tt.errs == "" && errs != "" {
+			t.Errorf("%q: status %d, output %q, stderr %q, want %d, %q, %q", tt.args, status, out, errs, tt.status, tt.out, tt.errs)
+		}
+	}
+}
+
+func TestBench(t *testing.T) {
+	status, out, errs := runBF(t, "", "-bench", "3", testdata + "hello.bf")
+	if status != 0 || out != "" || !regexp.MustCompile(`^3 runs: min \S+, median \S+, mean \S+, 246 steps, \d+ steps per second\n$`).MatchString(errs) {
+		t.Errorf("status %d, output %q, stderr %q", status, out, errs)
+	}
+	status, _, errs = runBF(t, "", "-bench", "3", "-bench-json", testdata + "hello.bf")
+	var r benchResult
+	if err := json.Unmarshal([]byte(errs), &r); status != 0 || err != nil {
+		t.Fatalf("json: status %d, error %v, stderr %q", status, err, errs)
+	}
+	if r.Runs != 3 || r.Steps != 246 || r.Min <= 0 || r.Min > r.Median || r.StepsPerSec <= 0 {
+		t.Errorf("json: report %+v", r)
+	}
+	status, _, errs = runBF(t, "x", "-bench", "3", testdata + "cat.bf")
+	if status != 2 || !strings.Contains(errs, "input must be given with -in or -input-string") {
+		t.Errorf("reading stdin: status %d, stderr %q", status, errs)
+	}
+	status, _, errs = runBF(t, "", "-bench", "3", "-input-string", "abc", testdata + "cat.bf")
+	if status != 0 || !strings.HasPrefix(errs, "3 runs: ") {
+		t.Errorf("-input-string: status %d, stderr %q", status, errs)
+	}
+}
+
+func TestOokFile(t *testing.T) {
+	// the .ook extension picks the dialect
+	status, out, errs := runBF(t, "", testdata + "ook/hello.ook")
+	if status != 0 || out != "Hello World!\n" || errs != "" {
+		t.Errorf("status %d, output %q, stderr %q", status, out, errs)
+	}
+	status, _, errs = runBF(t, "", "-dialect", "ook", "-e", "Ook. Ook?\nOok!")
+	if status != 1 || errs != "<cmdline>: 2:1: Ook! token without a pair\n" {
+		t.Errorf("error: status %d, stderr %q", status, errs)
+	}
+}
+
+func TestTranscriptReplay(t *testing.T) {
+	rec := filepath.Join(t.TempDir(), "run.jsonl")
+	if status, out, errs := runBF(t, "ab", "-eof", "0", "-transcript", rec, "-e", ",[.,]"); status != 0 || out != "ab" || errs != "" {
+		t.Fatalf("recording: status %d, output %q, stderr %q", status, out, errs)
+	}
+	if status, out, errs := runBF(t, "", "-eof", "0", "-replay", rec, "-e", ",[.,]"); status != 0 || out != "ab" || errs != "" {
+		t.Errorf("replay: status %d, output %q, stderr %q", status, out, errs)
+	}
+	status, _, errs := runBF(t, "", "-eof", "0", "-replay", rec, "-e", ",[+.,]")
+	if want := "error replay differs from event 2 at step 4 at 1:4: transcript writes 0x61, run writes 0x62, in loop at 1:2\n"; status != 1 || !strings.HasPrefix(errs, want) {
+		t.Errorf("changed program: status %d, stderr %q", status, errs)
+	}
+}
+
+func TestRandomInput(t *testing.T) {
+	// five bytes from seed 7, then EOF stores 0
+	prog := ",.,.,.,.,.,."
+	status, out, errs := runBF(t, "", "-in", "random:7:5", "-eof", "0", "-numout", "-e", prog)
+	if status != 0 || out != "60 221 90 127 137 0\n" || errs != "" {
+		t.Errorf("status %d, output %q, stderr %q", status, out, errs)
+	}
+	// a random run can be recorded and replayed
+	rec := filepath.Join(t.TempDir(), "run.jsonl")
+	status, out, _ = runBF(t, "", "-in", "random:7:5", "-eof", "0", "-transcript", rec, "-e", prog)
+	if status != 0 || out != "<\xddZ\x7f\x89\x00" {
+		t.Errorf("recording: status %d, output %q", status, out)
+	}
+	if status, replayed, errs := runBF(t, "", "-eof", "0", "-replay", rec, "-e", prog); status != 0 || replayed != out {
+		t.Errorf("replay: status %d, output %q, stderr %q", status, replayed, errs)
+	}
+	for _, tt := range []struct{ in, errs string }{
+		{"random:x", "error bad -in random seed \"x\": strconv.ParseInt: parsing \"x\": invalid syntax\n"},
+		{"random:1:-3", "error bad -in random length \"-3\": must not be negative\n"},
+	} {
+		if status, _, errs := runBF(t, "", "-in", tt.in, "-e", ","); status == 0 || errs != tt.errs {
+			t.Errorf("-in %s: status %d, stderr %q", tt.in, status, errs)
+		}
+	}
+}
+
+func TestInteractivePrompt(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in")
+	if err := os.WriteFile(in, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// run bf with the file as stdin, taken for a terminal or not
+	runTerm := func(term bool, args ...string) (string, string) {
+		t.Helper()
+		old := isTerminal
+		isTerminal = func(*os.File) bool { return term }
+		defer func() { isTerminal = old }()
+		f, err := os.Open(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		var stdout, stderr bytes.Buffer
+		if status := run(args, f, &stdout, &stderr); status != 0 {
+			t.Fatalf("%q: status %d, stderr %q", args, status, stderr.String())
+		}
+		return stdout.String(), stderr.String()
+	}
+	if out, errs := runTerm(true, "-interactive", "-e", ",.,"); out != "x" || errs != "input> input> " {
+		t.Errorf("terminal: output %q, stderr %q", out, errs)
+	}
+	if out, errs := runTerm(false, "-interactive", "-e", ",.,"); out != "x" || errs != "" {
+		t.Errorf("not a terminal: output %q, stderr %q", out, errs)
+	}
+	if _, errs := runTerm(true, "-e", ",.,"); errs != "" {
+		t.Errorf("without -interactive: stderr %q", errs)
+	}
+	if _, errs := runTerm(true, "-interactive", "-in", in, "-e", ",.,"); errs != "" {
+		t.Errorf("with -in: stderr %q", errs)
+	}
+	// the prompt goes to stderr, never into -out
+	outFile := filepath.Join(dir, "out")
+	if _, errs := runTerm(true, "-interactive", "-out", outFile, "-e", ",.,"); errs != "input> input> " {
+		t.Errorf("-out: stderr %q", errs)
+	}
+	if got, err := os.ReadFile(outFile); err != nil || string(got) != "x" {
+		t.Errorf("-out file %q, %v", got, err)
+	}
+}
+
+func TestInputString(t *testing.T) {
+	status, out, errs := runBF(t, "not this", "-eof", "0", "-input-string", `a\tb\n\xffc\\`, "-e", ",[.,]")
+	if status != 0 || out != "a\tb\n\xffc\\" || errs != "" {
+		t.Errorf("status %d, output %q, stderr %q", status, out, errs)
+	}
+	// \0 is a byte like any other; cat stops only at EOF
+	status, out, _ = runBF(t, "", "-input-string", `\0\r\x00z`, testdata + "cat.bf")
+	if status != 0 || out != "\x00\r\x00z" {
+		t.Errorf("cat: status %d, output %q", status, out)
+	}
+	status, _, errs = runBF(t, "", "-input-string", "a", "-in", testdata + "rot13.in", "-e", ",")
+	if status != 2 || !strings.HasPrefix(errs, "error -input-string gives the program's input, so can't be used with -in,") {
+		t.Errorf("with -in: status %d, stderr %q", status, errs)
+	}
+	for _, tt := range []struct{ arg, err string }{
+		{`a\q`, `unknown escape \q`},
+		{`a\`, "ends in an unfinished escape"},
+		{`\x4`, `escape \x4 needs two hex digits`},
+		{`\xzz`, `escape \xzz needs two hex digits`},
+	} {
+		status, _, errs := runBF(t, "", "-input-string", tt.arg, "-e", ",")
+		if status != 2 || !strings.Contains(errs, "for flag -input-string: " + tt.err + "\n") {
+			t.Errorf("%q: status %d, stderr %q", tt.arg, status, errs)
+		}
+	}
+}
+
+func TestJIT(t *testing.T) {
+	// the plugin has none of these, so they are refused rather than
+	// ignored, even when given their defaults
+	for _, flag := range [][]string{{"-tape", "30000"}, {"-tape-mode", "grow"}, {"-cells", "16"}, {"-strict-cells"}, {"-eof", "0"}, {"-max-steps", "10"}, {"-timeout", "1s"}} {
+		args := append(append([]string{"-jit"}, flag...), "-e", "+.")
+		status, out, errs := runBF(t, "", args...)
+		if status != 2 || out != "" || !strings.HasPrefix(errs, "error -jit runs the program on a fixed 30000 cell tape of bytes with no limits, so can't be used with ") {
+			t.Errorf("%q: status %d, output %q, stderr %q", args, status, out, errs)
+		}
+	}
+	// when the plugin can't be built, the interpreter runs the program
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+	if dir, err := os.UserCacheDir(); err != nil || dir != cache {
+		t.Skip("the user cache directory doesn't follow XDG_CACHE_HOME here")
+	}
+	t.Setenv("PATH", "")
+	status, out, errs := runBF(t, "", "-jit", testdata + "hello.bf")
+	if status != 0 || out != "Hello World!\n" || !strings.HasPrefix(errs, "warning: jit unavailable, using the interpreter: ") {
+		t.Errorf("fallback: status %d, output %q, stderr %q", status, out, errs)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/timnewsham/gobf"
+)
+
+// minMain is the min subcommand, which writes a program's commands
+// without comments to stdout and reports the saving to stderr.
+func minMain(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("min", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	cancel := fs.Bool("cancel", true, "delete +- -+ <> and >< pairs")
+	dead := fs.Bool("dead-loops", true, "delete loops that can never run")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintf(stderr, "usage: bf min [-cancel=false] [-dead-loops=false] program\n")
+		return 2
+	}
+	var rules bf.MinifyRule
+	if *cancel {
+		rules |= bf.MinifyCancel
+	}
+	if *dead {
+		rules |= bf.MinifyDeadLoops
+	}
+	fn := fs.Arg(0)
+	src, err := os.ReadFile(fn)
+	var out []byte
+	if err == nil {
+		out, err = bf.Minify(src, rules)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", fn, err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "%s\n", out)
+	fmt.Fprintf(stderr, "%d bytes, minified to %d\n", len(src), len(out))
+	return 0
+}
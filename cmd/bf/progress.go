@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/timnewsham/gobf"
+)
+
+// reportProgress writes a status line about rt's run to w every
+// interval: the steps so far, how many a second since the last line,
+// the command running and the pointer. On a terminal each line
+// replaces the last, and otherwise they are appended. The returned
+// function stops the reports, ending a replaced line with a newline,
+// and must be called once the run returns.
+func reportProgress(rt *bf.Runtime, every time.Duration, w io.Writer, terminal bool) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	shown := false
+	go func() {
+		defer close(done)
+		tick := time.NewTicker(every)
+		defer tick.Stop()
+		last, lastSteps := time.Now(), int64(0)
+		for {
+			select {
+			case <-tick.C:
+			case <-ctx.Done():
+				return
+			}
+			snap, err := rt.Snapshot(ctx, 0)
+			if err != nil {
+				return
+			}
+			now := time.Now()
+			rate := float64(snap.Steps - lastSteps) / now.Sub(last).Seconds()
+			last, lastSteps = now, snap.Steps
+			line := fmt.Sprintf("%d steps, %.0f per second, at %v, pointer at cell %d", snap.Steps, rate, snap.Pos, snap.Pointer)
+			if terminal {
+				// clear the rest of the last line
+				fmt.Fprintf(w, "\r%s\x1b[K", line)
+			} else {
+				fmt.Fprintln(w, line)
+			}
+			shown = true
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+		if terminal && shown {
+			fmt.Fprintln(w)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/timnewsham/gobf"
+)
+
+// lockedBuffer is a buffer that the reporting goroutine and the test
+// can both use.
+type lockedBuffer struct {
+	mu sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// progressLines runs a program that never ends with reports every
+// millisecond until some have been written, and returns them.
+func progressLines(t *testing.T, terminal bool) string {
+	t.Helper()
+	rt, err := bf.NewRuntime(bf.WithOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog, err := bf.ParseString(">+[]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- rt.RunContext(ctx, prog)
+	}()
+	var w lockedBuffer
+	stop := reportProgress(rt, time.Millisecond, &w, terminal)
+	for deadline := time.Now().Add(5 * time.Second); strings.Count(w.String(), "steps") < 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+	stop()
+	return w.String()
+}
+
+var progressLine = `\d+ steps, \d+ per second, at 1:[34], pointer at cell 1`
+
+func TestProgress(t *testing.T) {
+	got := progressLines(t, false)
+	if !regexp.MustCompile(`^(` + progressLine + `\n){2,}$`).MatchString(got) {
+		t.Errorf("appended lines: %q", got)
+	}
+	// on a terminal each line overwrites the last, and stopping ends it
+	got = progressLines(t, true)
+	if !regexp.MustCompile(`^(\r` + progressLine + `\x1b\[K){2,}\n$`).MatchString(got) {
+		t.Errorf("terminal lines: %q", got)
+	}
+}
+
+func TestProgressFlag(t *testing.T) {
+	status, _, errs := runBF(t, "", "-progress", "1ms", "-max-steps", "5000000", "-e", ">+[]")
+	if status != 1 || !regexp.MustCompile(`(?m)^` + progressLine + `$`).MatchString(errs) || !strings.Contains(errs, "error step limit reached") {
+		t.Errorf("status %d, stderr %q", status, errs)
+	}
+}
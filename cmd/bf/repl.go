@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/timnewsham/gobf"
+)
+
+// repl reads programs a line at a time from in and runs each on rt,
+// which keeps its tape and pointer from one to the next. A line with
+// loops left open is continued by the lines after it. It returns at
+// the end of input.
+func repl(rt *bf.Runtime, parser *bf.Parser, passes bf.Pass, in *bufio.Reader, stderr io.Writer, restore func()) int {
+	var src []byte
+	for {
+		if len(src) == 0 {
+			fmt.Fprintf(stderr, "bf> ")
+		} else {
+			fmt.Fprintf(stderr, "... ")
+		}
+		line, err := in.ReadBytes('\n')
+		src = append(src, line...)
+		if err == io.EOF {
+			if len(line) == 0 {
+				fmt.Fprintf(stderr, "\n")
+				return 0
+			}
+		} else if err != nil {
+			fmt.Fprintf(stderr, "error %v\n", err)
+			return 1
+		}
+		prog, perr := parser.ParseBytes(src)
+		if bf.Incomplete(perr) && err == nil {
+			continue
+		}
+		src = src[:0]
+		if perr != nil {
+			fmt.Fprintf(stderr, "%s\n", perr)
+			continue
+		}
+
+		ctx, stop := interruptContext(context.Background(), restore)
+		rerr := rt.RunContext(ctx, bf.Optimize(prog, passes))
+		stop()
+		if rerr != nil && !errors.Is(rerr, bf.ErrQuit) {
+			fmt.Fprintf(stderr, "error %v\n", rerr)
+		}
+		if err == io.EOF {
+			fmt.Fprintf(stderr, "\n")
+			return 0
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/timnewsham/gobf"
+)
+
+// What -serve allows a program when -max-steps or -timeout don't say,
+// and how large a request may be.
+const (
+	serveMaxSteps = 100000000
+	serveTimeout = 10 * time.Second
+	serveMaxRequest = 1 << 20
+)
+
+// runRequest is the body of a POST to /run.
+type runRequest struct {
+	Program string `json:"program"`
+	Input string `json:"input"`
+}
+
+// runResponse is the reply to a POST to /run. Line and Col are set
+// for a program that fails to parse.
+type runResponse struct {
+	Output string `json:"output"`
+	Error string `json:"error,omitempty"`
+	Line int `json:"line,omitempty"`
+	Col int `json:"col,omitempty"`
+	Steps int64 `json:"steps"`
+}
+
+// errOutputLimit stops a served program that writes too much.
+var errOutputLimit = errors.New("output limit reached")
+
+// limitWriter collects output, failing once it would pass max bytes.
+type limitWriter struct {
+	buf strings.Builder
+	max int
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if w.buf.Len() + len(p) > w.max {
+		n := w.max - w.buf.Len()
+		w.buf.Write(p[:n])
+		return n, fmt.Errorf("%w (%d bytes)", errOutputLimit, w.max)
+	}
+	return w.buf.Write(p)
+}
+
+// serveHTTP listens on addr and serves programs with serveHandler.
+func serveHTTP(addr string, parser bf.Parser, passes bf.Pass, newRuntime func(io.Reader, io.Writer) (*bf.Runtime, error), maxSteps int64, timeout time.Duration, maxOutput int, stderr io.Writer) error {
+	h, err := serveHandler(parser, passes, newRuntime, maxSteps, timeout, maxOutput)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "serving on %s\n", addr)
+	srv := &http.Server{Addr: addr, Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	return srv.ListenAndServe()
+}
+
+// serveHandler returns the handler for -serve, which runs each program
+// POSTed to /run on its own Runtime from newRuntime, parsed like parser
+// and optimized by passes, within the limits.
+func serveHandler(parser bf.Parser, passes bf.Pass, newRuntime func(io.Reader, io.Writer) (*bf.Runtime, error), maxSteps int64, timeout time.Duration, maxOutput int) (http.Handler, error) {
+	if maxSteps == 0 {
+		maxSteps = serveMaxSteps
+	}
+	if timeout == 0 {
+		timeout = serveTimeout
+	}
+	if maxOutput <= 0 {
+		return nil, fmt.Errorf("-max-output %d must be positive", maxOutput)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/run", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "POST a program to run", http.StatusMethodNotAllowed)
+			return
+		}
+		var req runRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, serveMaxRequest)).Decode(&req); err != nil {
+			reply(w, http.StatusBadRequest, runResponse{Error: fmt.Sprintf("bad request: %v", err)})
+			return
+		}
+		status, resp := runServed(r.Context(), req, parser, passes, newRuntime, maxSteps, timeout, maxOutput)
+		reply(w, status, resp)
+	})
+	return mux, nil
+}
+
+// runServed parses and runs one requested program, returning the
+// status and reply for it.
+func runServed(ctx context.Context, req runRequest, parser bf.Parser, passes bf.Pass, newRuntime func(io.Reader, io.Writer) (*bf.Runtime, error), maxSteps int64, timeout time.Duration, maxOutput int) (int, runResponse) {
+	// each request gets its own parser, as parsing changes it
+	prog, err := parser.ParseBytes([]byte(req.Program))
+	if err != nil {
+		resp := runResponse{Error: err.Error()}
+		var perr *bf.ParseError
+		if errors.As(err, &perr) {
+			resp.Line, resp.Col = perr.Line, perr.Col
+		}
+		return http.StatusBadRequest, resp
+	}
+	out := &limitWriter{max: maxOutput}
+	rt, err := newRuntime(strings.NewReader(req.Input), out)
+	if err == nil {
+		err = rt.SetMaxSteps(maxSteps)
+	}
+	if err != nil {
+		return http.StatusInternalServerError, runResponse{Error: err.Error()}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resp := runResponse{}
+	if err := rt.RunContext(ctx, bf.Optimize(prog, passes)); err != nil && !errors.Is(err, bf.ErrQuit) {
+		resp.Error = err.Error()
+	}
+	resp.Output = out.buf.String()
+	resp.Steps = rt.Steps()
+	return http.StatusOK, resp
+}
+
+func reply(w http.ResponseWriter, status int, resp runResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/timnewsham/gobf"
+)
+
+// testServer serves -serve's handler with the limits given.
+func testServer(t *testing.T, maxSteps int64, timeout time.Duration, maxOutput int) *httptest.Server {
+	t.Helper()
+	newRuntime := func(in io.Reader, out io.Writer) (*bf.Runtime, error) {
+		return bf.NewRuntime(bf.WithInput(in), bf.WithOutput(out), bf.WithEOFMode("0"))
+	}
+	h, err := serveHandler(bf.Parser{}, bf.AllPasses, newRuntime, maxSteps, timeout, maxOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// post sends body to /run and returns the status and decoded reply.
+func post(t *testing.T, srv *httptest.Server, body string) (int, runResponse) {
+	t.Helper()
+	resp, err := http.Post(srv.URL + "/run", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var r runResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		t.Fatalf("decoding reply to %s: %v", body, err)
+	}
+	return resp.StatusCode, r
+}
+
+func TestServe(t *testing.T) {
+	srv := testServer(t, 0, 0, 1 << 10)
+	tests := []struct {
+		body string
+		status int
+		want runResponse
+	}{
+		{`{"program": ",[.,]", "input": "hi"}`, 200, runResponse{Output: "hi", Steps: 8}},
+		{`{"program": "+++."}`, 200, runResponse{Output: "\x03", Steps: 4}},
+		// output before a runtime error comes back with it
+		{`{"program": "+.<"}`, 200, runResponse{Output: "\x01", Error: "position -1 (moving -1 from 0) is out of range for 30000 cell tape at 1:3", Steps: 3}},
+		{`{"program": "+\n+]"}`, 400, runResponse{Error: "2:2: unexpected close bracket", Line: 2, Col: 2}},
+		{`{"program": 1}`, 400, runResponse{Error: "bad request: json: cannot unmarshal number into Go struct field runRequest.program of type string"}},
+	}
+	for _, tt := range tests {
+		status, got := post(t, srv, tt.body)
+		if status != tt.status || got != tt.want {
+			t.Errorf("%s: status %d, reply %+v, want %d, %+v", tt.body, status, got, tt.status, tt.want)
+		}
+	}
+	resp, err := http.Get(srv.URL + "/run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed || resp.Header.Get("Allow") != "POST" {
+		t.Errorf("GET: status %d, Allow %q", resp.StatusCode, resp.Header.Get("Allow"))
+	}
+}
+
+func TestServeLimits(t *testing.T) {
+	srv := testServer(t, 0, 50 * time.Millisecond, 16)
+	start := time.Now()
+	status, got := post(t, srv, `{"program": "+[]"}`)
+	if status != 200 || !strings.HasPrefix(got.Error, "context deadline exceeded at ") || got.Steps == 0 {
+		t.Errorf("timeout: status %d, reply %+v", status, got)
+	}
+	if d := time.Since(start); d > 5 * time.Second {
+		t.Errorf("timeout took %v", d)
+	}
+	status, got = post(t, srv, `{"program": "+[.]"}`)
+	if status != 200 || got.Output != strings.Repeat("\x01", 16) || !strings.Contains(got.Error, "output limit reached (16 bytes)") {
+		t.Errorf("oversized output: status %d, reply %+v", status, got)
+	}
+	srv = testServer(t, 100, 0, 16)
+	status, got = post(t, srv, `{"program": "+[]"}`)
+	if status != 200 || !strings.Contains(got.Error, "step") || got.Steps != 100 {
+		t.Errorf("step limit: status %d, reply %+v", status, got)
+	}
+	if _, err := serveHandler(bf.Parser{}, 0, nil, 0, 0, 0); err == nil || err.Error() != "-max-output 0 must be positive" {
+		t.Errorf("-max-output 0: error %v", err)
+	}
+	// brainfork's threads each have the limits, so it isn't served; the
+	// address is bad so that were it served, it would fail at once
+	status, _, errs := runBF(t, "", "-serve", "bad address", "-dialect", "brainfork")
+	if status != 2 || errs != "error -serve can't be used with -dialect=brainfork, whose threads would each get the whole step and memory limits\n" {
+		t.Errorf("brainfork: status %d, stderr %q", status, errs)
+	}
+}
+
+func TestServeConcurrent(t *testing.T) {
+	srv := testServer(t, 0, 0, 1 << 10)
+	// each request runs on its own tape, so each gets its own input back
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			input := strings.Repeat(fmt.Sprint(i), 100)
+			body, _ := json.Marshal(runRequest{Program: ">,[>,]<[<]>[.>]", Input: input})
+			status, got := post(t, srv, string(body))
+			if status != 200 || got.Output != input || got.Error != "" {
+				t.Errorf("request %d: status %d, reply %+v", i, status, got)
+			}
+		}()
+	}
+	wg.Wait()
+}
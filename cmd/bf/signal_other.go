@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package main
+
+import (
+	"errors"
+
+	"github.com/timnewsham/gobf"
+)
+
+func checkpointOnSignal(rt *bf.Runtime, path string) error {
+	return errors.New("checkpoints on signal need SIGUSR1, which this platform lacks")
+}
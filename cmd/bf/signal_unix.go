@@ -0,0 +1,24 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/timnewsham/gobf"
+)
+
+// checkpointOnSignal makes SIGUSR1 write a checkpoint of rt to path.
+func checkpointOnSignal(rt *bf.Runtime, path string) error {
+	rt.CheckpointOnSignal(path)
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1)
+	go func() {
+		for range sigs {
+			rt.RequestCheckpoint()
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/timnewsham/gobf"
+)
+
+// suiteInput is how much input, in bytes, a suite program with a
+// name.in file reads: the file is repeated to make at least this
+// much, or suiteShortInput with -short.
+const (
+	suiteInput = 1 << 20
+	suiteShortInput = 64 << 10
+)
+
+// benchMain is the bench subcommand, which times each program name.bf
+// in a directory, parsed once and run with output discarded, and
+// prints the results in the format of go test -bench, so runs with
+// different flags can be compared with benchstat.
+func benchMain(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	match := fs.String("run", "", "time only the programs whose names match `regexp`")
+	level := fs.Int("O", 2, "optimization `level`: 0 for none, 1 to merge runs of commands, 2 to also rewrite common loops")
+	unbuffered := fs.Bool("unbuffered", false, "write each output byte as it is made instead of buffering")
+	short := fs.Bool("short", false, "time each program briefly, with less input")
+	benchtime := fs.Duration("benchtime", time.Second, "run each program for about `d`")
+	count := fs.Int("count", 1, "time each program `n` times")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fmt.Fprintf(stderr, "usage: bf bench [-run regexp] [-O level] [-unbuffered] [-short] [-benchtime d] [-count n] [dir]\n")
+		return 2
+	}
+	dir := filepath.Join("testdata", "bench")
+	if fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	}
+	passes, ok := levelPasses(*level)
+	if !ok {
+		fmt.Fprintf(stderr, "error -O must be 0, 1 or 2\n")
+		return 2
+	}
+	re, err := regexp.Compile(*match)
+	if err != nil {
+		fmt.Fprintf(stderr, "error -run: %v\n", err)
+		return 2
+	}
+	fns, err := filepath.Glob(filepath.Join(dir, "*.bf"))
+	if err == nil && len(fns) == 0 {
+		err = fmt.Errorf("no programs in %s", dir)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "error %v\n", err)
+		return 1
+	}
+	size := suiteInput
+	if *short {
+		size = suiteShortInput
+		*benchtime = *benchtime / 10
+	}
+
+	fmt.Fprintf(stdout, "goos: %s\ngoarch: %s\npkg: github.com/timnewsham/gobf\n", runtime.GOOS, runtime.GOARCH)
+	for _, fn := range fns {
+		name := strings.TrimSuffix(filepath.Base(fn), ".bf")
+		if !re.MatchString(name) {
+			continue
+		}
+		prog, err := bf.ParseFile(fn)
+		if err != nil {
+			fmt.Fprintf(stderr, "error %v\n", err)
+			return 1
+		}
+		prog = bf.Optimize(prog, passes)
+		input, err := readOptional(strings.TrimSuffix(fn, ".bf") + ".in")
+		if err != nil {
+			fmt.Fprintf(stderr, "error %v\n", err)
+			return 1
+		}
+		if len(input) > 0 {
+			input = bytes.Repeat(input, (size + len(input) - 1) / len(input))
+		}
+		for i := 0; i < *count; i++ {
+			line, err := timeProgram(name, prog, input, *unbuffered, *benchtime)
+			if err != nil {
+				fmt.Fprintf(stderr, "error %s: %v\n", name, err)
+				return 1
+			}
+			fmt.Fprintln(stdout, line)
+		}
+	}
+	return 0
+}
+
+// timeProgram runs prog more times until a batch of runs takes at
+// least d, and returns the last batch as a go test -bench line.
+func timeProgram(name string, prog bf.Runner, input []byte, unbuffered bool, d time.Duration) (string, error) {
+	n := 1
+	for {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			rt := bf.New(bytes.NewReader(input), io.Discard)
+			if err := rt.SetBuffered(!unbuffered); err != nil {
+				return "", err
+			}
+			if err := rt.Run(prog); err != nil {
+				return "", err
+			}
+		}
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&after)
+		if elapsed >= d || n >= 1e9 {
+			perOp := elapsed.Nanoseconds() / int64(n)
+			allocated := (after.TotalAlloc - before.TotalAlloc) / uint64(n)
+			allocs := (after.Mallocs - before.Mallocs) / uint64(n)
+			return fmt.Sprintf("Benchmark%s-%d\t%8d\t%12d ns/op\t%10d B/op\t%8d allocs/op",
+				strings.ToUpper(name[:1]) + name[1:], runtime.GOMAXPROCS(0), n, perOp, allocated, allocs), nil
+		}
+		// aim a little past d, as go test does
+		next := n * 2
+		if elapsed > 0 {
+			next = int(int64(n) * int64(d) * 6 / 5 / int64(elapsed)) + 1
+		}
+		if next > 100 * n {
+			next = 100 * n
+		}
+		n = next
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// rawTerminal puts f into cbreak mode, so each keypress reaches ,
+// without waiting for Enter, if it is a terminal. The returned
+// function restores the old settings and must always be called; it
+// may be called more than once. A termination signal also restores
+// the terminal before the process exits, and interrupts are left to
+// interruptContext. If f is not a terminal nothing changes.
+func rawTerminal(f *os.File) (func(), error) {
+	fd := int(f.Fd())
+	old, err := getTermios(fd)
+	if err != nil {
+		// not a terminal
+		return func() {}, nil
+	}
+	if err := setCbreak(fd, old); err != nil {
+		return nil, err
+	}
+
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigs, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigs:
+			setTermios(fd, old)
+			os.Exit(143)
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sigs)
+			close(done)
+			setTermios(fd, old)
+		})
+	}, nil
+}
+
+// isTerminal reports whether f is a terminal. Tests replace it to
+// have a file taken for one.
+var isTerminal = func(f *os.File) bool {
+	_, err := getTermios(int(f.Fd()))
+	return err == nil
+}
+
+// openTTY opens the controlling terminal for -tui. Tests replace it to
+// keep -tui off the terminal they run in.
+var openTTY = func() (*os.File, error) {
+	return os.OpenFile("/dev/tty", os.O_RDWR, 0)
+}
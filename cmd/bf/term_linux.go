@@ -0,0 +1,46 @@
+//go:build linux
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+type termios = syscall.Termios
+
+// getTermios and setTermios read and write the settings of the
+// terminal fd. Tests replace them with fakes.
+var getTermios = func(fd int) (*termios, error) {
+	t := &termios{}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TCGETS, uintptr(unsafe.Pointer(t))); errno != 0 {
+		return nil, errno
+	}
+	return t, nil
+}
+
+var setTermios = func(fd int, t *termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TCSETS, uintptr(unsafe.Pointer(t))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// setCbreak turns off line buffering and echo but leaves signals on,
+// so ^C still interrupts the program.
+func setCbreak(fd int, old *termios) error {
+	t := *old
+	t.Lflag &^= syscall.ICANON | syscall.ECHO
+	t.Cc[syscall.VMIN] = 1
+	t.Cc[syscall.VTIME] = 0
+	return setTermios(fd, &t)
+}
+
+// termSize returns the columns and rows of the terminal fd.
+func termSize(fd int) (int, int, error) {
+	var ws struct{ row, col, x, y uint16 }
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws))); errno != 0 {
+		return 0, 0, errno
+	}
+	return int(ws.col), int(ws.row), nil
+}
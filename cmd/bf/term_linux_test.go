@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// fakeTerminal has any file taken for a terminal whose settings start
+// as saved, and records each setting made.
+func fakeTerminal(t *testing.T, saved *termios, setErr error) *[]termios {
+	t.Helper()
+	oldGet, oldSet := getTermios, setTermios
+	t.Cleanup(func() { getTermios, setTermios = oldGet, oldSet })
+	var set []termios
+	getTermios = func(int) (*termios, error) {
+		s := *saved
+		return &s, nil
+	}
+	setTermios = func(fd int, t *termios) error {
+		set = append(set, *t)
+		return setErr
+	}
+	return &set
+}
+
+func TestRawRestoresOnError(t *testing.T) {
+	saved := &termios{Lflag: syscall.ICANON | syscall.ECHO | syscall.ISIG}
+	set := fakeTerminal(t, saved, nil)
+	in, err := os.Create(filepath.Join(t.TempDir(), "in"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	// the program fails after its first read
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"-raw", "-e", ",<"}, in, &stdout, &stderr); status != 1 {
+		t.Fatalf("status %d, stderr %q", status, stderr.String())
+	}
+	if len(*set) != 2 {
+		t.Fatalf("terminal set %d times: %+v", len(*set), *set)
+	}
+	if cbreak := (*set)[0]; cbreak.Lflag != syscall.ISIG || cbreak.Cc[syscall.VMIN] != 1 {
+		t.Errorf("cbreak settings %+v", cbreak)
+	}
+	if (*set)[1] != *saved {
+		t.Errorf("restored %+v, want %+v", (*set)[1], *saved)
+	}
+}
+
+func TestRawTerminal(t *testing.T) {
+	saved := &termios{Lflag: syscall.ICANON | syscall.ECHO}
+	set := fakeTerminal(t, saved, nil)
+	restore, err := rawTerminal(os.Stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// restoring again changes nothing more
+	restore()
+	restore()
+	if len(*set) != 2 || (*set)[1] != *saved {
+		t.Errorf("terminal set to %+v", *set)
+	}
+
+	// when cbreak mode can't be set there is nothing to restore
+	set = fakeTerminal(t, saved, errors.New("no"))
+	if restore, err := rawTerminal(os.Stdin); err == nil || restore != nil || len(*set) != 1 {
+		t.Errorf("failed cbreak: error %v, set %d times", err, len(*set))
+	}
+
+	// nor when the file isn't a terminal
+	getTermios = func(int) (*termios, error) { return nil, syscall.ENOTTY }
+	if restore, err := rawTerminal(os.Stdin); err != nil || restore == nil {
+		t.Errorf("not a terminal: error %v", err)
+	} else {
+		restore()
+	}
+	if len(*set) != 1 {
+		t.Errorf("not a terminal: set %d times", len(*set))
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+)
+
+type termios struct{}
+
+var errNoTerm = errors.New("raw terminal mode is not supported on this platform")
+
+// getTermios and setTermios read and write the settings of the
+// terminal fd. Tests replace them with fakes.
+var getTermios = func(fd int) (*termios, error) {
+	return nil, errNoTerm
+}
+
+var setTermios = func(fd int, t *termios) error {
+	return errNoTerm
+}
+
+func setCbreak(fd int, old *termios) error {
+	return errNoTerm
+}
+
+func termSize(fd int) (int, int, error) {
+	return 0, 0, errNoTerm
+}
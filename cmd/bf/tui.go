@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/timnewsham/gobf"
+)
+
+// The layout of a -tui frame: how many columns a cell takes, how many
+// rows are not source or output, and how often a running program
+// moves on.
+const (
+	tuiCell = 5
+	tuiFixed = 8
+	tuiFrame = 50 * time.Millisecond
+	tuiMaxSpeed = 1 << 20
+)
+
+// tuiView is what a -tui frame shows, gathered from the run so that
+// drawing it needs no terminal.
+type tuiView struct {
+	width, height int
+	src []byte
+	at bf.Pos // of the command to run next, or the last one run
+	steps int64
+	pointer int
+	start int // the logical cell of cells[0]
+	cells []*big.Int // nil where the tape doesn't reach
+	output []byte
+	running bool
+	speed int
+	status string // how the run ended, or "" while it hasn't
+}
+
+// tuiCells returns how many cells a frame width wide shows.
+func tuiCells(width int) int {
+	n := (width - 6) / tuiCell
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// drawTUI writes the frame for v to w, drawn over the last one.
+func drawTUI(w io.Writer, v *tuiView) error {
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	line := func(s string) {
+		b.WriteString(s)
+		b.WriteString("\x1b[K\r\n")
+	}
+
+	state := "paused"
+	if v.running {
+		state = "running"
+	}
+	if v.status != "" {
+		state = v.status
+	}
+	line(clip(fmt.Sprintf("step %d  cell %d  speed %d steps/frame  %s", v.steps, v.pointer, v.speed, state), v.width))
+	line("")
+
+	var idx, hex, dec strings.Builder
+	idx.WriteString("  cell")
+	hex.WriteString("   hex")
+	dec.WriteString("   dec")
+	for j, c := range v.cells {
+		i, h, d := fmt.Sprint(v.start + j), "", ""
+		if c != nil {
+			h, d = c.Text(16), c.String()
+		}
+		cols := [3]string{fitCell(i), fitCell(h), fitCell(d)}
+		if v.start + j == v.pointer {
+			for k := range cols {
+				cols[k] = "\x1b[7m" + cols[k] + "\x1b[0m"
+			}
+		}
+		idx.WriteString(cols[0])
+		hex.WriteString(cols[1])
+		dec.WriteString(cols[2])
+	}
+	line(idx.String())
+	line(hex.String())
+	line(dec.String())
+
+	srcRows := (v.height - tuiFixed) / 2
+	if srcRows < 1 {
+		srcRows = 1
+	}
+	outRows := v.height - tuiFixed - srcRows
+	if outRows < 1 {
+		outRows = 1
+	}
+	line(clip("-- source " + strings.Repeat("-", v.width), v.width))
+	drawSource(line, v, srcRows)
+	line(clip("-- output " + strings.Repeat("-", v.width), v.width))
+	lines := strings.Split(printable(v.output), "\n")
+	if len(lines) > outRows {
+		lines = lines[len(lines) - outRows:]
+	}
+	for i := 0; i < outRows; i++ {
+		s := ""
+		if i < len(lines) {
+			s = clip(lines[i], v.width)
+		}
+		line(s)
+	}
+	b.WriteString(clip("s step  r run/pause  + faster  - slower  q quit", v.width))
+	b.WriteString("\x1b[K\x1b[J")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// drawSource writes rows lines of the source around the command at
+// v.at, with the command highlighted.
+func drawSource(line func(string), v *tuiView, rows int) {
+	lines := strings.Split(printable(v.src), "\n")
+	cur, col := -1, 0
+	if off := v.at.Offset() - 1; off >= 0 && off < len(v.src) {
+		cur = bytes.Count(v.src[:off], []byte("\n"))
+		col = off - bytes.LastIndexByte(v.src[:off], '\n') - 1
+	}
+	first := cur - rows / 2
+	if first > len(lines) - rows {
+		first = len(lines) - rows
+	}
+	if first < 0 {
+		first = 0
+	}
+	// scroll sideways to keep the command in view
+	shift := 0
+	if col >= v.width {
+		shift = col - v.width / 2
+	}
+	for i := first; i < first + rows; i++ {
+		if i >= len(lines) {
+			line("")
+			continue
+		}
+		s := lines[i]
+		if shift < len(s) {
+			s = s[shift:]
+		} else {
+			s = ""
+		}
+		s = clip(s, v.width)
+		if c := col - shift; i == cur && c < len(s) {
+			s = s[:c] + "\x1b[7m" + s[c:c+1] + "\x1b[0m" + s[c+1:]
+		}
+		line(s)
+	}
+}
+
+// fitCell right-aligns s in a cell's columns, shortening it if needed.
+func fitCell(s string) string {
+	if len(s) > tuiCell - 1 {
+		s = s[:tuiCell - 2] + "~"
+	}
+	return fmt.Sprintf("%*s", tuiCell, s)
+}
+
+// clip cuts s to n columns.
+func clip(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// printable returns b as text with tabs as spaces and other control
+// bytes and non-ASCII as dots, so each byte takes one column.
+func printable(b []byte) string {
+	s := append([]byte(nil), b...)
+	for i, c := range s {
+		switch {
+		case c == '\t':
+			s[i] = ' '
+		case c != '\n' && (c < ' ' || c > '~'):
+			s[i] = '.'
+		}
+	}
+	return string(s)
+}
+
+// tuiRun is a program being run by -tui.
+type tuiRun struct {
+	rt *bf.Runtime
+	x *bf.Execution
+	src []byte
+	out *bytes.Buffer
+	running bool
+	speed int
+	done bool
+	err error
+}
+
+func (t *tuiRun) step(n int) {
+	t.done, t.err = t.x.RunSteps(n)
+	if t.done {
+		t.running = false
+	}
+}
+
+// view gathers the frame for a terminal width by height.
+func (t *tuiRun) view(width, height int) *tuiView {
+	v := &tuiView{
+		width: width,
+		height: height,
+		src: t.src,
+		at: t.rt.Position(),
+		steps: t.rt.Steps(),
+		pointer: t.rt.Pointer(),
+		output: t.out.Bytes(),
+		running: t.running,
+		speed: t.speed,
+	}
+	if code := t.x.Code(); !t.done && t.x.PC() < len(code) {
+		v.at = code[t.x.PC()].Pos()
+	}
+	n := tuiCells(width)
+	v.start = v.pointer - n / 2
+	for i := 0; i < n; i++ {
+		v.cells = append(v.cells, t.rt.Cell(v.start + i))
+	}
+	switch {
+	case t.done && t.err != nil:
+		v.status = "error: " + t.err.Error()
+	case t.done:
+		v.status = "finished"
+	}
+	return v
+}
+
+// runTUI runs prog on rt in a full-screen view on the terminal tty,
+// showing the tape, the source src and the output in out, until the
+// user quits or ctx is done before the program ends. It returns the
+// error the program ended with, if it did, or ctx's.
+func runTUI(ctx context.Context, rt *bf.Runtime, prog bf.Runner, src []byte, out *bytes.Buffer, tty *os.File) error {
+	x, err := rt.Start(prog)
+	if err != nil {
+		return err
+	}
+	restore, err := rawTerminal(tty)
+	if err != nil {
+		return err
+	}
+	defer restore()
+	fmt.Fprint(tty, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(tty, "\x1b[?25h\x1b[?1049l")
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+	keys := make(chan byte, 16)
+	go func() {
+		b := make([]byte, 1)
+		for {
+			if _, err := tty.Read(b); err != nil {
+				close(keys)
+				return
+			}
+			keys <- b[0]
+		}
+	}()
+
+	t := &tuiRun{rt: rt, x: x, src: src, out: out, speed: 1}
+	tick := time.NewTicker(tuiFrame)
+	defer tick.Stop()
+	for {
+		width, height, err := termSize(int(tty.Fd()))
+		if err != nil {
+			width, height = 80, 24
+		}
+		if err := drawTUI(tty, t.view(width, height)); err != nil {
+			return err
+		}
+		// once the program has ended there is nothing left to stop
+		var expired <-chan struct{}
+		if !t.done {
+			expired = ctx.Done()
+		}
+		select {
+		case <-expired:
+			return fmt.Errorf("%w at %+v", ctx.Err(), rt.Position())
+		case k, ok := <-keys:
+			if !ok || k == 'q' {
+				return t.err
+			}
+			switch k {
+			case 's', ' ':
+				t.running = false
+				t.step(1)
+			case 'r':
+				t.running = !t.running && !t.done
+			case '+', '=':
+				if t.speed < tuiMaxSpeed {
+					t.speed *= 2
+				}
+			case '-':
+				if t.speed > 1 {
+					t.speed /= 2
+				}
+			}
+		case <-sigs:
+			return t.err
+		case <-tick.C:
+			if t.running {
+				t.step(t.speed)
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+//go:build unix
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/timnewsham/gobf"
+)
+
+// frameText makes a drawn frame readable, with escapes as \e and each
+// line ending in a newline alone.
+var frameText = strings.NewReplacer("\x1b", `\e`, "\r\n", "\n")
+
+func TestDrawTUI(t *testing.T) {
+	src := []byte("+++[>+<-]\n>.")
+	prog, err := bf.ParseString(string(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the + inside the loop runs next
+	var at bf.Pos
+	bf.Walk(prog, func(r bf.Runner) bool {
+		if p, ok := r.(interface{ Pos() bf.Pos }); ok && p.Pos().Offset() == 6 {
+			at = p.Pos()
+		}
+		return true
+	})
+	v := &tuiView{
+		width: 40,
+		height: 14,
+		src: src,
+		at: at,
+		steps: 17,
+		pointer: 1,
+		start: -2,
+		cells: []*big.Int{nil, nil, big.NewInt(2), big.NewInt(1), big.NewInt(0), big.NewInt(255)},
+		output: []byte("hi\nthere\ttab\x01"),
+		running: true,
+		speed: 4,
+	}
+	var buf bytes.Buffer
+	if err := drawTUI(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(testdata + "dump/tui.frame")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := frameText.Replace(buf.String()); got != string(want) {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// fakeTTY returns one end of a socket pair, which reads and writes
+// like a terminal that isn't one, and the other end, for the test.
+func fakeTTY(t *testing.T) (*os.File, *os.File) {
+	t.Helper()
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		t.Skipf("no socket pair: %v", err)
+	}
+	tty, user := os.NewFile(uintptr(fds[0]), "tty"), os.NewFile(uintptr(fds[1]), "user")
+	t.Cleanup(func() {
+		tty.Close()
+		user.Close()
+	})
+	// the frames go nowhere
+	go io.Copy(io.Discard, user)
+	return tty, user
+}
+
+func TestRunTUITimeout(t *testing.T) {
+	tty, user := fakeTTY(t)
+	var out bytes.Buffer
+	rt, err := bf.NewRuntime(bf.WithOutput(&out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog, err := bf.ParseString("+[]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100 * time.Millisecond)
+	defer cancel()
+	if _, err := user.Write([]byte("r")); err != nil {
+		t.Fatal(err)
+	}
+	err = runTUI(ctx, rt, prog, []byte("+[]"), &out, tty)
+	if !errors.Is(err, context.DeadlineExceeded) || rt.Steps() == 0 {
+		t.Errorf("error %v after %d steps", err, rt.Steps())
+	}
+}
+
+func TestRunTUIQuit(t *testing.T) {
+	tty, user := fakeTTY(t)
+	var out bytes.Buffer
+	rt, err := bf.NewRuntime(bf.WithOutput(&out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog, err := bf.ParseString("+.+.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// step through to the end, then quit; the timeout doesn't apply
+	// once the program is done
+	ctx, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
+	defer cancel()
+	if _, err := user.Write([]byte("ssssq")); err != nil {
+		t.Fatal(err)
+	}
+	if err := runTUI(ctx, rt, prog, []byte("+.+."), &out, tty); err != nil || out.String() != "\x01\x02" {
+		t.Errorf("error %v, output %q", err, out.String())
+	}
+}
+
+func TestTUIInput(t *testing.T) {
+	in := filepath.Join(t.TempDir(), "in")
+	if err := os.WriteFile(in, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// stdin taken for a terminal is left for the keys, unless the
+	// program's input comes from -in; past that check, -tui stops at
+	// opening the terminal
+	oldTerm, oldTTY := isTerminal, openTTY
+	isTerminal = func(*os.File) bool { return true }
+	openTTY = func() (*os.File, error) { return nil, errors.New("no tty") }
+	defer func() { isTerminal, openTTY = oldTerm, oldTTY }()
+	const refused = "error -tui reads keys from the terminal, so give the program's input with -in or -input-string\n"
+	const opened = "error -tui needs a terminal: no tty\n"
+	for _, tt := range []struct {
+		args []string
+		refused bool
+	}{
+		{[]string{"-tui", "-e", ",."}, true},
+		{[]string{"-tui", "-in", in, "-e", ",."}, false},
+		{[]string{"-tui", "-e", "+."}, false},
+	} {
+		f, err := os.Open(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var stdout, stderr bytes.Buffer
+		status := run(tt.args, f, &stdout, &stderr)
+		f.Close()
+		want, wantStatus := opened, 1
+		if tt.refused {
+			want, wantStatus = refused, 2
+		}
+		if status != wantStatus || stderr.String() != want {
+			t.Errorf("%q: status %d, stderr %q", tt.args, status, stderr.String())
+		}
+	}
+}
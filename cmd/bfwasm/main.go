@@ -0,0 +1,64 @@
+//go:build js && wasm
+
+// Command bfwasm is the interpreter built for a web page with
+// GOOS=js GOARCH=wasm. It defines the JavaScript function
+//
+//	bfRun(program, input[, maxSteps])
+//
+// which runs program on the string input and returns an object with
+// its output, its error, which is empty if it succeeded, and the
+// steps it took. Programs stop after maxSteps steps, or
+// defaultMaxSteps, so a program that never ends can't hang the page.
+// examples/index.html shows how to load it.
+package main
+
+import (
+	"bytes"
+	"strings"
+	"syscall/js"
+
+	"github.com/timnewsham/gobf"
+)
+
+const defaultMaxSteps = 100000000
+
+func main() {
+	js.Global().Set("bfRun", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) < 2 {
+			return result("", 0, "bfRun needs a program and its input")
+		}
+		maxSteps := int64(defaultMaxSteps)
+		if len(args) > 2 && args[2].Type() == js.TypeNumber {
+			maxSteps = int64(args[2].Float())
+		}
+		return run(args[0].String(), args[1].String(), maxSteps)
+	}))
+	// the function must outlive main
+	select {}
+}
+
+// run runs program on input within maxSteps steps.
+func run(program, input string, maxSteps int64) js.Value {
+	prog, err := bf.Parse(strings.NewReader(program))
+	if err != nil {
+		return result("", 0, err.Error())
+	}
+	var out bytes.Buffer
+	rt := bf.New(strings.NewReader(input), &out)
+	if err := rt.SetMaxSteps(maxSteps); err != nil {
+		return result("", 0, err.Error())
+	}
+	msg := ""
+	if err := rt.Run(bf.Optimize(prog, bf.AllPasses)); err != nil {
+		msg = err.Error()
+	}
+	return result(out.String(), rt.Steps(), msg)
+}
+
+func result(output string, steps int64, err string) js.Value {
+	return js.ValueOf(map[string]interface{}{
+		"output": output,
+		"error": err,
+		"steps": float64(steps),
+	})
+}
@@ -0,0 +1,30 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		program, input string
+		maxSteps int64
+		output, err string
+		steps int
+	}{
+		{",+[-.,+]", "hi", defaultMaxSteps, "hi", "", 0},
+		{"++++++++[>++++++++<-]>+.", "", defaultMaxSteps, "A", "", 0},
+		{"+]", "", defaultMaxSteps, "", "1:2: unexpected close bracket", 0},
+		// a program that never ends stops at the step limit
+		{"+[]", "", 1000, "", "step limit", 1000},
+	}
+	for _, tt := range tests {
+		r := run(tt.program, tt.input, tt.maxSteps)
+		output, err, steps := r.Get("output").String(), r.Get("error").String(), r.Get("steps").Int()
+		if output != tt.output || !strings.Contains(err, tt.err) || tt.err == "" && err != "" || tt.steps != 0 && steps != tt.steps {
+			t.Errorf("%q: output %q, error %q, %d steps", tt.program, output, err, steps)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package bf
+
+// Comment is a run of the non-command text in a program, kept by a
+// Parser with KeepComments set. Start and End are the positions of
+// its first and last bytes, and Before is the command that follows
+// it, or the zero Pos for text at the end of the program. A loop's ]
+// is at its End.
+type Comment struct {
+	Start Pos
+	End Pos
+	Before Pos
+	Text string
+}
+
+// Comments returns the comments kept by the last Parse, in source
+// order. With the commands, they make up the whole of the source.
+func (p *Parser) Comments() []Comment {
+	return p.comments
+}
+
+// keepComment adds the comment byte b at p.pos to the pending text.
+func (p *Parser) keepComment(b byte) {
+	if len(p.text) == 0 {
+		p.pending.Start = p.pos
+	}
+	p.pending.End = p.pos
+	p.text = append(p.text, b)
+}
+
+// endComment records the pending text, if any, as coming before the
+// command at before.
+func (p *Parser) endComment(before Pos) {
+	if len(p.text) == 0 {
+		return
+	}
+	c := p.pending
+	c.Before = before
+	c.Text = string(p.text)
+	p.comments = append(p.comments, c)
+	p.text = p.text[:0]
+}
@@ -0,0 +1,83 @@
+package bf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// piece is a run of source text at an offset.
+type piece struct {
+	offset int
+	text string
+}
+
+// commandPieces lists the commands of an unoptimized program with
+// their offsets.
+func commandPieces(r Runner) []piece {
+	var pieces []piece
+	Walk(r, func(r Runner) bool {
+		switch x := r.(type) {
+		case *Loop:
+			pieces = append(pieces, piece{x.Pos().Offset(), "["}, piece{x.End().Offset(), "]"})
+		case *Update:
+			pieces = append(pieces, piece{x.Pos().Offset(), repeat('+', '-', x.Delta())})
+		case *Move:
+			pieces = append(pieces, piece{x.Pos().Offset(), repeat('>', '<', x.Dir())})
+		case *Getchar:
+			pieces = append(pieces, piece{x.Pos().Offset(), ","})
+		case *Putchar:
+			pieces = append(pieces, piece{x.Pos().Offset(), "."})
+		}
+		return true
+	})
+	return pieces
+}
+
+func TestKeepComments(t *testing.T) {
+	srcs := []string{"  lead\n+[ inner ->+<]tail.\n\n", "+", "no commands at all", ""}
+	for _, p := range corpus(t, "*.bf") {
+		srcs = append(srcs, p.src)
+	}
+	for _, src := range srcs {
+		p := &Parser{KeepComments: true}
+		prog, err := p.ParseBytes([]byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pieces := commandPieces(prog)
+		for _, c := range p.Comments() {
+			pieces = append(pieces, piece{c.Start.Offset(), c.Text})
+		}
+		sort.Slice(pieces, func(a, b int) bool {
+			return pieces[a].offset < pieces[b].offset
+		})
+		var b strings.Builder
+		for _, pc := range pieces {
+			b.WriteString(pc.text)
+		}
+		if b.String() != src {
+			t.Errorf("rebuilt %q, want %q", b.String(), src)
+		}
+	}
+}
+
+func TestCommentBefore(t *testing.T) {
+	p := &Parser{KeepComments: true}
+	if _, err := p.ParseBytes([]byte("+ a\n[b]c")); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range p.Comments() {
+		got = append(got, fmt.Sprintf("%q %v-%v before %v", c.Text, c.Start, c.End, c.Before))
+	}
+	want := `" a\n" 1:2-1:4 before 2:1, "b" 2:2-2:2 before 2:3, "c" 2:4-2:4 before 0:0`
+	if strings.Join(got, ", ") != want {
+		t.Errorf("got %s\nwant %s", strings.Join(got, ", "), want)
+	}
+	p = &Parser{}
+	if _, err := p.ParseBytes([]byte("+ a\n[b]c")); err != nil || p.Comments() != nil {
+		t.Errorf("comments %v kept without KeepComments, error %v", p.Comments(), err)
+	}
+}
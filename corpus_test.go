@@ -0,0 +1,45 @@
+package bf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCorpus runs each testdata/name.bf on name.in, the way bf does
+// by default, and checks its output is name.out and that it fails
+// with the error in name.err, if there is one, or succeeds. A case is
+// added by adding its files, and run alone with -run TestCorpus/name.
+func TestCorpus(t *testing.T) {
+	for _, p := range corpus(t, "*.bf") {
+		p := p
+		t.Run(p.name, func(t *testing.T) {
+			want := expected(t, p.name + ".out")
+			wantErr := strings.TrimSpace(expected(t, p.name + ".err"))
+			out, _, err := runProgram(t, p.src, p.input, AllPasses)
+			switch {
+			case err != nil && wantErr == "":
+				t.Errorf("error %v", err)
+			case err == nil && wantErr != "":
+				t.Errorf("succeeded, want error %q", wantErr)
+			case err != nil && err.Error() != wantErr:
+				t.Errorf("error %q, want %q", err, wantErr)
+			}
+			if out != want {
+				t.Errorf("output %q, want %q", out, want)
+			}
+		})
+	}
+}
+
+// expected returns the contents of the testdata file fn, or "" if
+// there is none.
+func expected(t *testing.T, fn string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", fn))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,43 @@
+package bf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxCount is the largest count Parser.Counts accepts.
+const maxCount = 1 << 30
+
+// addDigit adds the digit ch, at p.pos, to the count being read.
+func (p *Parser) addDigit(ch rune) {
+	if !p.counting {
+		p.counting = true
+		p.count = 0
+		p.countPos = p.pos
+	}
+	if p.count <= maxCount {
+		p.count = p.count * 10 + int(ch - '0')
+	}
+}
+
+// endCount applies the count just read to the command ch that follows
+// it, or zero at the end of input, and reports whether ch should be
+// returned. A count of zero drops its command, and a count before
+// anything but + - < or > is an error.
+func (p *Parser) endCount(ch rune) bool {
+	p.counting = false
+	switch {
+	case ch == 0:
+		p.errorAt(p.countPos, 0, "count at end of input", nil)
+	case !strings.ContainsRune("+-<>", ch):
+		p.errorAt(p.countPos, ch, fmt.Sprintf("count before %c, not + - < or >", ch), nil)
+	case p.count > maxCount:
+		p.errorAt(p.countPos, ch, fmt.Sprintf("count is larger than %d", maxCount), nil)
+	case p.count == 0:
+		return false
+	default:
+		p.repeat = p.count
+		p.tok = p.countPos
+	}
+	return true
+}
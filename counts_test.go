@@ -0,0 +1,68 @@
+package bf
+
+import (
+	"strings"
+	"testing"
+)
+
+// countParse parses src with counts enabled.
+func countParse(src string) (Runner, error) {
+	return (&Parser{Counts: true}).ParseBytes([]byte(src))
+}
+
+func TestCounts(t *testing.T) {
+	tests := []struct {
+		src string
+		tree string
+	}{
+		{"65+.", "+65 at 1:1\n. at 1:4\n"},
+		{"12>3<", ">12 at 1:1\n<<< at 1:4\n"},
+		{"007-", "-7 at 1:1\n"},
+		// a count of zero drops its command
+		{"0+.", ". at 1:3\n"},
+		// digits not right before a command are comment
+		{"+5 +", "+ at 1:1\n+ at 1:4\n"},
+	}
+	for _, tt := range tests {
+		prog, err := countParse(tt.src)
+		if err != nil {
+			t.Errorf("%q: %v", tt.src, err)
+			continue
+		}
+		if got := treeString(t, prog); got != tt.tree {
+			t.Errorf("%q: got:\n%s\nwant:\n%s", tt.src, got, tt.tree)
+		}
+	}
+	prog, err := countParse("8+[>8+<-]>+.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := New(nil, &out).Run(prog); err != nil || out.String() != "A" {
+		t.Errorf("output %q, error %v", out.String(), err)
+	}
+	// without the extension digits are comment
+	if got := treeString(t, parse(t, "65+")); got != "+ at 1:3\n" {
+		t.Errorf("counts off: got:\n%s", got)
+	}
+}
+
+func TestCountErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		err string
+	}{
+		{"+\n12[-]", "2:1: count before [, not + - < or >"},
+		{"3.", "1:1: count before ., not + - < or >"},
+		{"4,", "1:1: count before ,, not + - < or >"},
+		{"+[5]", "1:3: count before ], not + - < or >"},
+		{"+5", "1:2: count at end of input"},
+		{"99999999999+", "1:1: count is larger than 1073741824"},
+	}
+	for _, tt := range tests {
+		_, err := countParse(tt.src)
+		if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+			t.Errorf("%q: error %v, want %s", tt.src, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package bf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Coverage records which source commands a bytecode run executed.
+// It is only exact for an unoptimized program, where every command
+// compiles to its own instruction.
+type Coverage struct {
+	hit []bool // by Pos.pos
+}
+
+// EnableCoverage makes the next bytecode run record Coverage.
+func (rt *Runtime) EnableCoverage() {
+	rt.cover = &Coverage{}
+}
+
+// Coverage returns the coverage of the last run, or nil if it is not
+// enabled.
+func (rt *Runtime) Coverage() *Coverage {
+	return rt.cover
+}
+
+func (c *Coverage) reset(code []Instruction) {
+	n := 0
+	for _, in := range code {
+		if in.pos.pos >= n {
+			n = in.pos.pos + 1
+		}
+	}
+	c.hit = make([]bool, n)
+}
+
+func (c *Coverage) mark(at Pos) {
+	c.hit[at.pos] = true
+}
+
+// Covered reports whether the command at at was executed.
+func (c *Coverage) Covered(at Pos) bool {
+	return at.pos < len(c.hit) && c.hit[at.pos]
+}
+
+// Write lists the runs of commands in src, the program's source, that
+// never executed, as line:col ranges followed by the commands, and
+// then how many commands were covered in total.
+func (c *Coverage) Write(w io.Writer, src []byte) error {
+	total, covered := 0, 0
+	var start, end Pos
+	var run strings.Builder
+	flush := func() error {
+		if run.Len() == 0 {
+			return nil
+		}
+		_, err := fmt.Fprintf(w, "uncovered %s-%s %s\n", lineCol(start), lineCol(end), run.String())
+		run.Reset()
+		return err
+	}
+
+	at := Pos{lno: 1}
+	for _, ch := range src {
+		at.pos ++
+		at.linepos ++
+		if ch == '\n' {
+			if err := flush(); err != nil {
+				return err
+			}
+			at.lno ++
+			at.linepos = 0
+			continue
+		}
+		if !strings.ContainsRune("<>+-.,[]", rune(ch)) {
+			continue
+		}
+		total++
+		if c.Covered(at) {
+			covered++
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		if run.Len() == 0 {
+			start = at
+		}
+		end = at
+		run.WriteByte(ch)
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "covered %d of %d commands\n", covered, total)
+	return err
+}
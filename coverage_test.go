@@ -0,0 +1,33 @@
+package bf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	// the loop is skipped because its cell is zero
+	src := "+>[-<+>\n++]<.\n"
+	_, rt, err := runProgram(t, src, "", 0, func(rt *Runtime) error {
+		rt.EnableCoverage()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	if err := rt.Coverage().Write(&buf, []byte(src)); err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"uncovered 1:4-1:7 -<+>\n" +
+		"uncovered 2:1-2:3 ++]\n" +
+		"covered 5 of 12 commands\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	prog := parse(t, src)
+	if !rt.Coverage().Covered(prog.(*Block).Commands()[2].(*Loop).Pos()) {
+		t.Errorf("the [ of a skipped loop was not covered")
+	}
+}
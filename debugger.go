@@ -0,0 +1,153 @@
+package bf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Debugger pauses a bytecode run at breakpoints, or before every
+// command while single stepping, and reads commands from its input:
+//
+//	s	run the next command and pause again
+//	c	continue to the next breakpoint
+//	p	print the pointer and the cells around it
+//	q	stop the program
+//
+// An empty line repeats the last command, which starts out as c.
+// Positions are only exact for an unoptimized program, where every
+// command compiles to its own instruction.
+type Debugger struct {
+	rt *Runtime
+	in *bufio.Reader
+	out io.Writer
+	breaks map[[2]int]*Breakpoint // by line and column
+	stepping bool
+	last string
+}
+
+// ErrQuit is returned by a run the debugger was told to stop.
+var ErrQuit = errors.New("quit from debugger")
+
+// Breakpoint pauses before the command at a line and column runs.
+// If Limit is nonzero it only pauses on the first Limit hits.
+type Breakpoint struct {
+	Line int
+	Col int
+	Limit int
+	Hits int
+}
+
+// NewDebugger attaches a debugger to rt that reads from in and
+// reports to out.
+func NewDebugger(rt *Runtime, in io.Reader, out io.Writer) *Debugger {
+	d := &Debugger{
+		rt: rt,
+		in: bufio.NewReader(in),
+		out: out,
+		breaks: map[[2]int]*Breakpoint{},
+		last: "c",
+	}
+	rt.debug = d
+	return d
+}
+
+// Break sets a breakpoint from a spec of the form line:col or
+// line:col:limit.
+func (d *Debugger) Break(spec string) error {
+	parts := strings.Split(spec, ":")
+	if len(parts) != 2 && len(parts) != 3 {
+		return fmt.Errorf("breakpoint %q must be line:col or line:col:limit", spec)
+	}
+	nums := []int{}
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return fmt.Errorf("breakpoint %q must be line:col or line:col:limit", spec)
+		}
+		nums = append(nums, n)
+	}
+	bp := &Breakpoint{Line: nums[0], Col: nums[1]}
+	if len(nums) == 3 {
+		bp.Limit = nums[2]
+	}
+	d.breaks[[2]int{bp.Line, bp.Col}] = bp
+	return nil
+}
+
+// start checks that every breakpoint is on a command of code.
+func (d *Debugger) start(code []Instruction) error {
+	found := map[[2]int]bool{}
+	for _, in := range code {
+		found[[2]int{in.pos.lno, in.pos.linepos}] = true
+	}
+	for key, bp := range d.breaks {
+		if !found[key] {
+			return fmt.Errorf("breakpoint %d:%d is not on a command", bp.Line, bp.Col)
+		}
+	}
+	return nil
+}
+
+// Step makes the debugger pause before the next command.
+func (d *Debugger) Step() {
+	d.stepping = true
+}
+
+// before is called before each instruction runs.
+func (d *Debugger) before(in *Instruction) error {
+	if bp := d.breaks[[2]int{in.pos.lno, in.pos.linepos}]; bp != nil {
+		bp.Hits++
+		if bp.Limit == 0 || bp.Hits <= bp.Limit {
+			fmt.Fprintf(d.out, "break at %s, hit %d\n", lineCol(in.pos), bp.Hits)
+			return d.pause(in)
+		}
+	}
+	if d.stepping {
+		return d.pause(in)
+	}
+	return nil
+}
+
+// pause shows the next command, if in is not nil, and handles
+// debugger commands until one resumes the program. Running out of
+// input continues it.
+func (d *Debugger) pause(in *Instruction) error {
+	if in != nil {
+		fmt.Fprintf(d.out, "next %s at %s\n", in.String(), lineCol(in.pos))
+	}
+	for {
+		fmt.Fprintf(d.out, "(bf) ")
+		line, err := d.in.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			fmt.Fprintln(d.out)
+			d.stepping = false
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		cmd := strings.TrimSpace(line)
+		if cmd == "" {
+			cmd = d.last
+		}
+		d.last = cmd
+		switch cmd {
+		case "s":
+			d.stepping = true
+			return nil
+		case "c":
+			d.stepping = false
+			return nil
+		case "p":
+			d.rt.DumpState(d.out, d.rt.pos - d.rt.origin)
+		case "q":
+			return ErrQuit
+		default:
+			fmt.Fprintf(d.out, "unknown command %q, want s, c, p or q\n", cmd)
+		}
+	}
+}
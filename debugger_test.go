@@ -0,0 +1,110 @@
+package bf
+
+import (
+	"strings"
+	"testing"
+)
+
+// debug runs src under a debugger fed script, with the given
+// breakpoints, and returns what the debugger printed.
+func debug(t *testing.T, src, script string, step bool, breaks ...string) (string, error) {
+	t.Helper()
+	rt, err := NewRuntime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	d := NewDebugger(rt, strings.NewReader(script), &out)
+	for _, b := range breaks {
+		if err := d.Break(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if step {
+		d.Step()
+	}
+	err = rt.Run(parse(t, src))
+	return out.String(), err
+}
+
+func TestBreakpoints(t *testing.T) {
+	// an empty line repeats c, and running out of input continues
+	out, err := debug(t, "+++[>+<-]", "p\nc\n\n", false, "1:5", "1:6:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"break at 1:5, hit 1\n" +
+		"next > at 1:5\n" +
+		"(bf) at 1:4 step 4 ptr 0\n" +
+		"     0: 03 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00  |................|\n" +
+		"        ^^\n" +
+		"    16: 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00  |................|\n" +
+		"(bf) break at 1:6, hit 1\n" +
+		"next + at 1:6\n" +
+		"(bf) break at 1:5, hit 2\n" +
+		"next > at 1:5\n" +
+		"(bf) \n" +
+		"break at 1:5, hit 3\n" +
+		"next > at 1:5\n" +
+		"(bf) \n"
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestBreakpointErrors(t *testing.T) {
+	rt, err := NewRuntime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDebugger(rt, strings.NewReader(""), &strings.Builder{})
+	for _, spec := range []string{"x", "1", "1:x", "1:2:x"} {
+		if err := d.Break(spec); err == nil {
+			t.Errorf("breakpoint %q accepted", spec)
+		}
+	}
+	if err := d.Break("1:20"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Run(parse(t, "+++[>+<-]")); err == nil || err.Error() != "breakpoint 1:20 is not on a command" {
+		t.Errorf("error %v for a breakpoint past the program", err)
+	}
+}
+
+func TestDebuggerStep(t *testing.T) {
+	out, err := debug(t, "+>+", "s\nx\ns\np\nq\n", true)
+	if err != ErrQuit {
+		t.Fatalf("error %v, want ErrQuit", err)
+	}
+	want := "" +
+		"next + at 1:1\n" +
+		"(bf) next > at 1:2\n" +
+		"(bf) unknown command \"x\", want s, c, p or q\n" +
+		"(bf) next + at 1:3\n" +
+		"(bf) at 1:2 step 2 ptr 1\n" +
+		"     0: 01 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00  |................|\n" +
+		"           ^^\n" +
+		"    16: 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00  |................|\n" +
+		"(bf) "
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestDebuggerInput(t *testing.T) {
+	// the program reads its own input, not the debugger's commands
+	var out strings.Builder
+	rt, err := NewRuntime(WithInput(strings.NewReader("A")), WithOutput(&out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDebugger(rt, strings.NewReader("s\nc\n"), &strings.Builder{})
+	d.Step()
+	if err := rt.Run(parse(t, ",.")); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "A" {
+		t.Errorf("program read %q", out.String())
+	}
+}
@@ -0,0 +1,57 @@
+package bf
+
+import (
+	"fmt"
+)
+
+// Dialect selects a variant of the language with commands beyond the
+// eight of plain Brainf*ck. Outside it, their characters are comments.
+type Dialect int
+
+const (
+	// DialectBF is plain Brainf*ck. It is the default.
+	DialectBF Dialect = iota
+	// DialectPbrain adds procedures: ( ... ) defines the procedure
+	// numbered by the current cell, and : calls it.
+	DialectPbrain
+	// DialectBrainfork adds Y, which forks a new thread.
+	DialectBrainfork
+	// DialectOok is Ook!, which spells the eight commands of plain
+	// Brainf*ck as pairs of the words Ook. Ook? and Ook!.
+	DialectOok
+	// DialectEBF1 is Extended Brainfuck Type I, which adds @ to end
+	// the program, $ to copy the current cell into a storage
+	// register, and ! to copy the register back. As ! is then a
+	// command, it no longer ends the program for Parser.Bang.
+	DialectEBF1
+)
+
+var dialects = map[string]Dialect{
+	"bf": DialectBF,
+	"pbrain": DialectPbrain,
+	"brainfork": DialectBrainfork,
+	"ook": DialectOok,
+	"ebf1": DialectEBF1,
+}
+
+// ParseDialect returns the dialect with the given name.
+func ParseDialect(name string) (Dialect, error) {
+	d, ok := dialects[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown dialect %q", name)
+	}
+	return d, nil
+}
+
+// commands returns the command characters of the dialect.
+func (d Dialect) commands() string {
+	switch d {
+	case DialectPbrain:
+		return "<>+-.,[]():"
+	case DialectBrainfork:
+		return "<>+-.,[]Y"
+	case DialectEBF1:
+		return "<>+-.,[]@$!"
+	}
+	return "<>+-.,[]"
+}
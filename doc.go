@@ -0,0 +1,33 @@
+// Package bf is a Brainf*ck interpreter.
+//
+// To embed it, Run a program on some input and get its output:
+//
+//	out, err := bf.RunString("++++++++[>++++[>++>+++>+++>+<<<<-]>+>+>->>+[<]<-]>>.>---.+++++++..+++.>>.<-.<.+++.------.--------.>>+.>++.", "", bf.WithMaxSteps(1e6))
+//	// out is "Hello World!\n"
+//
+// Otherwise, parse a program into a tree of Runners, optionally Optimize it, and
+// run it on a Runtime:
+//
+//	prog, err := bf.Parse(strings.NewReader(",[.,]"))
+//	if err != nil {
+//		return err
+//	}
+//	rt := bf.New(os.Stdin, os.Stdout)
+//	err = rt.Run(bf.Optimize(prog, bf.AllPasses))
+//
+// Another goroutine can watch a run with Snapshot, say to report
+// progress every second:
+//
+//	go func() {
+//		for range time.Tick(time.Second) {
+//			snap, err := rt.Snapshot(ctx, 8)
+//			if err != nil {
+//				return
+//			}
+//			log.Printf("%d steps, at %v", snap.Steps, snap.Pos)
+//		}
+//	}()
+//
+// Programs can also be compiled to bytecode, translated to other
+// languages with Emit, or built into native code with JIT.
+package bf
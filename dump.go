@@ -0,0 +1,69 @@
+package bf
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// dumpCells is how many cells from the start of the tape # shows,
+// and dumpWindow how many either side of the pointer.
+const (
+	dumpCells = 16
+	dumpWindow = 8
+)
+
+// dump carries out #: it writes the step count and pointer, then the
+// first cells of the tape and the cells around the pointer in hex,
+// with the current cell in brackets.
+func (rt *Runtime) dump(at Pos) error {
+	if err := rt.step(1, at); err != nil {
+		return err
+	}
+	rt.flush()
+	w := rt.dumpOut
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, "# at %s step %d ptr %d\n", lineCol(at), rt.steps, rt.pos - rt.origin)
+	rt.dumpRange(w, 0, dumpCells - 1)
+	ptr := rt.pos - rt.origin
+	rt.dumpRange(w, ptr - dumpWindow, ptr + dumpWindow)
+	return nil
+}
+
+// dumpRange writes the logical cells lo to hi that are on the tape.
+func (rt *Runtime) dumpRange(w io.Writer, lo, hi int) {
+	if lo < -rt.origin {
+		lo = -rt.origin
+	}
+	if max := rt.size() - 1 - rt.origin; hi > max {
+		hi = max
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%6d:", lo)
+	for c := lo; c <= hi; c++ {
+		i := c + rt.origin
+		v := rt.cellHex(i)
+		if i == rt.pos {
+			fmt.Fprintf(&b, " [%s]", v)
+		} else {
+			fmt.Fprintf(&b, " %s", v)
+		}
+	}
+	fmt.Fprintln(w, b.String())
+}
+
+// cellHex formats cell i in hex, padded to the cell width.
+func (rt *Runtime) cellHex(i int) string {
+	switch {
+	case rt.bigs != nil:
+		return rt.big(i).Text(16)
+	case rt.wide != nil && rt.mask == 0xffff:
+		return fmt.Sprintf("%04x", rt.wide[i])
+	case rt.wide != nil:
+		return fmt.Sprintf("%08x", rt.wide[i])
+	}
+	return fmt.Sprintf("%02x", rt.store[i])
+}
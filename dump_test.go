@@ -0,0 +1,36 @@
+package bf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDumpCommand(t *testing.T) {
+	src := "+++>++#<."
+	for _, debug := range []bool{false, true} {
+		p := &Parser{Debug: debug}
+		prog, err := p.ParseBytes([]byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out, dump strings.Builder
+		rt, err := NewRuntime(WithOutput(&out), WithTapeSize(20))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rt.dumpOut = &dump
+		if err := rt.Run(prog); err != nil {
+			t.Fatal(err)
+		}
+		want := ""
+		if debug {
+			want = "" +
+				"# at 1:7 step 7 ptr 1\n" +
+				"     0: 03 [02] 00 00 00 00 00 00 00 00 00 00 00 00 00 00\n" +
+				"     0: 03 [02] 00 00 00 00 00 00 00 00\n"
+		}
+		if dump.String() != want || out.String() != "\x03" {
+			t.Errorf("debug %v: output %q, dump:\n%s\nwant:\n%s", debug, out.String(), dump.String(), want)
+		}
+	}
+}
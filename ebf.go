@@ -0,0 +1,83 @@
+package bf
+
+import (
+	"errors"
+	"math/big"
+)
+
+// ErrHalt is returned by the Run method of a Halt, and so by the tree
+// interpreter for a program that halts. Runtime.Run treats it as the
+// program's normal end.
+var ErrHalt = errors.New("program halted")
+
+// Halt is the Extended Type I @, which ends the program.
+type Halt struct {
+	pos Pos
+}
+
+func (r *Halt) Run(rt *Runtime) error {
+	if err := rt.step(1, r.pos); err != nil {
+		return err
+	}
+	if rt.trace {
+		rt.traceRun(r, r.pos)
+	}
+	return ErrHalt
+}
+
+// Store is the Extended Type I $, which copies the current cell into
+// the storage register.
+type Store struct {
+	pos Pos
+}
+
+func (r *Store) Run(rt *Runtime) error {
+	err := rt.storeReg(r.pos)
+	if rt.trace {
+		rt.traceRun(r, r.pos)
+	}
+	return err
+}
+
+// Retrieve is the Extended Type I !, which copies the storage register
+// into the current cell.
+type Retrieve struct {
+	pos Pos
+}
+
+func (r *Retrieve) Run(rt *Runtime) error {
+	err := rt.retrieveReg(r.pos)
+	if rt.trace {
+		rt.traceRun(r, r.pos)
+	}
+	return err
+}
+
+func (rt *Runtime) storeReg(at Pos) error {
+	if err := rt.step(1, at); err != nil {
+		return err
+	}
+	if rt.bigs != nil {
+		rt.bigReg = new(big.Int).Set(rt.big(rt.pos))
+		return nil
+	}
+	rt.reg = rt.get(rt.pos)
+	return nil
+}
+
+func (rt *Runtime) retrieveReg(at Pos) error {
+	if err := rt.step(1, at); err != nil {
+		return err
+	}
+	if rt.bigs != nil {
+		v := rt.big(rt.pos)
+		if rt.bigReg == nil {
+			v.SetInt64(0)
+		} else {
+			v.Set(rt.bigReg)
+		}
+		return nil
+	}
+	rt.put(rt.pos, rt.reg)
+	return nil
+}
@@ -0,0 +1,89 @@
+package bf
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// runEBF parses src as Extended Type I and runs it optimized with the
+// tree interpreter or as bytecode.
+func runEBF(t *testing.T, src string, interpret bool, opts ...Option) (string, *Runtime, error) {
+	t.Helper()
+	prog, err := (&Parser{Dialect: DialectEBF1}).ParseBytes([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	rt, err := NewRuntime(append([]Option{WithOutput(&out)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt.interpret = interpret
+	err = rt.Run(Optimize(prog, AllPasses))
+	return out.String(), rt, err
+}
+
+func TestEBFHaltInLoops(t *testing.T) {
+	const src = "+[>+[>+[>+.@<-]<-]<-]>>>++."
+	for _, interpret := range []bool{true, false} {
+		out, rt, err := runEBF(t, src, interpret)
+		if err != nil || out != "\x01" {
+			t.Errorf("interpret %v: output %q, error %v", interpret, out, err)
+		}
+		if rt.Pointer() != 3 || rt.Position().String() != "1:12" {
+			t.Errorf("interpret %v: halted with pointer %d at %v", interpret, rt.Pointer(), rt.Position())
+		}
+	}
+	// the tree interpreter's nodes return ErrHalt for Run to stop on
+	prog, err := (&Parser{Dialect: DialectEBF1}).ParseBytes([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := prog.Run(New(nil, &bytes.Buffer{})); !errors.Is(err, ErrHalt) {
+		t.Errorf("Block.Run error %v, want ErrHalt", err)
+	}
+}
+
+func TestEBFStoreRetrieve(t *testing.T) {
+	tests := []struct {
+		src string
+		out string
+	}{
+		// the register starts at zero and $ leaves the cell it copies
+		{"+++!.", "\x00"},
+		{"+++$>!.<.", "\x03\x03"},
+		// a later $ replaces the register
+		{"+$++$>!.", "\x03"},
+		{"-$>!.", "\xff"},
+	}
+	for _, tt := range tests {
+		for _, interpret := range []bool{true, false} {
+			if out, _, err := runEBF(t, tt.src, interpret); err != nil || out != tt.out {
+				t.Errorf("%q interpret %v: output %q, error %v, want %q", tt.src, interpret, out, err, tt.out)
+			}
+		}
+	}
+	// the register is as wide as the cells
+	for _, opt := range []Option{WithCellWidth(16), WithBigCells(false)} {
+		_, rt, err := runEBF(t, "-$>!", false, opt)
+		if got := tape(rt, 0, 1); err != nil || got[1] != got[0] || got[1] == "255" {
+			t.Errorf("wide cells: tape %q, error %v", got, err)
+		}
+	}
+}
+
+func TestEBFBang(t *testing.T) {
+	// with the dialect, ! is a command rather than the start of input
+	p := &Parser{Dialect: DialectEBF1, Bang: true}
+	prog, err := p.ParseBytes([]byte("+$>!.!input"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Rest() != nil {
+		t.Errorf("the program ended at a !")
+	}
+	if got, want := treeString(t, prog), "+ at 1:1\n$ at 1:2\n> at 1:3\n! at 1:4\n. at 1:5\n! at 1:6\n"; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
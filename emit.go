@@ -0,0 +1,55 @@
+package bf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// emitter accumulates generated source one indented line at a time.
+type emitter struct {
+	buf bytes.Buffer
+	indent string
+	depth int
+}
+
+func (e *emitter) line(format string, args ...interface{}) {
+	for i := 0; i < e.depth; i++ {
+		e.buf.WriteString(e.indent)
+	}
+	fmt.Fprintf(&e.buf, format, args...)
+	e.buf.WriteByte('\n')
+}
+
+// Emit writes prog to w as source code in the named language, or
+// as "tree", laid out by DumpTree.
+func Emit(w io.Writer, lang string, prog Runner) error {
+	var src []byte
+	var err error
+	switch lang {
+	case "tree":
+		return DumpTree(w, prog)
+	case "go":
+		src, err = EmitGo(prog)
+	case "c":
+		src, err = EmitC(prog)
+	case "wat":
+		src, err = EmitWat(prog)
+	case "js":
+		src, err = EmitJS(prog)
+	case "asm":
+		src, err = EmitAsm(prog)
+	default:
+		return fmt.Errorf("unknown emit language %q", lang)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// lineCol formats a position the way generated code refers to it.
+func lineCol(pos Pos) string {
+	return pos.String()
+}
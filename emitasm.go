@@ -0,0 +1,163 @@
+package bf
+
+import (
+	"fmt"
+)
+
+const asmPrelude = `# Generated by bf -emit=asm.
+# GNU syntax x86-64 for Linux: as -o prog.o prog.s && ld -o prog prog.o
+# %r12 holds the address of the current cell and %r13 the tape base.
+
+	.bss
+tape:
+	.zero 30000
+
+	.text
+	.globl _start
+_start:
+	lea tape(%rip), %r13
+	mov %r13, %r12
+`
+
+const asmEpilogue = `	mov $60, %eax
+	xor %edi, %edi
+	syscall
+
+# fail writes the message at %rsi of length %rdx to stderr and exits.
+fail:
+	mov $1, %eax
+	mov $2, %edi
+	syscall
+	mov $60, %eax
+	mov $1, %edi
+	syscall
+`
+
+// asmGen tracks the out-of-range stubs and their messages, which are
+// emitted after the program body.
+type asmGen struct {
+	*emitter
+	stubs []Pos
+}
+
+// EmitAsm lowers prog into x86-64 assembly using Linux system calls
+// for I/O. Loops become cmp/je pairs on labels named after the index
+// of their opening bracket in the flattened program.
+func EmitAsm(prog Runner) ([]byte, error) {
+	code, err := Compile(prog)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &asmGen{emitter: &emitter{indent: "\t", depth: 1}}
+	g.buf.WriteString(asmPrelude)
+	for i := range code {
+		if err := g.instr(i, &code[i]); err != nil {
+			return nil, err
+		}
+	}
+	g.buf.WriteString(asmEpilogue)
+
+	for i, pos := range g.stubs {
+		msg := fmt.Sprintf("error position out of range at %s\n", lineCol(pos))
+		g.buf.WriteString(fmt.Sprintf(".Lfail%d:\n", i))
+		g.line("lea .Lmsg%d(%%rip), %%rsi", i)
+		g.line("mov $%d, %%edx", len(msg))
+		g.line("jmp fail")
+	}
+	g.buf.WriteString("\n\t.section .rodata\n")
+	for i, pos := range g.stubs {
+		g.buf.WriteString(fmt.Sprintf(".Lmsg%d:\n", i))
+		g.line(".ascii %q", fmt.Sprintf("error position out of range at %s\n", lineCol(pos)))
+	}
+	return g.buf.Bytes(), nil
+}
+
+// check emits a bounds check of the address in reg.
+func (g *asmGen) check(reg string, pos Pos) {
+	g.line("mov %s, %%rcx", reg)
+	g.line("sub %%r13, %%rcx")
+	g.line("cmp $30000, %%rcx")
+	g.line("jae .Lfail%d", len(g.stubs))
+	g.stubs = append(g.stubs, pos)
+}
+
+// checkOff emits a bounds check of the cell at off, if it is not the
+// current cell, which is always in range.
+func (g *asmGen) checkOff(off int, pos Pos) {
+	if off != 0 {
+		g.line("lea %d(%%r12), %%rax", off)
+		g.check("%rax", pos)
+	}
+}
+
+func (g *asmGen) instr(pc int, in *Instruction) error {
+	g.line("# %s", lineCol(in.pos))
+	switch in.op {
+	case OpOpen:
+		g.line("cmpb $0, (%%r12)")
+		g.line("je .Lend%d", in.arg)
+		g.buf.WriteString(fmt.Sprintf(".Lbody%d:\n", pc))
+	case OpClose:
+		g.line("cmpb $0, (%%r12)")
+		g.line("jne .Lbody%d", in.arg)
+		g.buf.WriteString(fmt.Sprintf(".Lend%d:\n", pc))
+	case OpMove:
+		g.line("lea %d(%%r12), %%r12", in.arg)
+		g.check("%r12", in.pos)
+	case OpUpdate:
+		g.checkOff(in.off, in.pos)
+		switch b := byte(in.arg); b {
+		case 1:
+			g.line("incb %d(%%r12)", in.off)
+		case 0xff:
+			g.line("decb %d(%%r12)", in.off)
+		default:
+			g.line("addb $%d, %d(%%r12)", b, in.off)
+		}
+	case OpSet:
+		g.checkOff(in.off, in.pos)
+		g.line("movb $%d, %d(%%r12)", byte(in.arg), in.off)
+	case OpMulAdd:
+		g.line("cmpb $0, (%%r12)")
+		g.line("je .Ldone%d", pc)
+		g.checkOff(in.min, in.pos)
+		g.checkOff(in.max, in.pos)
+		g.line("movzbl (%%r12), %%edx")
+		for _, t := range in.terms {
+			g.line("imul $%d, %%edx, %%ecx", byte(t.factor))
+			g.line("addb %%cl, %d(%%r12)", t.off)
+		}
+		g.line("movb $0, (%%r12)")
+		g.buf.WriteString(fmt.Sprintf(".Ldone%d:\n", pc))
+	case OpScan:
+		g.buf.WriteString(fmt.Sprintf(".Lscan%d:\n", pc))
+		g.line("cmpb $0, (%%r12)")
+		g.line("je .Ldone%d", pc)
+		g.line("lea %d(%%r12), %%r12", in.arg)
+		g.check("%r12", in.pos)
+		g.line("jmp .Lscan%d", pc)
+		g.buf.WriteString(fmt.Sprintf(".Ldone%d:\n", pc))
+	case OpGetchar:
+		g.checkOff(in.off, in.pos)
+		g.line("lea %d(%%r12), %%rsi", in.off)
+		g.line("xor %%eax, %%eax")
+		g.line("xor %%edi, %%edi")
+		g.line("mov $1, %%edx")
+		g.line("syscall")
+		g.line("cmp $1, %%rax")
+		g.line("je .Lread%d", pc)
+		g.line("movb $0xff, (%%rsi)")
+		g.buf.WriteString(fmt.Sprintf(".Lread%d:\n", pc))
+	case OpPutchar:
+		g.checkOff(in.off, in.pos)
+		g.line("lea %d(%%r12), %%rsi", in.off)
+		g.line("mov $1, %%eax")
+		g.line("mov $1, %%edi")
+		g.line("mov $1, %%edx")
+		g.line("syscall")
+	default:
+		return fmt.Errorf("cannot emit opcode %d as asm", in.op)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package bf
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// TestEmitAsm assembles and links the corpus emitted as x86-64, which
+// makes Linux system calls, and checks it against the interpreter.
+func TestEmitAsm(t *testing.T) {
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("the assembly runs only on linux/amd64")
+	}
+	as, err := exec.LookPath("as")
+	if err != nil {
+		t.Skip("no as to assemble the programs")
+	}
+	ld, err := exec.LookPath("ld")
+	if err != nil {
+		t.Skip("no ld to link the programs")
+	}
+	dir := t.TempDir()
+	for _, p := range corpus(t, "*.bf") {
+		for name, passes := range map[string]Pass{"O0": 0, "O2": AllPasses} {
+			src, err := EmitAsm(Optimize(parse(t, p.src), passes))
+			if err != nil {
+				t.Fatalf("%s %s: %v", p.name, name, err)
+			}
+			fn := filepath.Join(dir, p.name + "-" + name)
+			if err := os.WriteFile(fn + ".s", src, 0644); err != nil {
+				t.Fatal(err)
+			}
+			for _, args := range [][]string{{as, "-o", fn + ".o", fn + ".s"}, {ld, "-o", fn, fn + ".o"}} {
+				if msg, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+					t.Fatalf("%s %s: %s: %v\n%s", p.name, name, filepath.Base(args[0]), err, msg)
+				}
+			}
+			checkEmitted(t, p, name, exec.Command(fn))
+		}
+	}
+}
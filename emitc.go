@@ -0,0 +1,126 @@
+package bf
+
+import (
+	"fmt"
+)
+
+const cPrelude = `/* Generated by bf -emit=c. */
+#include <stdio.h>
+#include <stdlib.h>
+
+#define TAPE 30000
+
+static unsigned char mem[TAPE];
+static unsigned char *p = mem;
+
+static void fail(long i, const char *pos)
+{
+	fflush(stdout);
+	fprintf(stderr, "error position %ld is out of range at %s\n", i, pos);
+	exit(1);
+}
+
+/* at returns the cell off cells from the pointer. */
+static unsigned char *at(long off, const char *pos)
+{
+	long i = (p - mem) + off;
+	if (i < 0 || i >= TAPE)
+		fail(i, pos);
+	return mem + i;
+}
+
+/* move moves the pointer dir cells, failing before it would leave
+   the tape, since a pointer off the tape is undefined. */
+static void move(long dir, const char *pos)
+{
+	p = at(dir, pos);
+}
+
+void get(unsigned char *c)
+{
+	int ch;
+
+	fflush(stdout);
+	ch = getchar();
+	*c = ch == EOF ? 0xff : ch;
+}
+
+int main(void)
+{
+`
+
+// EmitC translates prog into a single portable C file with the same
+// semantics as the interpreter.
+func EmitC(prog Runner) ([]byte, error) {
+	e := &emitter{indent: "\t", depth: 1}
+	e.buf.WriteString(cPrelude)
+	if err := emitC(e, prog); err != nil {
+		return nil, err
+	}
+	e.line("return 0;")
+	e.buf.WriteString("}\n")
+	return e.buf.Bytes(), nil
+}
+
+// cCell returns the C lvalue for the cell at off.
+func cCell(off int, pos Pos) string {
+	if off == 0 {
+		return "*p"
+	}
+	return fmt.Sprintf("*at(%d, %q)", off, lineCol(pos))
+}
+
+// cAdd returns a compound assignment adding n.
+func cAdd(n int) string {
+	if n < 0 {
+		return fmt.Sprintf("-= %d", -n)
+	}
+	return fmt.Sprintf("+= %d", n)
+}
+
+func emitC(e *emitter, r Runner) error {
+	switch x := r.(type) {
+	case *Block:
+		for _, cmd := range x.seq {
+			if err := emitC(e, cmd); err != nil {
+				return err
+			}
+		}
+	case *Loop:
+		e.line("while (*p) { /* %s */", lineCol(x.pos))
+		e.depth++
+		if err := emitC(e, x.block); err != nil {
+			return err
+		}
+		e.depth--
+		e.line("}")
+	case *Move:
+		e.line("move(%d, %q);", x.dir, lineCol(x.pos))
+	case *Update:
+		e.line("%s %s; /* %s */", cCell(x.off, x.pos), cAdd(int(int8(x.n))), lineCol(x.pos))
+	case *Set:
+		e.line("%s = %d; /* %s */", cCell(x.off, x.pos), x.value, lineCol(x.pos))
+	case *MulAdd:
+		e.line("if (*p) { /* %s */", lineCol(x.pos))
+		e.depth++
+		e.line("at(%d, %q);", x.min, lineCol(x.pos))
+		e.line("at(%d, %q);", x.max, lineCol(x.pos))
+		for _, t := range x.terms {
+			e.line("p[%d] += *p * %d;", t.off, byte(t.factor))
+		}
+		e.line("*p = 0;")
+		e.depth--
+		e.line("}")
+	case *Scan:
+		e.line("while (*p) { /* %s */", lineCol(x.pos))
+		e.line("\tmove(%d, %q);", x.dir, lineCol(x.pos))
+		e.line("}")
+	case *Getchar:
+		e.line("get(at(%d, %q)); /* %s */", x.off, lineCol(x.pos), lineCol(x.pos))
+	case *Putchar:
+		e.line("putchar(%s); /* %s */", cCell(x.off, x.pos), lineCol(x.pos))
+	default:
+		return fmt.Errorf("cannot emit %T as c", r)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package bf
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestEmitC compiles the corpus emitted as C, unoptimized and
+// optimized, with cc and checks it against the interpreter.
+func TestEmitC(t *testing.T) {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("no cc to compile the programs")
+	}
+	dir := t.TempDir()
+	for _, p := range corpus(t, "*.bf") {
+		for name, passes := range map[string]Pass{"O0": 0, "O2": AllPasses} {
+			src, err := EmitC(Optimize(parse(t, p.src), passes))
+			if err != nil {
+				t.Fatalf("%s %s: %v", p.name, name, err)
+			}
+			fn := filepath.Join(dir, p.name + "-" + name)
+			if err := os.WriteFile(fn + ".c", src, 0644); err != nil {
+				t.Fatal(err)
+			}
+			build := exec.Command(cc, "-std=c99", "-o", fn, fn + ".c")
+			if msg, err := build.CombinedOutput(); err != nil {
+				t.Fatalf("%s %s: cc: %v\n%s", p.name, name, err, msg)
+			}
+			checkEmitted(t, p, name, exec.Command(fn))
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package bf
+
+import (
+	"fmt"
+	"go/format"
+)
+
+const goMainPrelude = `// Code generated by bf -emit=go. DO NOT EDIT.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	mem = make([]byte, 30000)
+	p   int
+	in  = bufio.NewReader(os.Stdin)
+	out = bufio.NewWriter(os.Stdout)
+)
+
+func fail(err error) {
+	out.Flush()
+	fmt.Fprintf(os.Stderr, "error %v\n", err)
+	os.Exit(1)
+}
+
+func main() {
+	defer out.Flush()
+	run()
+}
+`
+
+const goPluginPrelude = `// Code generated by bf -jit. DO NOT EDIT.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+var (
+	mem []byte
+	p   int
+	in  *bufio.Reader
+	out *bufio.Writer
+)
+
+type bfError struct {
+	err error
+}
+
+func fail(err error) {
+	panic(bfError{err})
+}
+
+// Run executes the program, returning the first runtime error.
+func Run(input io.Reader, output io.Writer) (err error) {
+	mem = make([]byte, 30000)
+	p = 0
+	in = bufio.NewReader(input)
+	out = bufio.NewWriter(output)
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(bfError)
+			if !ok {
+				panic(r)
+			}
+			err = e.err
+		}
+		if ferr := out.Flush(); err == nil {
+			err = ferr
+		}
+	}()
+	run()
+	return nil
+}
+`
+
+const goHelpers = `
+// at returns the tape index off cells from the pointer.
+func at(off int, pos string) int {
+	i := p + off
+	if i < 0 || i >= len(mem) {
+		fail(fmt.Errorf("position %d is out of range at %s", i, pos))
+	}
+	return i
+}
+
+func move(dir int, pos string) {
+	p = at(dir, pos)
+}
+
+func getchar(i int, pos string) {
+	out.Flush()
+	b, err := in.ReadByte()
+	if err == io.EOF {
+		b = 0xff
+	} else if err != nil {
+		fail(fmt.Errorf("%v in getchar at %s", err, pos))
+	}
+	mem[i] = b
+}
+
+func putchar(i int, pos string) {
+	if err := out.WriteByte(mem[i]); err != nil {
+		fail(fmt.Errorf("%v in putchar at %s", err, pos))
+	}
+}
+
+func run() {
+`
+
+// EmitGo translates prog into the source of a standalone Go program
+// with the same semantics as the interpreter. Each statement carries
+// the line:col of the command it came from.
+func EmitGo(prog Runner) ([]byte, error) {
+	return emitGoSource(goMainPrelude, prog)
+}
+
+// EmitGoPlugin translates prog into a Go plugin exporting
+// Run(io.Reader, io.Writer) error, which reports runtime errors
+// instead of exiting.
+func EmitGoPlugin(prog Runner) ([]byte, error) {
+	return emitGoSource(goPluginPrelude, prog)
+}
+
+func emitGoSource(prelude string, prog Runner) ([]byte, error) {
+	e := &emitter{indent: "\t", depth: 1}
+	e.buf.WriteString(prelude)
+	e.buf.WriteString(goHelpers)
+	if err := emitGo(e, prog); err != nil {
+		return nil, err
+	}
+	e.buf.WriteString("}\n")
+	return format.Source(e.buf.Bytes())
+}
+
+// goCell returns the Go expression for the cell at off.
+func goCell(off int, pos Pos) string {
+	if off == 0 {
+		return "mem[p]"
+	}
+	return fmt.Sprintf("mem[at(%d, %q)]", off, lineCol(pos))
+}
+
+func emitGo(e *emitter, r Runner) error {
+	switch x := r.(type) {
+	case *Block:
+		for _, cmd := range x.seq {
+			if err := emitGo(e, cmd); err != nil {
+				return err
+			}
+		}
+	case *Loop:
+		e.line("for mem[p] != 0 { // %s", lineCol(x.pos))
+		e.depth++
+		if err := emitGo(e, x.block); err != nil {
+			return err
+		}
+		e.depth--
+		e.line("}")
+	case *Move:
+		e.line("move(%d, %q)", x.dir, lineCol(x.pos))
+	case *Update:
+		e.line("%s += %d // %s", goCell(x.off, x.pos), byte(x.n), lineCol(x.pos))
+	case *Set:
+		e.line("%s = %d // %s", goCell(x.off, x.pos), x.value, lineCol(x.pos))
+	case *MulAdd:
+		e.line("if v := mem[p]; v != 0 { // %s", lineCol(x.pos))
+		e.depth++
+		e.line("at(%d, %q)", x.min, lineCol(x.pos))
+		e.line("at(%d, %q)", x.max, lineCol(x.pos))
+		for _, t := range x.terms {
+			e.line("mem[p%+d] += v * %d", t.off, byte(t.factor))
+		}
+		e.line("mem[p] = 0")
+		e.depth--
+		e.line("}")
+	case *Scan:
+		e.line("for mem[p] != 0 { // %s", lineCol(x.pos))
+		e.line("\tmove(%d, %q)", x.dir, lineCol(x.pos))
+		e.line("}")
+	case *Getchar:
+		e.line("getchar(at(%d, %q), %q)", x.off, lineCol(x.pos), lineCol(x.pos))
+	case *Putchar:
+		e.line("putchar(at(%d, %q), %q)", x.off, lineCol(x.pos), lineCol(x.pos))
+	default:
+		return fmt.Errorf("cannot emit %T as go", r)
+	}
+	return nil
+}
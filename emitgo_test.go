@@ -0,0 +1,53 @@
+package bf
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestEmitGo translates the corpus to Go, unoptimized and optimized,
+// builds it all with the go tool and checks each program prints what
+// it does when interpreted, and fails where it does, with the error on
+// stderr.
+func TestEmitGo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the programs with the go tool")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go tool to build the programs")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module emitted\n\ngo 1.17\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	progs := corpus(t, "*.bf")
+	for _, p := range progs {
+		for name, passes := range map[string]Pass{"O0": 0, "O2": AllPasses} {
+			src, err := EmitGo(Optimize(parse(t, p.src), passes))
+			if err != nil {
+				t.Fatalf("%s %s: %v", p.name, name, err)
+			}
+			pkg := filepath.Join(dir, p.name + "-" + name)
+			if err := os.Mkdir(pkg, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(pkg, "main.go"), src, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// one build for the lot is much quicker than one each
+	build := exec.Command(goTool, "build", "-o", filepath.Join(dir, "bin") + string(filepath.Separator), "./...")
+	build.Dir = dir
+	if msg, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, msg)
+	}
+	for _, p := range progs {
+		for _, name := range []string{"O0", "O2"} {
+			checkEmitted(t, p, name, exec.Command(filepath.Join(dir, "bin", p.name + "-" + name)))
+		}
+	}
+}
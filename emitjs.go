@@ -0,0 +1,107 @@
+package bf
+
+import (
+	"fmt"
+)
+
+const jsPrelude = `// Generated by bf -emit=js.
+//
+// bf runs the program on input, either a Uint8Array of the bytes to
+// read or a string fed to the program as UTF-8, and returns everything
+// it printed as a Uint8Array. The output is left as bytes, since a
+// program may print any byte and decoding it would change some; use
+// new TextDecoder().decode(bf(input)) for a program known to print
+// UTF-8.
+function bf(input) {
+	const m = new Uint8Array(30000);
+	const inp = input instanceof Uint8Array ? input : new TextEncoder().encode(input || "");
+	const out = [];
+	let p = 0;
+	let ip = 0;
+
+	// at returns the index of the cell off cells from the pointer.
+	function at(off, pos) {
+		const i = p + off;
+		if (i < 0 || i >= m.length) {
+			throw new Error("position " + i + " is out of range at " + pos);
+		}
+		return i;
+	}
+
+`
+
+const jsEpilogue = `
+	return Uint8Array.from(out);
+}
+
+if (typeof module !== "undefined") {
+	module.exports = bf;
+}
+`
+
+// EmitJS translates prog into a self-contained JavaScript function.
+func EmitJS(prog Runner) ([]byte, error) {
+	e := &emitter{indent: "\t", depth: 1}
+	e.buf.WriteString(jsPrelude)
+	if err := emitJS(e, prog); err != nil {
+		return nil, err
+	}
+	e.buf.WriteString(jsEpilogue)
+	return e.buf.Bytes(), nil
+}
+
+// jsCell returns the JS index expression for the cell at off.
+func jsCell(off int, pos Pos) string {
+	if off == 0 {
+		return "m[p]"
+	}
+	return fmt.Sprintf("m[at(%d, %q)]", off, lineCol(pos))
+}
+
+func emitJS(e *emitter, r Runner) error {
+	switch x := r.(type) {
+	case *Block:
+		for _, cmd := range x.seq {
+			if err := emitJS(e, cmd); err != nil {
+				return err
+			}
+		}
+	case *Loop:
+		e.line("while (m[p]) { // %s", lineCol(x.pos))
+		e.depth++
+		if err := emitJS(e, x.block); err != nil {
+			return err
+		}
+		e.depth--
+		e.line("}")
+	case *Move:
+		e.line("p = at(%d, %q);", x.dir, lineCol(x.pos))
+	case *Update:
+		e.line("%s %s; // %s", jsCell(x.off, x.pos), cAdd(int(int8(x.n))), lineCol(x.pos))
+	case *Set:
+		e.line("%s = %d; // %s", jsCell(x.off, x.pos), x.value, lineCol(x.pos))
+	case *MulAdd:
+		e.line("if (m[p]) { // %s", lineCol(x.pos))
+		e.depth++
+		e.line("const v = m[p];")
+		e.line("at(%d, %q);", x.min, lineCol(x.pos))
+		e.line("at(%d, %q);", x.max, lineCol(x.pos))
+		for _, t := range x.terms {
+			e.line("m[p + %d] += v * %d;", t.off, t.factor)
+		}
+		e.line("m[p] = 0;")
+		e.depth--
+		e.line("}")
+	case *Scan:
+		e.line("while (m[p]) { // %s", lineCol(x.pos))
+		e.line("\tp = at(%d, %q);", x.dir, lineCol(x.pos))
+		e.line("}")
+	case *Getchar:
+		e.line("%s = ip < inp.length ? inp[ip++] : 0xff; // %s", jsCell(x.off, x.pos), lineCol(x.pos))
+	case *Putchar:
+		e.line("out.push(%s); // %s", jsCell(x.off, x.pos), lineCol(x.pos))
+	default:
+		return fmt.Errorf("cannot emit %T as js", r)
+	}
+	return nil
+}
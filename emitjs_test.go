@@ -0,0 +1,79 @@
+package bf
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// runJS is a node script that runs the bf function in the file named
+// by its argument on stdin, as bytes, and writes the bytes it returns.
+const runJS = `const bf = require(process.argv[1]);
+process.stdout.write(bf(require("fs").readFileSync(0)));
+`
+
+// TestEmitJS runs the corpus translated to JavaScript under node and
+// checks each program prints what it does when interpreted, byte for
+// byte.
+func TestEmitJS(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("no node to run the JavaScript")
+	}
+	dir := t.TempDir()
+	for _, p := range corpus(t, "*.bf") {
+		if expected(t, p.name + ".err") != "" {
+			continue
+		}
+		js, err := EmitJS(Optimize(parse(t, p.src), AllPasses))
+		if err != nil {
+			t.Fatalf("%s: %v", p.name, err)
+		}
+		fn := filepath.Join(dir, p.name + ".js")
+		if err := os.WriteFile(fn, js, 0644); err != nil {
+			t.Fatal(err)
+		}
+		cmd := exec.Command(node, "-e", runJS, fn)
+		cmd.Stdin = bytes.NewReader([]byte(p.input))
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			t.Errorf("%s: %v: %s", p.name, err, stderr.Bytes())
+			continue
+		}
+		if want := expected(t, p.name + ".out"); string(out) != want {
+			t.Errorf("%s: output %q, want %q", p.name, out, want)
+		}
+	}
+}
+
+func TestEmitJSBytes(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("no node to run the JavaScript")
+	}
+	// bytes that aren't UTF-8 come back as they were read, and a
+	// string argument is read as its UTF-8
+	js, err := EmitJS(parse(t, ",+[-.,+]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := filepath.Join(t.TempDir(), "bytes.js")
+	if err := os.WriteFile(fn, js, 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := `const bf = require(process.argv[1]);
+const got = [bf(new Uint8Array([0x80, 0xc3, 0x61, 0x00])), bf("é")];
+process.stdout.write(JSON.stringify(got.map(b => [b instanceof Uint8Array, Array.from(b)])));
+`
+	out, err := exec.Command(node, "-e", script, fn).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[[true,[128,195,97,0]],[true,[195,169]]]`; string(out) != want {
+		t.Errorf("got %s, want %s", out, want)
+	}
+}
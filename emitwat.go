@@ -0,0 +1,118 @@
+package bf
+
+import (
+	"fmt"
+)
+
+const watPrelude = `;; Generated by bf -emit=wat.
+;; The host supplies getchar (returning a byte, or -1 at EOF), putchar,
+;; and fail, which is called with the offending cell index and the
+;; line and column of the command before the module traps.
+(module
+  (import "env" "getchar" (func $getchar (result i32)))
+  (import "env" "putchar" (func $putchar (param i32)))
+  (import "env" "fail" (func $fail (param i32 i32 i32)))
+  (memory (export "memory") 1)
+  (global $p (mut i32) (i32.const 0))
+
+  ;; at returns the address of the cell off cells from the pointer.
+  (func $at (param $off i32) (param $line i32) (param $col i32) (result i32)
+    (local $i i32)
+    (local.set $i (i32.add (global.get $p) (local.get $off)))
+    (if (i32.ge_u (local.get $i) (i32.const 30000))
+      (then
+        (call $fail (local.get $i) (local.get $line) (local.get $col))
+        (unreachable)))
+    (local.get $i))
+
+  (func (export "run")
+    (local $a i32)
+    (local $v i32)
+`
+
+// EmitWat lowers prog into a WebAssembly text module. The tape lives
+// at the start of linear memory and the pointer in a global.
+func EmitWat(prog Runner) ([]byte, error) {
+	code, err := Compile(prog)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &emitter{indent: "  ", depth: 2}
+	e.buf.WriteString(watPrelude)
+	for i := range code {
+		if err := emitWat(e, i, &code[i]); err != nil {
+			return nil, err
+		}
+	}
+	e.buf.WriteString("  )\n)\n")
+	return e.buf.Bytes(), nil
+}
+
+// watAt returns an expression for the address of the cell at off.
+func watAt(off int, pos Pos) string {
+	if off == 0 {
+		return "(global.get $p)"
+	}
+	return fmt.Sprintf("(call $at (i32.const %d) (i32.const %d) (i32.const %d))", off, pos.lno, pos.linepos)
+}
+
+func emitWat(e *emitter, pc int, in *Instruction) error {
+	switch in.op {
+	case OpOpen:
+		e.line(";; [ at %s", lineCol(in.pos))
+		e.line("(block $b%d", pc)
+		e.depth++
+		e.line("(loop $l%d", pc)
+		e.depth++
+		e.line("(br_if $b%d (i32.eqz (i32.load8_u (global.get $p))))", pc)
+	case OpClose:
+		e.line("(br $l%d)", in.arg)
+		e.depth--
+		e.line(")")
+		e.depth--
+		e.line(")")
+	case OpMove:
+		e.line("(global.set $p %s) ;; %s", watAt(in.arg, in.pos), lineCol(in.pos))
+	case OpUpdate:
+		e.line("(local.set $a %s) ;; %s", watAt(in.off, in.pos), lineCol(in.pos))
+		e.line("(i32.store8 (local.get $a) (i32.add (i32.load8_u (local.get $a)) (i32.const %d)))", byte(in.arg))
+	case OpSet:
+		e.line("(i32.store8 %s (i32.const %d)) ;; %s", watAt(in.off, in.pos), byte(in.arg), lineCol(in.pos))
+	case OpMulAdd:
+		e.line("(local.set $v (i32.load8_u (global.get $p))) ;; %s", lineCol(in.pos))
+		e.line("(if (local.get $v)")
+		e.depth++
+		e.line("(then")
+		e.depth++
+		if in.min != 0 {
+			e.line("(drop %s)", watAt(in.min, in.pos))
+		}
+		if in.max != 0 {
+			e.line("(drop %s)", watAt(in.max, in.pos))
+		}
+		for _, t := range in.terms {
+			e.line("(local.set $a (i32.add (global.get $p) (i32.const %d)))", t.off)
+			e.line("(i32.store8 (local.get $a) (i32.add (i32.load8_u (local.get $a)) (i32.mul (local.get $v) (i32.const %d))))", byte(t.factor))
+		}
+		e.line("(i32.store8 (global.get $p) (i32.const 0))))")
+		e.depth -= 2
+	case OpScan:
+		e.line(";; scan at %s", lineCol(in.pos))
+		e.line("(block $b%d", pc)
+		e.depth++
+		e.line("(loop $l%d", pc)
+		e.depth++
+		e.line("(br_if $b%d (i32.eqz (i32.load8_u (global.get $p))))", pc)
+		e.line("(global.set $p %s)", watAt(in.arg, in.pos))
+		e.line("(br $l%d)))", pc)
+		e.depth -= 2
+	case OpGetchar:
+		e.line("(i32.store8 %s (call $getchar)) ;; %s", watAt(in.off, in.pos), lineCol(in.pos))
+	case OpPutchar:
+		e.line("(call $putchar (i32.load8_u %s)) ;; %s", watAt(in.off, in.pos), lineCol(in.pos))
+	default:
+		return fmt.Errorf("cannot emit opcode %d as wat", in.op)
+	}
+	return nil
+}
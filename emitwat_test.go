@@ -0,0 +1,21 @@
+package bf
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEmitWatGolden(t *testing.T) {
+	p := corpus(t, "nested.bf")[0]
+	want, err := os.ReadFile("testdata/nested.wat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := EmitWat(Optimize(parse(t, p.src), AllPasses))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,33 @@
+package bf
+
+import (
+	"fmt"
+)
+
+// EOFMode selects what , stores when the input is exhausted.
+type EOFMode int
+
+const (
+	// EOFMinusOne stores 0xff. It is the default.
+	EOFMinusOne EOFMode = iota
+	// EOFZero stores 0.
+	EOFZero
+	// EOFNoChange leaves the cell as it was.
+	EOFNoChange
+)
+
+var eofModes = map[string]EOFMode{
+	"-1": EOFMinusOne,
+	"0": EOFZero,
+	"nochange": EOFNoChange,
+}
+
+// SetEOFMode selects the named EOF mode.
+func (rt *Runtime) SetEOFMode(name string) error {
+	mode, ok := eofModes[name]
+	if !ok {
+		return fmt.Errorf("unknown eof mode %q", name)
+	}
+	rt.eof = mode
+	return nil
+}
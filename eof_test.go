@@ -0,0 +1,42 @@
+package bf
+
+import (
+	"testing"
+)
+
+func TestEOFModes(t *testing.T) {
+	tests := []struct {
+		mode string
+		cat string
+		eofs string
+	}{
+		// at EOF the cat program stops, loops printing 0xff, or keeps
+		// printing the last byte forever
+		{"0", "ab", "\x00\x00"},
+		{"-1", "ab\xff\xff", "\xff\xff"},
+		{"nochange", "abbb", "\x01\x01"},
+	}
+	for _, tt := range tests {
+		for _, passes := range []Pass{0, AllPasses} {
+			out, _, err := runProgram(t, ",[.,]", "ab", passes, WithEOFMode(tt.mode), WithMaxSteps(14))
+			if tt.mode != "0" && err == nil || out != tt.cat {
+				t.Errorf("eof %s passes %d: cat printed %q with error %v, want %q", tt.mode, passes, out, err, tt.cat)
+			}
+			// every read after EOF behaves the same
+			out, _, err = runProgram(t, "+,.,.", "", passes, WithEOFMode(tt.mode))
+			if err != nil || out != tt.eofs {
+				t.Errorf("eof %s passes %d: printed %q with error %v, want %q", tt.mode, passes, out, err, tt.eofs)
+			}
+		}
+	}
+	if _, err := NewRuntime(WithEOFMode("1")); err == nil {
+		t.Errorf("eof mode 1 accepted")
+	}
+}
+
+func TestEOFDefault(t *testing.T) {
+	out, _, err := runProgram(t, ",.", "", 0)
+	if err != nil || out != "\xff" {
+		t.Errorf("default eof printed %q with error %v", out, err)
+	}
+}
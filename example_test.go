@@ -0,0 +1,47 @@
+package bf_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/timnewsham/gobf"
+)
+
+func ExampleRun() {
+	hello, err := os.ReadFile("testdata/hello.bf")
+	if err != nil {
+		panic(err)
+	}
+	out, err := bf.Run(string(hello), nil)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(out))
+	// Output: Hello World!
+}
+
+func ExampleRunString() {
+	// cat, stopping at the end of input
+	out, err := bf.RunString(",+[-.,+]", "abc\n")
+	fmt.Printf("%q %v\n", out, err)
+	// Output: "abc\n" <nil>
+}
+
+func ExampleRun_errors() {
+	for _, program := range []string{"+[", "+.<", "+[]"} {
+		_, err := bf.Run(program, nil, bf.WithMaxSteps(1000))
+		var pe *bf.ParseError
+		var re *bf.RunError
+		switch {
+		case errors.As(err, &pe):
+			fmt.Printf("%s: parse error at %d:%d\n", program, pe.Line, pe.Col)
+		case errors.As(err, &re):
+			fmt.Printf("%s: run error: %v\n", program, re.Err)
+		}
+	}
+	// Output:
+	// +[: parse error at 1:2
+	// +.<: run error: position -1 (moving -1 from 0) is out of range for 30000 cell tape at 1:3
+	// +[]: run error: step limit reached after 1000 steps at 1:3
+}
@@ -0,0 +1,70 @@
+package bf
+
+import (
+	"errors"
+)
+
+// errPaused stops a RunSteps that has run its instructions.
+var errPaused = errors.New("paused")
+
+// Execution is a bytecode run that goes a few instructions at a time,
+// for callers such as visualizers that look at the Runtime between
+// them. Make one with Runtime.Start.
+type Execution struct {
+	rt *Runtime
+	code []Instruction
+	pc int
+	done bool
+	err error
+}
+
+// Start compiles prog and returns an Execution that runs it on rt,
+// from the start or from where a restored checkpoint left off.
+// Nothing runs until RunSteps is called.
+func (rt *Runtime) Start(prog Runner) (*Execution, error) {
+	code, err := Compile(prog)
+	if err != nil {
+		return nil, err
+	}
+	pc, _, err := rt.begin(code)
+	if err != nil {
+		return nil, rt.end(err)
+	}
+	return &Execution{rt: rt, code: code, pc: pc}, nil
+}
+
+// RunSteps executes at most n more instructions, flushes the output,
+// and reports whether the program has ended, with the error it ended
+// with. Once it has, RunSteps returns the same again. An instruction
+// only counts once it starts, so a , waiting for input holds up
+// RunSteps until the input arrives, and one that fails is not run
+// again.
+func (x *Execution) RunSteps(n int) (done bool, err error) {
+	if x.done {
+		return true, x.err
+	}
+	if n <= 0 {
+		return false, nil
+	}
+	rt := x.rt
+	rt.sliced, rt.budget = true, n
+	err = rt.execute(x.code, x.pc, true)
+	rt.sliced = false
+	if err == errPaused {
+		x.pc = rt.pc
+		return false, rt.flush()
+	}
+	err = rt.finish(rt.end(err))
+	x.done, x.err = true, err
+	return true, err
+}
+
+// PC returns the index of the instruction the execution runs next.
+func (x *Execution) PC() int {
+	return x.pc
+}
+
+// Code returns the instructions the execution runs.
+func (x *Execution) Code() []Instruction {
+	return x.code
+}
@@ -0,0 +1,137 @@
+package bf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunStepsMatchesRun(t *testing.T) {
+	for _, p := range corpus(t, "*.bf") {
+		prog := Optimize(parse(t, p.src), AllPasses)
+		want, _, wantErr := runProgram(t, p.src, p.input, AllPasses)
+		var out bytes.Buffer
+		rt, err := NewRuntime(WithInput(strings.NewReader(p.input)), WithOutput(&out))
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, err := rt.Start(prog)
+		if err != nil {
+			t.Fatal(err)
+		}
+		calls := 0
+		for {
+			done, err := x.RunSteps(1)
+			calls++
+			if done {
+				if (err == nil) != (wantErr == nil) {
+					t.Errorf("%s: error %v, want %v", p.name, err, wantErr)
+				}
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: paused with %v", p.name, err)
+			}
+		}
+		if out.String() != want {
+			t.Errorf("%s: output %q, want %q", p.name, out.String(), want)
+		}
+		if calls < 2 {
+			t.Errorf("%s: done after %d calls", p.name, calls)
+		}
+	}
+}
+
+func TestRunStepsState(t *testing.T) {
+	rt, err := NewRuntime(WithOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// unoptimized, each command is an instruction of its own
+	x, err := rt.Start(parse(t, "+>++>+++<"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []struct {
+		n int
+		ptr int
+		cells string
+	}{
+		{1, 0, "[1 0 0]"},
+		{1, 1, "[1 0 0]"},
+		{2, 1, "[1 2 0]"},
+		{4, 2, "[1 2 3]"},
+	} {
+		if done, err := x.RunSteps(want.n); done || err != nil {
+			t.Fatalf("RunSteps(%d): done %v, error %v", want.n, done, err)
+		}
+		if got := strings.Join(tape(rt, 0, 2), " "); rt.Pointer() != want.ptr || "[" + got + "]" != want.cells {
+			t.Errorf("after %d more: pointer %d, tape [%s], want %d, %s", want.n, rt.Pointer(), got, want.ptr, want.cells)
+		}
+	}
+	if done, err := x.RunSteps(0); done || err != nil {
+		t.Errorf("RunSteps(0): done %v, error %v", done, err)
+	}
+	if done, err := x.RunSteps(100); !done || err != nil || rt.Pointer() != 1 {
+		t.Errorf("to the end: done %v, error %v, pointer %d", done, err, rt.Pointer())
+	}
+	if done, err := x.RunSteps(1); !done || err != nil {
+		t.Errorf("after the end: done %v, error %v", done, err)
+	}
+}
+
+func TestRunStepsError(t *testing.T) {
+	rt, err := NewRuntime(WithOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := rt.Start(parse(t, "+<"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	done, err := x.RunSteps(10)
+	if !done || err == nil {
+		t.Fatalf("done %v, error %v", done, err)
+	}
+	// the failed instruction is not run again
+	if done2, err2 := x.RunSteps(10); !done2 || err2 != err {
+		t.Errorf("again: done %v, error %v, want %v", done2, err2, err)
+	}
+}
+
+func TestRunStepsBlockingInput(t *testing.T) {
+	in, w := io.Pipe()
+	var out bytes.Buffer
+	rt, err := NewRuntime(WithInput(in), WithOutput(&out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := rt.Start(parse(t, ",+."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make(chan error)
+	go func() {
+		done, err := x.RunSteps(1)
+		if done && err == nil {
+			err = errors.New("ended after one instruction")
+		}
+		result <- err
+	}()
+	// the , waits for the input inside RunSteps, which then returns
+	// with it read
+	if _, err := w.Write([]byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-result; err != nil {
+		t.Fatalf("RunSteps(1): %v", err)
+	}
+	if x.PC() != 1 || rt.Cell(0).Int64() != 'a' {
+		t.Errorf("after the read: pc %d, cell %v", x.PC(), rt.Cell(0))
+	}
+	if done, err := x.RunSteps(5); !done || err != nil || out.String() != "b" {
+		t.Errorf("done %v, error %v, output %q", done, err, out.String())
+	}
+}
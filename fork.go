@@ -0,0 +1,163 @@
+package bf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+)
+
+// maxThreads is how many threads a brainfork run may start.
+const maxThreads = 1024
+
+// Fork is the brainfork Y, which starts a new thread running the rest
+// of the program. The parent's current cell is set to zero, and the
+// child gets a copy of the tape with the pointer one cell to the
+// right and that cell set to one.
+//
+// Only the first thread reads input. Every other thread sees the end
+// of input at its first , and each writes its output through a shared
+// writer, so the output of different threads may be interleaved but
+// a single write is never split. Each thread counts its own steps
+// against the step limit and its own tape against the memory limit.
+// Forking needs the bytecode interpreter, so a program that forks
+// can't be traced.
+type Fork struct {
+	pos Pos
+}
+
+func (r *Fork) Run(rt *Runtime) error {
+	if err := rt.step(1, r.pos); err != nil {
+		return err
+	}
+	return fmt.Errorf("cannot fork in the tree interpreter, as used for tracing, at %+v", r.pos)
+}
+
+// forkGroup is the threads of a run that has forked.
+type forkGroup struct {
+	wg sync.WaitGroup
+	mu sync.Mutex
+	errs []error // by thread, from the first child
+	output io.Writer // the first thread's, before forking
+}
+
+// lockedWriter serializes the writes of the threads to w.
+type lockedWriter struct {
+	mu sync.Mutex
+	w io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// fork runs the Y at code[pc], starting a thread at the next
+// instruction.
+func (rt *Runtime) fork(code []Instruction, pc int, at Pos) error {
+	g := rt.forks
+	if g == nil {
+		if err := rt.flush(); err != nil {
+			return fmt.Errorf("%v in fork at %+v", err, at)
+		}
+		g = &forkGroup{output: rt.output}
+		rt.output = &lockedWriter{w: rt.output}
+		rt.buf = nil
+		rt.forks = g
+	}
+	c := rt.clone()
+	if err := c.move(1, at); err != nil {
+		return err
+	}
+	if err := c.set(0, 1, 0, at); err != nil {
+		return err
+	}
+	if err := rt.set(0, 0, 0, at); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	if len(g.errs) >= maxThreads {
+		g.mu.Unlock()
+		return fmt.Errorf("more than %d threads at %+v", maxThreads, at)
+	}
+	id := len(g.errs) + 1
+	g.errs = append(g.errs, nil)
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		err := c.execute(code, pc + 1, false)
+		if ferr := c.flush(); err == nil && ferr != nil {
+			err = fmt.Errorf("%v flushing output", ferr)
+		}
+		if err != nil {
+			g.mu.Lock()
+			g.errs[id - 1] = fmt.Errorf("thread %d: %w", id, err)
+			g.mu.Unlock()
+		}
+	}()
+	return nil
+}
+
+// clone returns a copy of rt for a new thread, with its own tape and
+// no input, hooks or checkpoints.
+func (rt *Runtime) clone() *Runtime {
+	c := *rt
+	c.input = nil
+	c.exhausted = true
+	c.buf = nil
+	c.trace = false
+	c.checkpointPath = ""
+	c.profile = nil
+	c.hot = nil
+	c.cover = nil
+	c.stats = nil
+	c.debug = nil
+	c.watches = nil
+	c.snaps = nil
+	c.transcript = nil
+	c.replay = nil
+	c.prompt = ""
+	c.calls = append([]int(nil), rt.calls...)
+	switch {
+	case rt.bigs != nil:
+		c.bigs = make([]*big.Int, len(rt.bigs))
+		for i, v := range rt.bigs {
+			if v != nil {
+				c.bigs[i] = new(big.Int).Set(v)
+			}
+		}
+	case rt.wide != nil:
+		c.wide = append([]uint32(nil), rt.wide...)
+	default:
+		c.store = append([]byte(nil), rt.store...)
+	}
+	return &c
+}
+
+// joinThreads waits for the threads the run forked and restores the
+// first thread's output, returning err, from the first thread, joined
+// with any errors from the others.
+func (rt *Runtime) joinThreads(err error) error {
+	g := rt.forks
+	g.wg.Wait()
+	if ferr := rt.flush(); err == nil && ferr != nil {
+		err = fmt.Errorf("%v flushing output", ferr)
+	}
+	rt.output = g.output
+	rt.buf = nil
+	errs := []error{err}
+	for _, e := range g.errs {
+		if e != nil {
+			errs = append(errs, e)
+		}
+	}
+	if len(errs) == 1 {
+		return err
+	}
+	return errors.Join(errs...)
+}
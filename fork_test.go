@@ -0,0 +1,74 @@
+package bf
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// runFork parses src as brainfork and runs it optimized on input,
+// returning the output with its bytes sorted, since the threads may
+// write in any order, and the error the run ended with.
+func runFork(t *testing.T, src, input string, opts ...Option) (string, error) {
+	t.Helper()
+	prog, err := (&Parser{Dialect: DialectBrainfork}).ParseBytes([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	opts = append([]Option{WithInput(strings.NewReader(input)), WithOutput(&out)}, opts...)
+	rt, err := NewRuntime(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rt.Run(Optimize(prog, AllPasses))
+	b := out.Bytes()
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	return string(b), err
+}
+
+func TestFork(t *testing.T) {
+	// after a Y the parent's cell is 0 and the child's is 1, so adding
+	// 65 prints A in the parent and B in the child
+	const letter = "+>++++++++[<++++++++>-]<."
+	tests := []struct {
+		src string
+		input string
+		out string
+	}{
+		{"Y" + letter, "", "AB"},
+		{"YYY" + letter, "", "AAAABBBB"},
+		// only the first thread reads; the child sees the end of input
+		{"Y,.", "x", "x\xff"},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 10; i++ {
+			if out, err := runFork(t, tt.src, tt.input); err != nil || out != tt.out {
+				t.Errorf("%q: output %q, error %v, want %q", tt.src, out, err, tt.out)
+				break
+			}
+		}
+	}
+}
+
+func TestForkErrors(t *testing.T) {
+	// the child, with 1 in its cell, runs off the tape; the parent
+	// does too if it moves left
+	_, err := runFork(t, "Y[>]", "", WithTapeSize(2))
+	if err == nil || err.Error() != "thread 1: position 2 (moving +1 from 1) is out of range for 2 cell tape at 1:2" {
+		t.Errorf("child error %v", err)
+	}
+	_, err = runFork(t, "Y[>]<", "", WithTapeSize(2))
+	if err == nil || !strings.HasPrefix(err.Error(), "position -1 (moving -1 from 0)") || !strings.Contains(err.Error(), "\nthread 1: ") {
+		t.Errorf("both errors %v", err)
+	}
+	_, err = runFork(t, "Y", "", WithTrace(&bytes.Buffer{}))
+	if err == nil || err.Error() != "cannot fork in the tree interpreter, as used for tracing, at 1:1" {
+		t.Errorf("tracing error %v", err)
+	}
+	_, err = runFork(t, "+[Y]", "")
+	if err == nil || !strings.Contains(err.Error(), "more than 1024 threads at 1:3") {
+		t.Errorf("thread limit error %v", err)
+	}
+}
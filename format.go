@@ -0,0 +1,136 @@
+package bf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DumpTree writes prog to w with one node per line, giving each its
+// position and indenting loop and procedure bodies by their depth.
+func DumpTree(w io.Writer, prog Runner) error {
+	return dumpTree(w, prog, 0)
+}
+
+func dumpTree(w io.Writer, r Runner, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	switch x := r.(type) {
+	case *Block:
+		for _, cmd := range x.seq {
+			if err := dumpTree(w, cmd, depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *Loop:
+		if _, err := fmt.Fprintf(w, "%s[ at %s\n", indent, x.pos); err != nil {
+			return err
+		}
+		if err := dumpTree(w, x.block, depth + 1); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "%s] at %s\n", indent, x.end)
+		return err
+	case *DefineProc:
+		if _, err := fmt.Fprintf(w, "%s( at %s\n", indent, x.pos); err != nil {
+			return err
+		}
+		if err := dumpTree(w, x.block, depth + 1); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "%s) at %s\n", indent, x.end)
+		return err
+	}
+	in, ok := instruction(r)
+	if !ok {
+		_, err := fmt.Fprintf(w, "%s%T\n", indent, r)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s%s at %s\n", indent, in.String(), in.pos)
+	return err
+}
+
+// children describes how many commands a block holds.
+func children(b *Block) string {
+	if len(b.seq) == 1 {
+		return "(1 child)"
+	}
+	return fmt.Sprintf("(%d children)", len(b.seq))
+}
+
+// String gives the size of the block rather than its contents, which
+// DumpTree writes out.
+func (r *Block) String() string {
+	return "{...}" + children(r)
+}
+
+func (r *Loop) String() string {
+	return "[...]" + children(r.block)
+}
+
+func (r *DefineProc) String() string {
+	return "(...)" + children(r.block)
+}
+
+// nodeString is the String of a node with a single instruction, in
+// the notation of Instruction.String.
+func nodeString(r Runner) string {
+	in, _ := instruction(r)
+	return in.String()
+}
+
+func (r *Move) String() string {
+	return nodeString(r)
+}
+
+func (r *Scan) String() string {
+	return nodeString(r)
+}
+
+func (r *Update) String() string {
+	return nodeString(r)
+}
+
+func (r *Set) String() string {
+	return nodeString(r)
+}
+
+func (r *MulAdd) String() string {
+	return nodeString(r)
+}
+
+func (r *Getchar) String() string {
+	return nodeString(r)
+}
+
+func (r *Putchar) String() string {
+	return nodeString(r)
+}
+
+func (r *Dump) String() string {
+	return nodeString(r)
+}
+
+func (r *CallProc) String() string {
+	return nodeString(r)
+}
+
+func (r *Fork) String() string {
+	return nodeString(r)
+}
+
+func (r *Halt) String() string {
+	return nodeString(r)
+}
+
+func (r *Store) String() string {
+	return nodeString(r)
+}
+
+func (r *Retrieve) String() string {
+	return nodeString(r)
+}
+
+func (r *Hang) String() string {
+	return nodeString(r)
+}
@@ -0,0 +1,44 @@
+package bf
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDumpTreeGolden(t *testing.T) {
+	p := corpus(t, "nested.bf")[0]
+	want, err := os.ReadFile("testdata/nested.tree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := treeString(t, Optimize(parse(t, p.src), PassCoalesce)); got != string(want) {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestNodeStrings(t *testing.T) {
+	prog := parse(t, "+[>-<]\n,.")
+	cmds := prog.(*Block).Commands()
+	var got []string
+	for _, cmd := range cmds {
+		got = append(got, cmd.(interface{ String() string }).String())
+	}
+	if s := strings.Join(got, " "); s != "+ [...](3 children) , ." {
+		t.Errorf("strings %q", s)
+	}
+	if s := prog.(*Block).String(); s != "{...}(4 children)" {
+		t.Errorf("block string %q", s)
+	}
+}
+
+func TestLoopTraceLine(t *testing.T) {
+	var trace strings.Builder
+	if _, _, err := runProgram(t, "[>+++<-]", "", 0, WithTrace(&trace)); err != nil {
+		t.Fatal(err)
+	}
+	// the loop is skipped, so its one trace line is all there is
+	if got, want := trace.String(), "[          1:1      ptr 0 cell 0\n"; got != want {
+		t.Errorf("loop traced as %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,97 @@
+package bf
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fuzzSeeds adds the testdata programs to f's corpus, each with every
+// value of the byte that picks how it is parsed or run.
+func fuzzSeeds(f *testing.F) {
+	for _, p := range corpus(f, "*.bf") {
+		for mode := 0; mode < 8; mode++ {
+			f.Add([]byte(p.src), byte(mode))
+		}
+	}
+	f.Add([]byte("+(-):Y@$!#%include \"x\"\n12+"), byte(0xff))
+}
+
+// fuzzParser returns a Parser with the dialect and extensions that
+// the bits of mode pick.
+func fuzzParser(mode byte) *Parser {
+	dialects := []Dialect{DialectBF, DialectPbrain, DialectBrainfork, DialectEBF1, DialectOok}
+	return &Parser{
+		Dialect: dialects[int(mode) % len(dialects)],
+		Debug: mode & 0x08 != 0,
+		Counts: mode & 0x10 != 0,
+		Bang: mode & 0x20 != 0,
+		KeepComments: mode & 0x40 != 0,
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, src []byte, mode byte) {
+		prog, err := fuzzParser(mode).ParseBytes(src)
+		if err != nil {
+			return
+		}
+		if err := DumpTree(io.Discard, prog); err != nil {
+			t.Fatal(err)
+		}
+		Walk(prog, func(Runner) bool { return true })
+		Optimize(prog, AllPasses)
+	})
+}
+
+func FuzzRun(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, src []byte, mode byte) {
+		prog, err := fuzzParser(mode &^ 0x20).ParseBytes(src)
+		if err != nil {
+			return
+		}
+		opts := []Option{
+			WithInput(strings.NewReader("")),
+			WithOutput(io.Discard),
+			WithTapeSize(64),
+			WithMaxSteps(10000),
+			WithTapeMode([]string{"fixed", "grow", "wrap", "infinite"}[mode >> 6], 1024),
+		}
+		switch mode >> 3 & 3 {
+		case 1:
+			opts = append(opts, WithCellWidth(16))
+		case 2:
+			opts = append(opts, WithCellWidth(32))
+		case 3:
+			opts = append(opts, WithBigCells(true))
+		}
+		if mode & 0x20 != 0 {
+			opts = append(opts, WithStrictCells())
+		}
+		for _, passes := range []Pass{0, AllPasses} {
+			rt, err := NewRuntime(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// any error is fine, so long as the run ends
+			rt.Run(Optimize(prog, passes))
+		}
+	})
+}
+
+func TestStrictBigClear(t *testing.T) {
+	// strict mode once checked big cells for wrapping by indexing the
+	// byte tape, which a big-cell Runtime doesn't have
+	_, rt, err := runProgram(t, "+[+]", "", AllPasses, WithBigCells(false), WithStrictCells())
+	if err != nil || rt.Cell(0).String() != "0" {
+		t.Errorf("cell %v, error %v", rt.Cell(0), err)
+	}
+	_, rt, err = runProgram(t, "+[+]", "", ExactPasses, WithBigCells(false), WithStrictCells(), WithMaxSteps(1000))
+	var limit *StepLimitError
+	if !errors.As(err, &limit) || rt.Cell(0).String() != "500" {
+		t.Errorf("exact passes: cell %v, error %v", rt.Cell(0), err)
+	}
+}
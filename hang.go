@@ -0,0 +1,57 @@
+package bf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrHang is wrapped by the error a Hang returns when it is entered.
+var ErrHang = errors.New("infinite loop detected")
+
+// Hang is a loop that can never end once entered, such as [] or
+// [<>], put in its place by PassHangs. Entering it with a nonzero
+// cell is an error instead of running forever.
+type Hang struct {
+	pos Pos
+}
+
+func (r *Hang) Run(rt *Runtime) error {
+	err := rt.hang(r.pos)
+	if rt.trace {
+		rt.traceRun(r, r.pos)
+	}
+	return err
+}
+
+// hang tests the cell for a Hang at at.
+func (rt *Runtime) hang(at Pos) error {
+	if err := rt.step(1, at); err != nil {
+		return err
+	}
+	if !rt.zero(rt.pos) {
+		return fmt.Errorf("%w at %+v", ErrHang, at)
+	}
+	return nil
+}
+
+// hangLoop replaces a loop whose body only moves and updates, leaving
+// the pointer and the loop's cell as they were, with a Hang.
+func hangLoop(l *Loop) Runner {
+	off, delta := 0, 0
+	for _, cmd := range l.block.seq {
+		switch x := cmd.(type) {
+		case *Update:
+			if off + x.off == 0 {
+				delta += x.n
+			}
+		case *Move:
+			off += x.dir
+		default:
+			return nil
+		}
+	}
+	if off != 0 || delta != 0 {
+		return nil
+	}
+	return &Hang{l.pos}
+}
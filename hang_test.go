@@ -0,0 +1,46 @@
+package bf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHangs(t *testing.T) {
+	for _, c := range []struct {
+		src string
+		at string // where the hang is, or "" if the program ends
+	}{
+		{"+[]", "1:2"},
+		{"+[<>]", "1:2"},
+		{"+>+[+-<>]", "1:4"},
+		{"+[-]", ""},
+		{"+[>]", ""},
+		{"[]+", ""},
+	} {
+		// the tree interpreter runs a Hang itself
+		for _, opts := range [][]Option{nil, {WithTrace(&strings.Builder{})}} {
+			_, _, err := runProgram(t, c.src, "", AllPasses | PassHangs, append(opts, WithMaxSteps(1000))...)
+			if c.at == "" {
+				if err != nil {
+					t.Errorf("%q, %d options: %v", c.src, len(opts), err)
+				}
+				continue
+			}
+			if !errors.Is(err, ErrHang) || !strings.Contains(err.Error(), "infinite loop detected at " + c.at) {
+				t.Errorf("%q, %d options: error %v, want a hang at %s", c.src, len(opts), err, c.at)
+			}
+		}
+	}
+}
+
+func TestHangsNeedPass(t *testing.T) {
+	var se *StepLimitError
+	if _, _, err := runProgram(t, "+[]", "", AllPasses, WithMaxSteps(1000)); !errors.As(err, &se) {
+		t.Errorf("without PassHangs: error %v, want the step limit", err)
+	}
+	// output makes the loop do something, so it is left alone
+	if _, _, err := runProgram(t, "+[.]", "", AllPasses | PassHangs, WithMaxSteps(1000)); !errors.As(err, &se) {
+		t.Errorf("loop with output: error %v, want the step limit", err)
+	}
+}
@@ -0,0 +1,134 @@
+package bf
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// parse parses src or fails the test.
+func parse(t testing.TB, src string) Runner {
+	t.Helper()
+	prog, err := ParseString(src)
+	if err != nil {
+		t.Fatalf("parse %q: %v", src, err)
+	}
+	return prog
+}
+
+// treeString returns DumpTree's rendering of prog.
+func treeString(t testing.TB, prog Runner) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := DumpTree(&buf, prog); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// runProgram parses src, optimizes it with passes and runs it as
+// bytecode on input, returning the output, the Runtime and the error
+// the run ended with.
+func runProgram(t testing.TB, src, input string, passes Pass, opts ...Option) (string, *Runtime, error) {
+	t.Helper()
+	var out bytes.Buffer
+	opts = append([]Option{WithInput(strings.NewReader(input)), WithOutput(&out)}, opts...)
+	rt, err := NewRuntime(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rt.Run(Optimize(parse(t, src), passes))
+	return out.String(), rt, err
+}
+
+// tape returns the logical cells from lo to hi of rt as strings.
+func tape(rt *Runtime, lo, hi int) []string {
+	var cells []string
+	for i := lo; i <= hi; i++ {
+		c := rt.Cell(i)
+		if c == nil {
+			cells = append(cells, "-")
+			continue
+		}
+		cells = append(cells, c.String())
+	}
+	return cells
+}
+
+// sameRun runs src on input unoptimized and optimized by passes, and
+// fails the test unless the output, error and final tape agree.
+func sameRun(t *testing.T, src, input string, passes Pass, opts ...Option) {
+	t.Helper()
+	plainOut, plain, plainErr := runProgram(t, src, input, 0, opts...)
+	optOut, opt, optErr := runProgram(t, src, input, passes, opts...)
+	if plainOut != optOut {
+		t.Errorf("%q: output %q unoptimized, %q optimized", src, plainOut, optOut)
+	}
+	if (plainErr == nil) != (optErr == nil) {
+		t.Errorf("%q: error %v unoptimized, %v optimized", src, plainErr, optErr)
+	}
+	if plain.Pointer() != opt.Pointer() {
+		t.Errorf("%q: pointer %d unoptimized, %d optimized", src, plain.Pointer(), opt.Pointer())
+	}
+	if d := diffTapes(plain, opt); d != "" {
+		t.Errorf("%q: %s", src, d)
+	}
+}
+
+// corpusProgram is a sample program from testdata with its input.
+type corpusProgram struct {
+	name string
+	src string
+	input string
+}
+
+// corpus returns the sample programs matching the testdata glob.
+func corpus(t testing.TB, glob string) []corpusProgram {
+	t.Helper()
+	fns, err := filepath.Glob(filepath.Join("testdata", glob))
+	if err != nil || len(fns) == 0 {
+		t.Fatalf("no sample programs in testdata/%s: %v", glob, err)
+	}
+	var progs []corpusProgram
+	for _, fn := range fns {
+		src, err := os.ReadFile(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		input, err := os.ReadFile(strings.TrimSuffix(fn, ".bf") + ".in")
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		progs = append(progs, corpusProgram{strings.TrimSuffix(filepath.Base(fn), ".bf"), string(src), string(input)})
+	}
+	return progs
+}
+
+// checkEmitted runs cmd, a translation of p built as passes name says,
+// on p's input and checks it prints what p does when interpreted, and
+// fails where p does, with the out of range error on stderr.
+func checkEmitted(t *testing.T, p corpusProgram, name string, cmd *exec.Cmd) {
+	t.Helper()
+	want := expected(t, p.name + ".out")
+	wantErr := expected(t, p.name + ".err") != ""
+	cmd.Stdin = strings.NewReader(p.input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exit *exec.ExitError
+	switch {
+	case err != nil && !errors.As(err, &exit):
+		t.Fatal(err)
+	case wantErr && (err == nil || !strings.HasPrefix(stderr.String(), "error position ")):
+		t.Errorf("%s %s: exit %v, stderr %q, want an out of range error", p.name, name, err, stderr.String())
+	case !wantErr && err != nil:
+		t.Errorf("%s %s: exit %v: %s", p.name, name, err, stderr.Bytes())
+	}
+	if string(out) != want {
+		t.Errorf("%s %s: output %q, want %q", p.name, name, out, want)
+	}
+}
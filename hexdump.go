@@ -0,0 +1,76 @@
+package bf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// HexWriter writes what is written to it as a hex dump in the layout
+// of xxd: each line has the offset, 16 bytes in hex in groups of
+// two, and the bytes as text, with dots for those that are not
+// printable. Writes of any size are fine; Close writes the last,
+// partial line.
+type HexWriter struct {
+	w io.Writer
+	line []byte
+	off int64
+}
+
+// NewHexWriter returns a HexWriter writing its dump to w.
+func NewHexWriter(w io.Writer) *HexWriter {
+	return &HexWriter{w: w, line: make([]byte, 0, 16)}
+}
+
+func (h *HexWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		take := 16 - len(h.line)
+		if take > len(p) {
+			take = len(p)
+		}
+		h.line = append(h.line, p[:take]...)
+		p = p[take:]
+		if len(h.line) == 16 {
+			if err := h.writeLine(); err != nil {
+				return n, err
+			}
+		}
+		n += take
+	}
+	return n, nil
+}
+
+// Close writes the partial line left by earlier writes, if any. It
+// does not close the underlying writer.
+func (h *HexWriter) Close() error {
+	if len(h.line) == 0 {
+		return nil
+	}
+	return h.writeLine()
+}
+
+func (h *HexWriter) writeLine() error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%08x:", h.off)
+	for i, c := range h.line {
+		if i % 2 == 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%02x", c)
+	}
+	// pad a partial line so its text lines up
+	hex := len(h.line) * 2 + (len(h.line) + 1) / 2
+	b.WriteString(strings.Repeat(" ", 40 - hex + 2))
+	for _, c := range h.line {
+		if c < ' ' || c > '~' {
+			c = '.'
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte('\n')
+	h.off += int64(len(h.line))
+	h.line = h.line[:0]
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
@@ -0,0 +1,63 @@
+package bf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// hexBytes is the input of testdata/dump/bytes.hex, which xxd made:
+// printable and unprintable bytes ending in a partial line of odd
+// length.
+func hexBytes() []byte {
+	var p []byte
+	for c := 0x1e; c <= 0x42; c++ {
+		p = append(p, byte(c))
+	}
+	return append(p, 0x7d, 0x7e, 0x7f, 0xff)
+}
+
+func TestHexWriter(t *testing.T) {
+	want, err := os.ReadFile(filepath.Join("testdata", "dump", "bytes.hex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := hexBytes()
+	// the dump doesn't depend on how the writes split the data
+	for _, size := range []int{1, 3, 15, 16, 17, len(data)} {
+		var out strings.Builder
+		h := NewHexWriter(&out)
+		for p := data; len(p) > 0; {
+			n := size
+			if n > len(p) {
+				n = len(p)
+			}
+			if m, err := h.Write(p[:n]); m != n || err != nil {
+				t.Fatalf("writes of %d: wrote %d, %v", size, m, err)
+			}
+			p = p[n:]
+		}
+		if err := h.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != string(want) {
+			t.Errorf("writes of %d:\n%s\nwant:\n%s", size, out.String(), want)
+		}
+	}
+}
+
+func TestHexWriterWholeLines(t *testing.T) {
+	var out strings.Builder
+	h := NewHexWriter(&out)
+	h.Write(bytes.Repeat([]byte("a"), 32))
+	want := "00000000: 6161 6161 6161 6161 6161 6161 6161 6161  aaaaaaaaaaaaaaaa\n00000010: 6161 6161 6161 6161 6161 6161 6161 6161  aaaaaaaaaaaaaaaa\n"
+	if out.String() != want {
+		t.Errorf("before Close:\n%s", out.String())
+	}
+	// nothing is left for Close to write
+	if err := h.Close(); err != nil || out.String() != want {
+		t.Errorf("after Close: %v\n%s", err, out.String())
+	}
+}
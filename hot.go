@@ -0,0 +1,123 @@
+package bf
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// HotLoops attributes the work of a bytecode run to the loops it
+// was done in. Work is counted in instructions executed, and a loop's
+// body work includes that of the loops nested inside it.
+type HotLoops struct {
+	code []Instruction
+	total int64
+	loops map[int]*LoopStats // by pc of the loop's OpOpen
+	stack []loopFrame
+}
+
+// LoopStats is the activity of one loop.
+type LoopStats struct {
+	Pos Pos
+	Entered int64 // times the loop was entered
+	Iterations int64 // times its body ran
+	Work int64 // instructions executed in its body
+}
+
+type loopFrame struct {
+	open int
+	start int64
+}
+
+// EnableHotLoops makes the next bytecode run record HotLoops.
+func (rt *Runtime) EnableHotLoops() {
+	rt.hot = &HotLoops{}
+}
+
+// HotLoops returns the loop statistics of the last run, or nil if
+// they are not enabled.
+func (rt *Runtime) HotLoops() *HotLoops {
+	return rt.hot
+}
+
+func (h *HotLoops) reset(code []Instruction) {
+	h.code = code
+	h.total = 0
+	h.loops = map[int]*LoopStats{}
+	h.stack = h.stack[:0]
+}
+
+// enter starts the first iteration of the loop opened at pc.
+func (h *HotLoops) enter(pc int) {
+	s := h.loops[pc]
+	if s == nil {
+		s = &LoopStats{Pos: h.code[pc].pos}
+		h.loops[pc] = s
+	}
+	s.Entered++
+	s.Iterations++
+	h.stack = append(h.stack, loopFrame{pc, h.total})
+}
+
+// iterate starts another iteration of the innermost loop.
+// A run resumed from a checkpoint may be inside loops it never
+// entered, which are left out.
+func (h *HotLoops) iterate() {
+	if len(h.stack) == 0 {
+		return
+	}
+	h.loops[h.stack[len(h.stack)-1].open].Iterations++
+}
+
+// exit leaves the innermost loop.
+func (h *HotLoops) exit() {
+	if len(h.stack) == 0 {
+		return
+	}
+	f := h.stack[len(h.stack)-1]
+	h.stack = h.stack[:len(h.stack)-1]
+	h.loops[f.open].Work += h.total - f.start
+}
+
+// finish closes any loops a run stopped inside of.
+func (h *HotLoops) finish() {
+	for len(h.stack) > 0 {
+		h.exit()
+	}
+}
+
+// Loops returns the statistics of every loop that ran, busiest first.
+func (h *HotLoops) Loops() []*LoopStats {
+	loops := []*LoopStats{}
+	for _, s := range h.loops {
+		loops = append(loops, s)
+	}
+	sort.Slice(loops, func(a, b int) bool {
+		if loops[a].Work != loops[b].Work {
+			return loops[a].Work > loops[b].Work
+		}
+		return loops[a].Pos.pos < loops[b].Pos.pos
+	})
+	return loops
+}
+
+// Write prints a table of the n busiest loops to w.
+func (h *HotLoops) Write(w io.Writer, n int) error {
+	loops := h.Loops()
+	if len(loops) > n {
+		loops = loops[:n]
+	}
+	if _, err := fmt.Fprintf(w, "%-8s %10s %12s %14s %7s\n", "line:col", "entered", "iterations", "work", "share"); err != nil {
+		return err
+	}
+	for _, s := range loops {
+		share := 0.0
+		if h.total > 0 {
+			share = 100 * float64(s.Work) / float64(h.total)
+		}
+		if _, err := fmt.Fprintf(w, "%-8s %10d %12d %14d %6.1f%%\n", lineCol(s.Pos), s.Entered, s.Iterations, s.Work, share); err != nil {
+			return err
+		}
+	}
+	return nil
+}
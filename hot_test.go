@@ -0,0 +1,40 @@
+package bf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHotLoops(t *testing.T) {
+	// a short loop at 1:3, then one at 1:21 running ten times round
+	// a loop at 1:33 that runs ten times each
+	src := "++[>+<-]>>++++++++++[>++++++++++[>+<-]<-]"
+	_, rt, err := runProgram(t, src, "", 0, func(rt *Runtime) error {
+		rt.EnableHotLoops()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, l := range rt.HotLoops().Loops() {
+		got = append(got, fmt.Sprintf("%+v %d %d", l.Pos, l.Entered, l.Iterations))
+	}
+	want := []string{"1:21 1 10", "1:33 10 100", "1:3 1 2"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Fatalf("loops %v, want %v", got, want)
+	}
+	loops := rt.HotLoops().Loops()
+	if loops[0].Work <= loops[1].Work || loops[1].Work < 100 * 5 || loops[2].Work > 20 {
+		t.Errorf("implausible work %d, %d, %d", loops[0].Work, loops[1].Work, loops[2].Work)
+	}
+	var buf strings.Builder
+	if err := rt.HotLoops().Write(&buf, 1); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "1:21 ") || !strings.HasSuffix(lines[1], "%") {
+		t.Errorf("report for the top loop:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,89 @@
+package bf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxIncludeDepth is how deeply %include directives may nest.
+const maxIncludeDepth = 16
+
+const includeDirective = "%include"
+
+// includer builds the source of a program from its files, expanding
+// their %include directives.
+type includer struct {
+	src []byte
+	files []fileStart
+	chain []string // the files being expanded, outermost first
+	lines []int // the line of the %include in each but the last
+}
+
+// expand appends src, from the file fn, to the program, with each
+// %include line replaced by the file it names. A bad directive, a
+// file that can't be read, and a cycle are errors at the directive.
+func (in *includer) expand(fn string, src []byte) error {
+	in.chain = append(in.chain, fn)
+	defer func() { in.chain = in.chain[:len(in.chain) - 1] }()
+	lno := 0
+	for len(src) > 0 {
+		lno++
+		line := src
+		if i := bytes.IndexByte(src, '\n'); i >= 0 {
+			line = src[:i + 1]
+		}
+		src = src[len(line):]
+		if !bytes.HasPrefix(line, []byte(includeDirective)) {
+			in.src = append(in.src, line...)
+			continue
+		}
+
+		name, err := strconv.Unquote(strings.TrimSpace(string(line[len(includeDirective):])))
+		if err != nil || name == "" {
+			return in.errorAt(fn, lno, "bad %include, want %include \"file\"", nil)
+		}
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(filepath.Dir(fn), name)
+		}
+		for i, f := range in.chain {
+			if f != "" && filepath.Clean(f) == filepath.Clean(name) {
+				cycle := append(append([]string(nil), in.chain[i:]...), name)
+				return in.errorAt(fn, lno, "%include cycle: " + strings.Join(cycle, " includes "), nil)
+			}
+		}
+		if len(in.chain) > maxIncludeDepth {
+			return in.errorAt(fn, lno, fmt.Sprintf("%%include nested more than %d deep", maxIncludeDepth), nil)
+		}
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return in.errorAt(fn, lno, fmt.Sprintf("cannot %%include %q", name), err)
+		}
+
+		in.files = append(in.files, fileStart{len(in.src), name, 1})
+		in.lines = append(in.lines, lno)
+		err = in.expand(name, b)
+		in.lines = in.lines[:len(in.lines) - 1]
+		if err != nil {
+			return err
+		}
+		in.files = append(in.files, fileStart{len(in.src), fn, lno + 1})
+	}
+	return nil
+}
+
+// errorAt returns a ParseError for the %include on line lno of fn,
+// adding the files that included it to msg.
+func (in *includer) errorAt(fn string, lno int, msg string, err error) error {
+	for i := len(in.lines) - 1; i >= 0; i-- {
+		if in.chain[i] == "" {
+			msg += fmt.Sprintf(", included from line %d", in.lines[i])
+		} else {
+			msg += fmt.Sprintf(", included from %s:%d", in.chain[i], in.lines[i])
+		}
+	}
+	return &ParseError{File: fn, Line: lno, Col: 1, Rune: '%', Msg: msg, Err: err}
+}
@@ -0,0 +1,106 @@
+package bf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes each file of files, by name, to a new directory,
+// which it returns.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range files {
+		fn := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fn, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestIncludeNested(t *testing.T) {
+	// lib/add includes digit, which is found next to it
+	dir := writeFiles(t, map[string]string{
+		"main": "++++++\n%include \"lib/add\"\n>.\n",
+		"lib/add": "[>\n%include \"digit\"\n<-]\n",
+		"lib/digit": "++++++++\n",
+	})
+	p := &Parser{Includes: true}
+	prog, err := p.ParseFile(filepath.Join(dir, "main"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := New(nil, &out).Run(Optimize(prog, AllPasses)); err != nil || out.String() != "0" {
+		t.Errorf("output %q, error %v", out.String(), err)
+	}
+	// positions name the file they are in with its own lines
+	prog, err = p.ParseFile(filepath.Join(dir, "main"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.ReplaceAll(treeString(t, Optimize(prog, PassCoalesce)), dir + string(filepath.Separator), "")
+	want := "+6 at main:1:1\n[ at lib/add:1:1\n  > at lib/add:1:2\n  +8 at lib/digit:1:1\n  < at lib/add:3:1\n  - at lib/add:3:2\n] at lib/add:3:3\n> at main:3:1\n. at main:3:2\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestIncludeErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"missing": "+\n%include \"nowhere\"\n",
+		"bad": "%include nowhere\n",
+		"a": "%include \"b\"\n",
+		"b": "+\n%include \"a\"\n",
+		"close": "%include \"stray\"\n",
+		"stray": "+\n+]\n",
+	})
+	name := func(fn string) string { return filepath.Join(dir, fn) }
+	tests := []struct {
+		fn string
+		err string
+	}{
+		{"missing", name("missing") + ":2:1: cannot %include \"" + name("nowhere") + "\": open " + name("nowhere") + ": no such file or directory"},
+		{"bad", name("bad") + ":1:1: bad %include, want %include \"file\""},
+		{"a", name("b") + ":2:1: %include cycle: " + name("a") + " includes " + name("b") + " includes " + name("a") + ", included from " + name("a") + ":1"},
+		{"close", name("stray") + ":2:2: unexpected close bracket"},
+	}
+	for _, tt := range tests {
+		_, err := (&Parser{Includes: true}).ParseFile(name(tt.fn))
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%s: error %v, want %s", tt.fn, err, tt.err)
+		}
+	}
+}
+
+func TestIncludeDepth(t *testing.T) {
+	files := map[string]string{}
+	for i := 0; i <= maxIncludeDepth + 1; i++ {
+		files[fmt.Sprint(i)] = fmt.Sprintf("%%include \"%d\"\n", i + 1)
+	}
+	files[fmt.Sprint(maxIncludeDepth + 2)] = "+\n"
+	dir := writeFiles(t, files)
+	_, err := (&Parser{Includes: true}).ParseFile(filepath.Join(dir, "0"))
+	if err == nil || !strings.Contains(err.Error(), "%include nested more than 16 deep") {
+		t.Errorf("error %v", err)
+	}
+}
+
+func TestIncludeOff(t *testing.T) {
+	// without Includes the directive is comment
+	dir := writeFiles(t, map[string]string{"main": "%include \"lib\"\n+\n"})
+	prog, err := (&Parser{}).ParseFile(filepath.Join(dir, "main"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := treeString(t, prog); got != "+ at 2:1\n" {
+		t.Errorf("got:\n%s", got)
+	}
+}
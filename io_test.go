@@ -0,0 +1,150 @@
+package bf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// stallReader returns (0, nil) stalls times before each byte of its
+// data.
+type stallReader struct {
+	data string
+	stalls int
+	n int
+}
+
+func (r *stallReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	if r.n < r.stalls {
+		r.n++
+		return 0, nil
+	}
+	r.n = 0
+	p[0] = r.data[0]
+	r.data = r.data[1:]
+	return 1, nil
+}
+
+func TestParseReaderContracts(t *testing.T) {
+	src := "+[->+<]\n>."
+	want := treeString(t, parse(t, src))
+	for name, r := range map[string]io.Reader{
+		"data with EOF": iotest.DataErrReader(strings.NewReader(src)),
+		"stalls": &stallReader{data: src, stalls: 3},
+	} {
+		prog, err := Parse(r)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if got := treeString(t, prog); got != want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", name, got, want)
+		}
+	}
+}
+
+func TestGetcharReaderContracts(t *testing.T) {
+	for name, r := range map[string]io.Reader{
+		"data with EOF": iotest.DataErrReader(strings.NewReader("ab")),
+		"one byte with EOF": iotest.DataErrReader(iotest.OneByteReader(strings.NewReader("ab"))),
+		"stalls": &stallReader{data: "ab", stalls: 3},
+	} {
+		var out bytes.Buffer
+		rt, err := NewRuntime(WithInput(r), WithOutput(&out))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rt.Run(parse(t, ",.,.,.")); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		// neither byte is lost or doubled, and then comes EOF
+		if out.String() != "ab\xff" {
+			t.Errorf("%s: read %q", name, out.String())
+		}
+	}
+}
+
+func TestInputNotRebuffered(t *testing.T) {
+	br := bufio.NewReader(strings.NewReader("x"))
+	rt, err := NewRuntime(WithInput(br))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rt.input != br {
+		t.Errorf("buffered input was wrapped again")
+	}
+	rt, err = NewRuntime(WithInput(iotest.OneByteReader(strings.NewReader("x"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rt.input.(*bufio.Reader); !ok {
+		t.Errorf("unbuffered input was not buffered")
+	}
+}
+
+func BenchmarkCat(b *testing.B) {
+	input := bytes.Repeat([]byte("The quick brown fox jumps over the lazy dog.\n"), 4 << 20 / 45)
+	prog := Optimize(parse(b, ",[.,]"), AllPasses)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rt, err := NewRuntime(WithInput(iotest.OneByteReader(bytes.NewReader(input))), WithOutput(io.Discard), WithEOFMode("0"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := rt.Run(prog); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// closedReader returns its data, then io.EOF once, then fails as a
+// closed file would.
+type closedReader struct {
+	data string
+	eof bool
+}
+
+func (r *closedReader) Read(p []byte) (int, error) {
+	if len(r.data) > 0 {
+		n := copy(p, r.data)
+		r.data = r.data[n:]
+		return n, nil
+	}
+	if !r.eof {
+		r.eof = true
+		return 0, io.EOF
+	}
+	return 0, errors.New("file already closed")
+}
+
+func TestStickyEOF(t *testing.T) {
+	for _, tt := range []struct {
+		mode string
+		out string
+	}{
+		{"-1", "a\xff\xff\xff"},
+		{"0", "a\x00\x00\x00"},
+		{"nochange", "aaaa"},
+	} {
+		var out bytes.Buffer
+		rt, err := NewRuntime(WithInput(&closedReader{data: "a"}), WithOutput(&out), WithEOFMode(tt.mode))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rt.Run(parse(t, ",.,.,.,.")); err != nil {
+			t.Errorf("eof %s: %v", tt.mode, err)
+		}
+		if out.String() != tt.out {
+			t.Errorf("eof %s: output %q, want %q", tt.mode, out.String(), tt.out)
+		}
+	}
+}
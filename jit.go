@@ -0,0 +1,86 @@
+package bf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plugin"
+	"runtime"
+)
+
+// JitFunc is the entry point exported by a compiled program plugin.
+type JitFunc func(input io.Reader, output io.Writer) error
+
+// JIT translates prog to Go, builds it as a plugin with the go tool,
+// and loads it. Built plugins are cached in the user cache directory
+// keyed by a hash of the generated source and the Go version, so
+// running the same program again skips the build.
+func JIT(prog Runner) (JitFunc, error) {
+	src, err := EmitGoPlugin(prog)
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(cache, "bf-jit")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(append(src, runtime.Version()...))
+	so := filepath.Join(dir, hex.EncodeToString(sum[:]) + ".so")
+
+	if _, err := os.Stat(so); err != nil {
+		if err := buildPlugin(src, so); err != nil {
+			return nil, err
+		}
+	}
+
+	p, err := plugin.Open(so)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup("Run")
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := sym.(func(io.Reader, io.Writer) error)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s has Run of type %T", so, sym)
+	}
+	return fn, nil
+}
+
+// buildPlugin builds src as a plugin at so, via a temporary module.
+// Tests replace it to see whether JIT builds.
+var buildPlugin = func(src []byte, so string) error {
+	tmp, err := os.MkdirTemp("", "bf-jit")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	mod := "module bfjit\n\ngo 1.17\n"
+	if err := os.WriteFile(filepath.Join(tmp, "go.mod"), []byte(mod), 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), src, 0644); err != nil {
+		return err
+	}
+
+	// build to a temporary name so a failed build never leaves a
+	// partial plugin in the cache.
+	out := so + ".tmp"
+	cmd := exec.Command("go", "build", "-buildmode=plugin", "-o", out, ".")
+	cmd.Dir = tmp
+	if msg, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("building plugin: %v\n%s", err, msg)
+	}
+	return os.Rename(out, so)
+}
@@ -0,0 +1,73 @@
+package bf
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tempCache points the user cache directory, where JIT keeps its
+// plugins, at a new directory and returns it. The go tool keeps its
+// own build cache, so that the plugin's packages are built only once.
+func tempCache(t *testing.T) string {
+	t.Helper()
+	gocache, err := exec.Command("go", "env", "GOCACHE").Output()
+	if err != nil {
+		t.Skipf("no go tool: %v", err)
+	}
+	t.Setenv("GOCACHE", strings.TrimSpace(string(gocache)))
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+	if dir, err := os.UserCacheDir(); err != nil || dir != cache {
+		t.Skip("the user cache directory doesn't follow XDG_CACHE_HOME here")
+	}
+	return cache
+}
+
+func TestJITCache(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a plugin with the go tool")
+	}
+	dir := tempCache(t)
+	build := buildPlugin
+	defer func() { buildPlugin = build }()
+	builds := 0
+	buildPlugin = func(src []byte, so string) error {
+		builds++
+		return build(src, so)
+	}
+	prog := Optimize(parse(t, ",+[-.,+]"), AllPasses)
+	// the second time the plugin comes from the cache, unbuilt
+	for i := 0; i < 2; i++ {
+		run, err := JIT(prog)
+		if err != nil {
+			t.Skipf("no plugins here: %v", err)
+		}
+		var out bytes.Buffer
+		if err := run(strings.NewReader("cached"), &out); err != nil || out.String() != "cached" {
+			t.Errorf("run %d: output %q, error %v", i, out.String(), err)
+		}
+	}
+	if builds != 1 {
+		t.Errorf("built %d times", builds)
+	}
+	if sos, _ := filepath.Glob(filepath.Join(dir, "bf-jit", "*.so")); len(sos) != 1 {
+		t.Errorf("cache holds %q", sos)
+	}
+}
+
+func TestJITBuildFails(t *testing.T) {
+	dir := tempCache(t)
+	// without the go tool there is no plugin, and nothing half built
+	// left in the cache
+	t.Setenv("PATH", "")
+	if _, err := JIT(parse(t, "+.")); err == nil {
+		t.Fatal("built without the go tool")
+	}
+	if left, _ := filepath.Glob(filepath.Join(dir, "bf-jit", "*")); len(left) != 0 {
+		t.Errorf("cache holds %q", left)
+	}
+}
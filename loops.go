@@ -0,0 +1,59 @@
+package bf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxLoopFrames is how many enclosing loops a LoopError lists.
+const maxLoopFrames = 10
+
+// LoopError is a runtime error raised inside one or more loops.
+// Loops holds the positions of the innermost of them, up to
+// maxLoopFrames, and Depth how many there were in all.
+type LoopError struct {
+	Err error
+	Loops []Pos
+	Depth int
+}
+
+func (e *LoopError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Err.Error())
+	for _, pos := range e.Loops {
+		fmt.Fprintf(&b, ", in loop at %s", pos)
+	}
+	if more := e.Depth - len(e.Loops); more > 0 {
+		fmt.Fprintf(&b, ", in %d more loops", more)
+	}
+	return b.String()
+}
+
+func (e *LoopError) Unwrap() error {
+	return e.Err
+}
+
+// inLoop adds the loop at pos as the next frame out of err.
+func inLoop(err error, pos Pos) error {
+	e, ok := err.(*LoopError)
+	if !ok {
+		e = &LoopError{Err: err}
+	}
+	if len(e.Loops) < maxLoopFrames {
+		e.Loops = append(e.Loops, pos)
+	}
+	e.Depth++
+	return e
+}
+
+// inLoops wraps an error raised by code[pc] with the loops enclosing
+// it. They are found by searching back for the open brackets whose
+// match lies beyond pc, so nothing is tracked until there is an error.
+func inLoops(err error, code []Instruction, pc int) error {
+	for i := pc - 1; i >= 0; i-- {
+		if code[i].op == OpOpen && code[i].arg > pc {
+			err = inLoop(err, code[i].pos)
+		}
+	}
+	return err
+}
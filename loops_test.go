@@ -0,0 +1,36 @@
+package bf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLoopStack(t *testing.T) {
+	want := "position -1 (moving -1 from 0) is out of range for 30000 cell tape at 1:11, in loop at 1:8, in loop at 1:5, in loop at 1:2"
+	// the tree interpreter and bytecode find the loops differently
+	for _, opts := range [][]Option{nil, {WithTrace(&strings.Builder{})}} {
+		_, _, err := runProgram(t, "+[>+[>+[<<<<]]]", "", 0, opts...)
+		if err == nil || err.Error() != want {
+			t.Errorf("%d options: error %v, want %q", len(opts), err, want)
+		}
+		var le *LoopError
+		if !errors.As(err, &le) || le.Depth != 3 || fmt.Sprint(le.Loops) != "[1:8 1:5 1:2]" {
+			t.Errorf("%d options: error %#v", len(opts), err)
+		}
+	}
+}
+
+func TestLoopStackCap(t *testing.T) {
+	depth := maxLoopFrames + 5
+	src := "+" + strings.Repeat("[", depth) + "<" + strings.Repeat("]", depth)
+	_, _, err := runProgram(t, src, "", 0)
+	var le *LoopError
+	if !errors.As(err, &le) || le.Depth != depth || len(le.Loops) != maxLoopFrames {
+		t.Fatalf("error %v", err)
+	}
+	if !strings.HasSuffix(err.Error(), ", in loop at 1:7, in 5 more loops") {
+		t.Errorf("error %q", err)
+	}
+}
@@ -0,0 +1,56 @@
+package bf
+
+import (
+	"fmt"
+	"math/big"
+	"unsafe"
+)
+
+// MemoryLimitError is returned when a growing tape would take more
+// memory than SetMaxMemory allows. Pos is the command that moved or
+// reached past the tape, Cells the size of the tape before it and
+// Limit the limit in bytes.
+type MemoryLimitError struct {
+	Pos Pos
+	Cells int
+	Limit int64
+}
+
+func (e *MemoryLimitError) Error() string {
+	return fmt.Sprintf("tape of %d cells cannot grow within the %d byte memory limit at %+v", e.Cells, e.Limit, e.Pos)
+}
+
+// SetMaxMemory limits how many bytes the cells of a growing tape may
+// take, or removes the limit if n is zero. It only stops the tape
+// growing, not starting larger. Big cells are counted without their
+// digits, so their tapes can take more.
+func (rt *Runtime) SetMaxMemory(n int64) error {
+	if n < 0 {
+		return fmt.Errorf("memory limit %d must not be negative", n)
+	}
+	rt.maxMem = n
+	return nil
+}
+
+// cellBytes is how much memory each cell of the tape takes.
+func (rt *Runtime) cellBytes() int64 {
+	switch {
+	case rt.wide != nil:
+		return 4
+	case rt.bigs != nil:
+		return int64(unsafe.Sizeof(&big.Int{}) + unsafe.Sizeof(big.Int{}))
+	}
+	return 1
+}
+
+// memCells returns how many cells the memory limit allows the tape,
+// which is at most max.
+func (rt *Runtime) memCells(max int) int {
+	if rt.maxMem == 0 {
+		return max
+	}
+	if n := rt.maxMem / rt.cellBytes(); n < int64(max) {
+		return int(n)
+	}
+	return max
+}
@@ -0,0 +1,58 @@
+package bf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMaxMemory(t *testing.T) {
+	tests := []struct {
+		src string
+		mode string
+		opts []Option
+		cells int // the tape when it hits the limit
+	}{
+		{"+[>+]", "grow", nil, 4096},
+		{"+[<+]", "infinite", nil, 4096},
+		{"+[>+]", "grow", []Option{WithCellWidth(32)}, 1024},
+	}
+	for _, tt := range tests {
+		opts := append([]Option{WithTapeSize(16), WithTapeMode(tt.mode, 1 << 30), WithMaxMemory(4096)}, tt.opts...)
+		// unoptimized the move reaches past the tape, optimized the
+		// update it is folded into
+		for passes, at := range map[Pass]string{0: "1:3", AllPasses: "1:4"} {
+			_, rt, err := runProgram(t, tt.src, "", passes, opts...)
+			var me *MemoryLimitError
+			if !errors.As(err, &me) {
+				t.Errorf("%q %s passes %d: error %v, want the memory limit", tt.src, tt.mode, passes, err)
+				continue
+			}
+			if me.Limit != 4096 || me.Cells != tt.cells || fmt.Sprint(me.Pos) != at {
+				t.Errorf("%q %s passes %d: %+v", tt.src, tt.mode, passes, me)
+			}
+			if rt.Cell(rt.Pointer()) == nil {
+				t.Errorf("%q %s passes %d: pointer %d left off the tape", tt.src, tt.mode, passes, rt.Pointer())
+			}
+		}
+	}
+}
+
+func TestMaxMemoryUnder(t *testing.T) {
+	// 1000 cells fit in 4096 bytes, and 0 is no limit at all
+	for _, limit := range []int64{4096, 0} {
+		_, rt, err := runProgram(t, strings.Repeat(">", 1000) + "+", "", AllPasses, WithTapeMode("grow", 1 << 30), WithMaxMemory(limit))
+		if err != nil {
+			t.Errorf("limit %d: %v", limit, err)
+			continue
+		}
+		if rt.Pointer() != 1000 || rt.Cell(1000).Int64() != 1 {
+			t.Errorf("limit %d: pointer %d, tape %v", limit, rt.Pointer(), tape(rt, 998, 1000))
+		}
+	}
+	var rt Runtime
+	if err := rt.SetMaxMemory(-1); err == nil {
+		t.Error("negative limit accepted")
+	}
+}
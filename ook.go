@@ -0,0 +1,84 @@
+package bf
+
+import (
+	"fmt"
+)
+
+// ookCommands maps the punctuation of each pair of Ook! tokens to the
+// command it stands for.
+var ookCommands = map[string]rune{
+	".?": '>',
+	"?.": '<',
+	"..": '+',
+	"!!": '-',
+	"!.": '.',
+	".!": ',',
+	"!?": '[',
+	"?!": ']',
+}
+
+// maxOokWord is the longest word nextOok keeps for an error message.
+const maxOokWord = 20
+
+// nextOok is next for Ook!, whose source is words separated by white
+// space, each Ook. Ook? or Ook!, read in pairs. It returns the
+// command a pair stands for, leaving p.tok at the first word of it.
+// Any other word, a pair that is no command, or a word left without
+// a pair at the end is an error.
+func (p *Parser) nextOok() rune {
+	var pair []byte
+	var first Pos
+	for !p.stop {
+		word, at, ok := p.word()
+		if !ok {
+			if len(pair) == 1 {
+				p.errorAt(first, 0, "Ook! token without a pair", nil)
+			}
+			return 0
+		}
+		if len(word) != 4 || string(word[:3]) != "Ook" || (word[3] != '.' && word[3] != '?' && word[3] != '!') {
+			p.errorAt(at, 0, fmt.Sprintf("unexpected %q, want Ook. Ook? or Ook!", word), nil)
+			continue
+		}
+		if len(pair) == 0 {
+			first = at
+		}
+		pair = append(pair, word[3])
+		if len(pair) < 2 {
+			continue
+		}
+		if ch, ok := ookCommands[string(pair)]; ok {
+			p.tok = first
+			return ch
+		}
+		p.errorAt(first, 0, fmt.Sprintf("Ook%c Ook%c is not a command", pair[0], pair[1]), nil)
+		pair = pair[:0]
+	}
+	return 0
+}
+
+// word returns the next run of bytes other than white space, up to
+// maxOokWord of them, and the position of its first byte. It returns
+// false at the end of input.
+func (p *Parser) word() ([]byte, Pos, bool) {
+	var word []byte
+	var at Pos
+	for {
+		b, ok := p.advance()
+		if !ok {
+			return word, at, len(word) > 0
+		}
+		if b == ' ' || b == '\t' || b == '\n' || b == '\r' {
+			if len(word) > 0 {
+				return word, at, true
+			}
+			continue
+		}
+		if len(word) == 0 {
+			at = p.pos
+		}
+		if len(word) < maxOokWord {
+			word = append(word, b)
+		}
+	}
+}
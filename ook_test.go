@@ -0,0 +1,56 @@
+package bf
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestOokHello(t *testing.T) {
+	src, err := os.ReadFile("testdata/ook/hello.ook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("testdata/ook/hello.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog, err := (&Parser{Dialect: DialectOok}).ParseBytes(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := New(nil, &out).Run(Optimize(prog, AllPasses)); err != nil || out.String() != string(want) {
+		t.Errorf("output %q, error %v, want %q", out.String(), err, want)
+	}
+}
+
+func TestOokPositions(t *testing.T) {
+	// each command is at the first word of its pair
+	prog, err := (&Parser{Dialect: DialectOok}).ParseBytes([]byte("Ook. Ook.\n  Ook! Ook? Ook! Ook!\nOok? Ook!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := treeString(t, prog), "+ at 1:1\n[ at 2:3\n  - at 2:13\n] at 3:1\n"; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestOokErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		err string
+	}{
+		// the bad word is skipped, leaving the Ook. before it unpaired
+		{"Ook. Ook.\nOok. Moo", "2:1: Ook! token without a pair\n2:6: unexpected \"Moo\", want Ook. Ook? or Ook!"},
+		{"Ook. Ook? Ook? Ook?", "1:11: Ook? Ook? is not a command"},
+		{"Ook. Ook.\n\nOok.", "3:1: Ook! token without a pair"},
+		{"Ook? Ook!", "1:1: unexpected close bracket"},
+	}
+	for _, tt := range tests {
+		_, err := (&Parser{Dialect: DialectOok}).ParseBytes([]byte(tt.src))
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%q: error %v, want %s", tt.src, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package bf
+
+// Pass selects one of the optimizer's rewrites.
+type Pass uint
+
+const (
+	PassDeadLoops Pass = 1 << iota
+	PassCoalesce
+	PassClear
+	PassMulAdd
+	PassScan
+	PassFuse
+	// PassHangs replaces loops that can never end once entered with a
+	// Hang. It is not in AllPasses, as some programs hang on purpose.
+	PassHangs
+
+	AllPasses = PassDeadLoops | PassCoalesce | PassClear | PassMulAdd | PassScan | PassFuse
+
+	// ExactPasses leaves out the rewrites that rely on cell
+	// arithmetic wrapping around, for cells that never wrap.
+	ExactPasses = AllPasses &^ (PassClear | PassMulAdd)
+
+	// StrictPasses leaves out coalescing, which could hide an
+	// overflow part way through a run like +++--, for strict cells.
+	StrictPasses = AllPasses &^ PassCoalesce
+)
+
+// Optimize rewrites a parsed program into an equivalent one that
+// executes fewer nodes, using the selected passes.
+func Optimize(prog Runner, passes Pass) Runner {
+	block, ok := prog.(*Block)
+	if !ok {
+		return prog
+	}
+	if passes & PassDeadLoops != 0 {
+		deadLoops(block, true)
+	}
+	if passes & PassCoalesce != 0 {
+		coalesce(block)
+	}
+	if passes & PassHangs != 0 {
+		rewriteLoops(block, hangLoop)
+	}
+	if passes & PassClear != 0 {
+		rewriteLoops(block, clearLoop)
+	}
+	if passes & PassMulAdd != 0 {
+		rewriteLoops(block, mulLoop)
+	}
+	if passes & PassScan != 0 {
+		rewriteLoops(block, scanLoop)
+	}
+	if passes & PassFuse != 0 {
+		fuse(block)
+	}
+	return prog
+}
+
+// deadLoops removes loops that can never be entered because the
+// current cell is known to be zero: at the start of the program when
+// top is set, and immediately after another loop exits.
+func deadLoops(block *Block, top bool) {
+	zero := top
+	seq := block.seq[:0]
+	for _, cmd := range block.seq {
+		switch x := cmd.(type) {
+		case *Loop:
+			if zero {
+				continue
+			}
+			deadLoops(x.block, false)
+			zero = true
+		case *DefineProc:
+			// defining leaves the cell alone
+			deadLoops(x.block, false)
+		case *Set:
+			zero = x.value == 0
+		case *Scan, *MulAdd:
+			zero = true
+		default:
+			zero = false
+		}
+		seq = append(seq, cmd)
+	}
+	block.seq = seq
+}
+
+// coalesce merges runs of adjacent Updates or Moves in block (and in
+// any loops or procedures it contains) into a single node carrying the net count.
+// The merged node keeps the pos of the first command in the run,
+// and runs that cancel out entirely are dropped.
+func coalesce(block *Block) {
+	seq := block.seq[:0]
+	for _, cmd := range block.seq {
+		switch x := cmd.(type) {
+		case *Loop:
+			coalesce(x.block)
+		case *DefineProc:
+			coalesce(x.block)
+		}
+
+		if len(seq) > 0 && merge(seq[len(seq)-1], cmd) {
+			if isNop(seq[len(seq)-1]) {
+				seq = seq[:len(seq)-1]
+			}
+			continue
+		}
+		seq = append(seq, cmd)
+	}
+	block.seq = seq
+}
+
+// merge folds b into a if they are the same kind of counted command.
+func merge(a, b Runner) bool {
+	switch x := a.(type) {
+	case *Update:
+		if y, ok := b.(*Update); ok && x.off == y.off {
+			x.n += y.n
+			return true
+		}
+	case *Move:
+		if y, ok := b.(*Move); ok {
+			x.dir += y.dir
+			return true
+		}
+	}
+	return false
+}
+
+// isNop reports whether a coalesced node has no effect.
+func isNop(r Runner) bool {
+	switch x := r.(type) {
+	case *Update:
+		return x.n == 0
+	case *Move:
+		return x.dir == 0
+	}
+	return false
+}
+
+// rewriteLoops replaces each loop in block, innermost first, with the
+// result of fn. Loops for which fn returns nil are left alone.
+func rewriteLoops(block *Block, fn func(*Loop) Runner) {
+	for i, cmd := range block.seq {
+		if d, ok := cmd.(*DefineProc); ok {
+			rewriteLoops(d.block, fn)
+			continue
+		}
+		l, ok := cmd.(*Loop)
+		if !ok {
+			continue
+		}
+		rewriteLoops(l.block, fn)
+		if r := fn(l); r != nil {
+			block.seq[i] = r
+		}
+	}
+}
+
+// clearLoop replaces loops of the form [-] or [+] with a Set to zero.
+func clearLoop(l *Loop) Runner {
+	if len(l.block.seq) != 1 {
+		return nil
+	}
+	u, ok := l.block.seq[0].(*Update)
+	if !ok || (u.n != 1 && u.n != -1) {
+		return nil
+	}
+	return &Set{l.pos, 0, 0, u.n}
+}
+
+// mulLoop replaces balanced loops such as [->+>++<<] with a MulAdd.
+// The body must contain only Updates and Moves, return the pointer
+// to where it started, and decrement the loop cell by exactly one.
+// No cell may be both incremented and decremented, which could hide a
+// step that overflows strict cells, as in [--+].
+func mulLoop(l *Loop) Runner {
+	off, min, max := 0, 0, 0
+	factors := map[int]int{}
+	order := []int{}
+	up, down := map[int]bool{}, map[int]bool{}
+	for _, cmd := range l.block.seq {
+		switch x := cmd.(type) {
+		case *Update:
+			if _, ok := factors[off]; !ok {
+				order = append(order, off)
+			}
+			factors[off] += x.n
+			if x.n > 0 {
+				up[off] = true
+			} else {
+				down[off] = true
+			}
+			if up[off] && down[off] {
+				return nil
+			}
+		case *Move:
+			off += x.dir
+			if off < min {
+				min = off
+			}
+			if off > max {
+				max = off
+			}
+		default:
+			return nil
+		}
+	}
+	if off != 0 || factors[0] != -1 {
+		return nil
+	}
+
+	m := &MulAdd{pos: l.pos, min: min, max: max}
+	for _, o := range order {
+		if o != 0 && factors[o] != 0 {
+			m.terms = append(m.terms, MulTerm{o, factors[o]})
+		}
+	}
+	return m
+}
+
+// scanLoop replaces loops such as [>] or [<<] with a Scan.
+func scanLoop(l *Loop) Runner {
+	if len(l.block.seq) != 1 {
+		return nil
+	}
+	m, ok := l.block.seq[0].(*Move)
+	if !ok {
+		return nil
+	}
+	return &Scan{l.pos, m.dir}
+}
+
+// fuse folds the Moves in each straight-line run of commands into the
+// offsets of the commands that follow them, leaving a single Move for
+// the net pointer change at the end of the run. Every offset-addressed
+// command checks its own cell is in range, so no bounds check is lost.
+func fuse(block *Block) {
+	seq := block.seq[:0]
+	var mv *Move
+	flush := func() {
+		if mv != nil && mv.dir != 0 {
+			seq = append(seq, mv)
+		}
+		mv = nil
+	}
+	for _, cmd := range block.seq {
+		off := 0
+		if mv != nil {
+			off = mv.dir
+		}
+		switch x := cmd.(type) {
+		case *Move:
+			if mv == nil {
+				mv = x
+			} else {
+				mv.dir += x.dir
+			}
+			continue
+		case *Update:
+			x.off += off
+		case *Set:
+			x.off += off
+		case *Getchar:
+			x.off += off
+		case *Putchar:
+			x.off += off
+		case *Loop:
+			flush()
+			fuse(x.block)
+		case *DefineProc:
+			flush()
+			fuse(x.block)
+		default:
+			flush()
+		}
+		seq = append(seq, cmd)
+	}
+	flush()
+	block.seq = seq
+}
@@ -0,0 +1,265 @@
+package bf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeadLoops(t *testing.T) {
+	tests := []struct {
+		src string
+		want string
+	}{
+		// a leading comment loop goes, with the loops inside it
+		{"[a [comment] loop]+.", "+ at 1:19\n. at 1:20\n"},
+		{"[-][+]", ""},
+		// a loop right after a loop has ended is as dead
+		{"+[-][.]", "+ at 1:1\n[ at 1:2\n  - at 1:3\n] at 1:4\n"},
+		{"+[-[.][.]]", "+ at 1:1\n[ at 1:2\n  - at 1:3\n  [ at 1:4\n    . at 1:5\n  ] at 1:6\n] at 1:10\n"},
+		// but not one after input or arithmetic, which may leave the
+		// cell set
+		{",[.]", ", at 1:1\n[ at 1:2\n  . at 1:3\n] at 1:4\n"},
+		{"+[.]", "+ at 1:1\n[ at 1:2\n  . at 1:3\n] at 1:4\n"},
+		{"-[.]", "- at 1:1\n[ at 1:2\n  . at 1:3\n] at 1:4\n"},
+		{"[-]>[.]", "> at 1:4\n[ at 1:5\n  . at 1:6\n] at 1:7\n"},
+		// nor the first loop inside a loop, entered with the cell set
+		{"+[[.]-]", "+ at 1:1\n[ at 1:2\n  [ at 1:3\n    . at 1:4\n  ] at 1:5\n  - at 1:6\n] at 1:7\n"},
+	}
+	for _, tt := range tests {
+		prog := Optimize(parse(t, tt.src), PassDeadLoops)
+		if got := treeString(t, prog); got != tt.want {
+			t.Errorf("dead loops %q:\n%s\nwant:\n%s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestCoalesceUpdates(t *testing.T) {
+	tests := []struct {
+		src string
+		want string
+	}{
+		{"+++--", "+ at 1:1\n"},
+		{"-----", "-5 at 1:1\n"},
+		{"+-", ""},
+		{"+-+.", "+ at 1:3\n. at 1:4\n"},
+		{"++.++", "++ at 1:1\n. at 1:3\n++ at 1:4\n"},
+		{",[++++]", ", at 1:1\n[ at 1:2\n  +4 at 1:3\n] at 1:7\n"},
+	}
+	for _, tt := range tests {
+		prog := Optimize(parse(t, tt.src), PassCoalesce)
+		if got := treeString(t, prog); got != tt.want {
+			t.Errorf("coalesce %q:\n%s\nwant:\n%s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestCoalesceKeepsFirstPos(t *testing.T) {
+	block := Optimize(parse(t, "\n  +++"), PassCoalesce).(*Block)
+	if len(block.Commands()) != 1 {
+		t.Fatalf("got %d commands, want 1", len(block.Commands()))
+	}
+	u := block.Commands()[0].(*Update)
+	if u.Delta() != 3 || u.Pos().Line() != 2 || u.Pos().Col() != 3 {
+		t.Errorf("got Update %+d at %v, want +3 at 2:3", u.Delta(), u.Pos())
+	}
+}
+
+// loopHeavy spends its time in long runs of + and - inside loops.
+const loopHeavy = "++++++++[>++++++++[>++++++++++++++++--------++++++++<-]<-]"
+
+func BenchmarkCoalesce(b *testing.B) {
+	for _, bb := range []struct {
+		name string
+		passes Pass
+	}{
+		{"none", 0},
+		{"coalesce", PassCoalesce},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			prog := Optimize(parse(b, loopHeavy), bb.passes)
+			for i := 0; i < b.N; i++ {
+				rt := New(nil, nil)
+				rt.interpret = true
+				if err := rt.Run(prog); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestClearLoop(t *testing.T) {
+	for _, src := range []string{"[-]", "[+]", "+++[-]", "-[+]", "+++>++[-]<[+]", ",[-]>,[+]."} {
+		prog := Optimize(parse(t, src), PassClear)
+		if CountNodes(prog)["Loop"] != 0 {
+			t.Errorf("%q kept a loop:\n%s", src, treeString(t, prog))
+		}
+		sameRun(t, src, "ab", PassClear)
+	}
+	// only a single step of one counts
+	for _, src := range []string{"+[--]", "+[->]", "+[-.]"} {
+		if CountNodes(Optimize(parse(t, src), PassClear))["Set"] != 0 {
+			t.Errorf("%q became a Set", src)
+		}
+	}
+}
+
+func TestClearLoopTrace(t *testing.T) {
+	var trace strings.Builder
+	_, _, err := runProgram(t, "+++[-]", "", PassClear, WithTrace(&trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(trace.String(), "[-]        1:4      ptr 0 cell 0") {
+		t.Errorf("trace does not show the Set:\n%s", trace.String())
+	}
+}
+
+func TestMulAdd(t *testing.T) {
+	tests := []struct {
+		src string
+		want []string // cells 0 to 3
+	}{
+		{"+++[->+>++<<]", []string{"0", "3", "6", "0"}},
+		{"[->+>++<<]", []string{"0", "0", "0", "0"}},
+		{">+<+++[->>---<<]", []string{"0", "1", "247", "0"}},
+		{"++++++++++++++++[->++++++++++++++++<]", []string{"0", "0", "0", "0"}},
+		{"+++++[->>>+++++<<<]>>>", []string{"0", "0", "0", "25"}},
+		{">+++++[-<+++>]", []string{"15", "0", "0", "0"}},
+	}
+	for _, tt := range tests {
+		prog := Optimize(parse(t, tt.src), PassMulAdd)
+		if CountNodes(prog)["MulAdd"] != 1 {
+			t.Errorf("%q has no MulAdd:\n%s", tt.src, treeString(t, prog))
+		}
+		_, rt, err := runProgram(t, tt.src, "", PassMulAdd)
+		if err != nil {
+			t.Errorf("%q: %v", tt.src, err)
+			continue
+		}
+		if got := strings.Join(tape(rt, 0, 3), " "); got != strings.Join(tt.want, " ") {
+			t.Errorf("%q: cells %s, want %s", tt.src, got, strings.Join(tt.want, " "))
+		}
+		sameRun(t, tt.src, "", PassMulAdd)
+	}
+	// unbalanced loops, or ones that don't count down by one, stay loops
+	for _, src := range []string{"+[->+>]", "+[-->+<]", "+[->+<+]", "+[->.<]"} {
+		if CountNodes(Optimize(parse(t, src), PassMulAdd))["MulAdd"] != 0 {
+			t.Errorf("%q became a MulAdd", src)
+		}
+	}
+}
+
+// multiply spends its time in copy and multiply loops.
+const multiply = "++++++++[>++++++++[>++++++++[>+>++>+++<<<-]<-]<-]"
+
+func BenchmarkMulAdd(b *testing.B) {
+	for _, bb := range []struct {
+		name string
+		passes Pass
+	}{
+		{"loops", PassCoalesce},
+		{"muladd", PassCoalesce | PassMulAdd},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			code, err := Compile(Optimize(parse(b, multiply), bb.passes))
+			if err != nil {
+				b.Fatal(err)
+			}
+			for i := 0; i < b.N; i++ {
+				if err := New(nil, nil).RunBytecode(code); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		src string
+		ptr int
+		err string
+	}{
+		{"+>+>+>>+<<<<[>]", 3, ""},
+		{">>>>>>>>>[<]", 9, ""},
+		{"+>+>+>+[<]", 0, "position -1 (moving -1 from 0) is out of range for 10 cell tape at 1:8"},
+		{"+>+>+>+>+>+>+>+>+>+<<<<<<<<<[>]", 0, "position 10 (moving +1 from 9) is out of range for 10 cell tape at 1:29"},
+		{"+>>+>>+>>+<<<<<<[>>]", 8, ""},
+		{"+>>+>>+>>+>>+<<<<<<<<[>>]", 0, "position 10 (moving +2 from 8) is out of range for 10 cell tape at 1:22"},
+		{"+>>>+>>>+>>>+[<<<]", 0, "position -3 (moving -3 from 0) is out of range for 10 cell tape at 1:14"},
+	}
+	for _, tt := range tests {
+		prog := Optimize(parse(t, tt.src), PassCoalesce | PassScan)
+		if CountNodes(prog)["Scan"] != 1 {
+			t.Errorf("%q has no Scan:\n%s", tt.src, treeString(t, prog))
+		}
+		_, rt, err := runProgram(t, tt.src, "", PassCoalesce | PassScan, WithTapeSize(10))
+		switch {
+		case tt.err != "":
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%q: error %v, want %q", tt.src, err, tt.err)
+			}
+		case err != nil:
+			t.Errorf("%q: %v", tt.src, err)
+		case rt.Pointer() != tt.ptr:
+			t.Errorf("%q: pointer at %d, want %d", tt.src, rt.Pointer(), tt.ptr)
+		}
+	}
+}
+
+func BenchmarkScan(b *testing.B) {
+	for _, bb := range []struct {
+		name string
+		passes Pass
+	}{
+		{"loop", PassCoalesce},
+		{"scan", PassCoalesce | PassScan},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			code, err := Compile(Optimize(parse(b, "[>]"), bb.passes))
+			if err != nil {
+				b.Fatal(err)
+			}
+			// ones up to a zero at the far end, which scans leave alone
+			rt := New(nil, nil)
+			for i := range rt.store[:len(rt.store) - 1] {
+				rt.store[i] = 1
+			}
+			for i := 0; i < b.N; i++ {
+				rt.pos = 0
+				if err := rt.RunBytecode(code); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestFuse(t *testing.T) {
+	tests := []struct {
+		src string
+		want string
+	}{
+		{">>+<<", "+@+2 at 1:3\n"},
+		{">>+<.<", "+@+2 at 1:3\n.@+1 at 1:5\n"},
+		{">>+<.", "+@+2 at 1:3\n.@+1 at 1:5\n> at 1:1\n"},
+		{">,>-<<", ",@+1 at 1:2\n-@+2 at 1:4\n"},
+	}
+	for _, tt := range tests {
+		prog := Optimize(parse(t, tt.src), PassCoalesce | PassFuse)
+		if got := treeString(t, prog); got != tt.want {
+			t.Errorf("fuse %q:\n%s\nwant:\n%s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestFuseCorpus(t *testing.T) {
+	for _, p := range corpus(t, "*.bf") {
+		t.Run(p.name, func(t *testing.T) {
+			sameRun(t, p.src, p.input, PassCoalesce)
+			sameRun(t, p.src, p.input, PassCoalesce | PassFuse)
+			sameRun(t, p.src, p.input, AllPasses)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package bf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Option configures a Runtime made by NewRuntime.
+type Option func(rt *Runtime) error
+
+// NewRuntime returns a Runtime configured by opts, applied in order.
+// Without options it reads stdin and writes stdout with a
+// DefaultTapeSize tape of 8 bit cells, fixed at its ends, and no
+// tracing or limits.
+func NewRuntime(opts ...Option) (*Runtime, error) {
+	rt := New(os.Stdin, os.Stdout)
+	for _, opt := range opts {
+		if err := opt(rt); err != nil {
+			return nil, err
+		}
+	}
+	if rt.mode != TapeFixed && rt.maxCells < rt.size() {
+		return nil, fmt.Errorf("tape limit %d is smaller than the tape size %d", rt.maxCells, rt.size())
+	}
+	return rt, nil
+}
+
+// WithInput makes , read from r.
+func WithInput(r io.Reader) Option {
+	return func(rt *Runtime) error {
+		if r == nil {
+			return errors.New("input must not be nil")
+		}
+		rt.input = byteReader(r)
+		return nil
+	}
+}
+
+// WithOutput makes . write to w.
+func WithOutput(w io.Writer) Option {
+	return func(rt *Runtime) error {
+		if w == nil {
+			return errors.New("output must not be nil")
+		}
+		rt.output = w
+		rt.buf = nil
+		return nil
+	}
+}
+
+// WithUnbufferedOutput writes each byte of output as it is produced.
+func WithUnbufferedOutput() Option {
+	return func(rt *Runtime) error {
+		return rt.SetBuffered(false)
+	}
+}
+
+// WithNumericOutput makes . write cells in decimal, as for
+// SetNumericOutput.
+func WithNumericOutput(sep string) Option {
+	return func(rt *Runtime) error {
+		rt.SetNumericOutput(sep)
+		return nil
+	}
+}
+
+// WithTapeSize gives the tape n cells.
+func WithTapeSize(n int) Option {
+	return func(rt *Runtime) error {
+		return rt.SetTapeSize(n)
+	}
+}
+
+// WithTapeMode selects the named tape mode, as for SetTapeMode. The
+// limit is checked against the tape size so far, so it must come
+// after any WithTapeSize.
+func WithTapeMode(name string, maxCells int) Option {
+	return func(rt *Runtime) error {
+		return rt.SetTapeMode(name, maxCells)
+	}
+}
+
+// WithCellWidth selects 8, 16 or 32 bit cells.
+func WithCellWidth(bits int) Option {
+	return func(rt *Runtime) error {
+		return rt.SetCellWidth(bits)
+	}
+}
+
+// WithBigCells selects arbitrary precision cells, as for SetBigCells.
+func WithBigCells(lowByte bool) Option {
+	return func(rt *Runtime) error {
+		rt.SetBigCells(lowByte)
+		return nil
+	}
+}
+
+// WithStrictCells makes cell overflow an error.
+func WithStrictCells() Option {
+	return func(rt *Runtime) error {
+		rt.SetStrictCells(true)
+		return nil
+	}
+}
+
+// WithEOFMode selects the named EOF mode, as for SetEOFMode.
+func WithEOFMode(name string) Option {
+	return func(rt *Runtime) error {
+		return rt.SetEOFMode(name)
+	}
+}
+
+// WithTrace traces each command to w.
+func WithTrace(w io.Writer) Option {
+	return func(rt *Runtime) error {
+		if w == nil {
+			return errors.New("trace writer must not be nil")
+		}
+		rt.SetTrace(w)
+		return nil
+	}
+}
+
+// WithMaxSteps limits the program to n steps, or none if n is zero.
+func WithMaxSteps(n uint64) Option {
+	return func(rt *Runtime) error {
+		if n > math.MaxInt64 {
+			return fmt.Errorf("step limit %d is too large", n)
+		}
+		return rt.SetMaxSteps(int64(n))
+	}
+}
+
+// WithMaxMemory limits how many bytes a growing tape may take, as
+// for SetMaxMemory.
+func WithMaxMemory(n int64) Option {
+	return func(rt *Runtime) error {
+		return rt.SetMaxMemory(n)
+	}
+}
+
+// WithTranscript records the input and output of runs to w, as for
+// RecordTranscript.
+func WithTranscript(w io.Writer) Option {
+	return func(rt *Runtime) error {
+		if w == nil {
+			return errors.New("transcript must not be nil")
+		}
+		rt.RecordTranscript(w)
+		return nil
+	}
+}
+
+// WithReplay replays the transcript events, as for Replay.
+func WithReplay(events []TranscriptEvent) Option {
+	return func(rt *Runtime) error {
+		rt.Replay(events)
+		return nil
+	}
+}
+
+// WithMaxCallDepth limits how deeply procedure calls may nest.
+func WithMaxCallDepth(n int) Option {
+	return func(rt *Runtime) error {
+		return rt.SetMaxCallDepth(n)
+	}
+}
@@ -0,0 +1,76 @@
+package bf
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewRuntimeDefaults(t *testing.T) {
+	rt, err := NewRuntime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rt.output != os.Stdout || rt.size() != DefaultTapeSize || rt.mode != TapeFixed || rt.store == nil || rt.trace || rt.maxSteps != 0 {
+		t.Errorf("defaults are output %v, %d cells, mode %v, trace %v, step limit %d", rt.output, rt.size(), rt.mode, rt.trace, rt.maxSteps)
+	}
+	// hello world runs with nothing but an output
+	hello := corpus(t, "hello.bf")[0]
+	var out bytes.Buffer
+	rt, err = NewRuntime(WithOutput(&out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Run(parse(t, hello.src)); err != nil || out.String() != "Hello World!\n" {
+		t.Errorf("hello printed %q with error %v", out.String(), err)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	var out, trace bytes.Buffer
+	rt, err := NewRuntime(
+		WithInput(strings.NewReader("x")),
+		WithOutput(&out),
+		WithTapeSize(64),
+		WithTapeMode("grow", 128),
+		WithTrace(&trace),
+		WithMaxSteps(100),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rt.size() != 64 || rt.maxCells != 128 || rt.mode != TapeGrow || !rt.trace || rt.maxSteps != 100 {
+		t.Errorf("options gave %d cells, limit %d, mode %v, trace %v, step limit %d", rt.size(), rt.maxCells, rt.mode, rt.trace, rt.maxSteps)
+	}
+	if err := rt.Run(parse(t, ",.")); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "x" || trace.Len() == 0 {
+		t.Errorf("output %q, trace %q", out.String(), trace.String())
+	}
+}
+
+func TestOptionErrors(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"nil input": {WithInput(nil)},
+		"nil output": {WithOutput(nil)},
+		"nil trace": {WithTrace(nil)},
+		"tape size 0": {WithTapeSize(0)},
+		"unknown tape mode": {WithTapeMode("loop", 30000)},
+		"limit below tape size": {WithTapeMode("grow", 10)},
+		"tape grown past limit": {WithTapeMode("grow", 30000), WithTapeSize(40000)},
+	} {
+		if _, err := NewRuntime(opts...); err == nil {
+			t.Errorf("%s accepted", name)
+		}
+	}
+}
+
+func TestNewNilInput(t *testing.T) {
+	var out bytes.Buffer
+	rt := New(nil, &out)
+	if err := rt.Run(parse(t, ",.")); err != nil || out.String() != "\xff" {
+		t.Errorf("nil input read %q with error %v, want EOF", out.String(), err)
+	}
+}
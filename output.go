@@ -0,0 +1,119 @@
+package bf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Run executes prog, with the tree interpreter when tracing and as
+// bytecode otherwise, until it ends or halts. Buffered output is flushed before it returns,
+// even on error, so it always precedes anything the caller prints.
+func (rt *Runtime) Run(prog Runner) error {
+	var err error
+	if !rt.resume {
+		rt.resetProcs()
+	}
+	if rt.trace && rt.resume {
+		err = errors.New("cannot trace a resumed run")
+	} else if rt.trace || rt.interpret {
+		err = prog.Run(rt)
+	} else {
+		var code []Instruction
+		code, err = Compile(prog)
+		if err == nil {
+			err = rt.RunBytecode(code)
+		}
+	}
+	if errors.Is(err, ErrHalt) {
+		err = nil
+	}
+	return rt.finish(err)
+}
+
+// finish ends a run that stopped with err: it checks the whole of a
+// replayed transcript was used, ends numeric output, and flushes the
+// output and transcript, returning err or failing that the first
+// error from these.
+func (rt *Runtime) finish(err error) error {
+	if err == nil && rt.replay != nil {
+		err = rt.endReplay()
+	}
+	if nerr := rt.endNumbers(); err == nil && nerr != nil {
+		err = nerr
+	}
+	if ferr := rt.flush(); err == nil && ferr != nil {
+		err = fmt.Errorf("%v flushing output", ferr)
+	}
+	if rt.transcript != nil {
+		if terr := rt.transcript.flush(); err == nil {
+			err = terr
+		}
+	}
+	return err
+}
+
+// SetBuffered selects whether output is buffered, which it is by
+// default. Turning buffering off flushes anything already pending.
+func (rt *Runtime) SetBuffered(on bool) error {
+	if !on {
+		if err := rt.flush(); err != nil {
+			return err
+		}
+		rt.buf = nil
+	}
+	rt.unbuffered = !on
+	return nil
+}
+
+// writer returns where putchar should write.
+func (rt *Runtime) writer() io.Writer {
+	if rt.unbuffered {
+		return rt.output
+	}
+	if rt.buf == nil {
+		rt.buf = bufio.NewWriter(rt.output)
+	}
+	return rt.buf
+}
+
+// flush writes out any buffered output.
+func (rt *Runtime) flush() error {
+	if rt.buf == nil {
+		return nil
+	}
+	return rt.buf.Flush()
+}
+
+// SetNumericOutput makes . write the cell's value in decimal, with
+// sep between values and a newline after the last when the run ends,
+// instead of writing the cell as a byte. An empty sep goes back to
+// bytes.
+func (rt *Runtime) SetNumericOutput(sep string) {
+	rt.numSep = sep
+	rt.numbers = 0
+}
+
+// putNumber writes cell i in decimal for numeric output.
+func (rt *Runtime) putNumber(i int, at Pos) error {
+	s := rt.cellString(i)
+	if rt.numbers > 0 {
+		s = rt.numSep + s
+	}
+	rt.numbers++
+	if _, err := io.WriteString(rt.writer(), s); err != nil {
+		return fmt.Errorf("%v in putchar at %+v", err, at)
+	}
+	return nil
+}
+
+// endNumbers ends the line of a run's numeric output.
+func (rt *Runtime) endNumbers() error {
+	if rt.numbers == 0 {
+		return nil
+	}
+	rt.numbers = 0
+	_, err := io.WriteString(rt.writer(), "\n")
+	return err
+}
@@ -0,0 +1,120 @@
+package bf
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// countWriter counts the Write calls made to it.
+type countWriter struct {
+	strings.Builder
+	writes int
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Builder.Write(p)
+}
+
+// promptReader fails a read unless out already holds want.
+type promptReader struct {
+	out *countWriter
+	want string
+}
+
+func (r *promptReader) Read(p []byte) (int, error) {
+	if got := r.out.String(); got != r.want {
+		return 0, errors.New("read input before output " + got + " was flushed")
+	}
+	return 0, io.EOF
+}
+
+func TestFlushBeforeInput(t *testing.T) {
+	var out countWriter
+	rt, err := NewRuntime(WithOutput(&out), WithInput(&promptReader{&out, "?"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog := parse(t, strings.Repeat("+", 63) + ".,.")
+	if err := rt.Run(prog); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "?\xff" || out.writes != 2 {
+		t.Errorf("output %q in %d writes, want %q in 2", out.String(), out.writes, "?\xff")
+	}
+}
+
+func TestFlushOnError(t *testing.T) {
+	var out countWriter
+	rt, err := NewRuntime(WithOutput(&out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Run(parse(t, "+.+.<")); err == nil {
+		t.Fatal("moved left of the tape")
+	}
+	if out.String() != "\x01\x02" || out.writes != 1 {
+		t.Errorf("output %q in %d writes before the error", out.String(), out.writes)
+	}
+}
+
+func TestNumericOutput(t *testing.T) {
+	// prints 0, 255 and 42
+	src := ".-.>" + strings.Repeat("+", 42) + "."
+	tests := []struct {
+		sep string
+		opts []Option
+		want string
+	}{
+		{"", nil, "\x00\xff*"},
+		{" ", nil, "0 255 42\n"},
+		{"\n", nil, "0\n255\n42\n"},
+		{" ", []Option{WithUnbufferedOutput()}, "0 255 42\n"},
+		{" ", []Option{WithCellWidth(16)}, "0 65535 42\n"},
+		{" ", []Option{WithBigCells(false)}, "0 -1 42\n"},
+	}
+	for _, tt := range tests {
+		out, _, err := runProgram(t, src, "", AllPasses, append([]Option{WithNumericOutput(tt.sep)}, tt.opts...)...)
+		if err != nil || out != tt.want {
+			t.Errorf("sep %q, %d options: output %q, error %v, want %q", tt.sep, len(tt.opts), out, err, tt.want)
+		}
+	}
+	// the line is ended when the run fails too
+	out, _, err := runProgram(t, "+.+.<", "", 0, WithNumericOutput(" "))
+	if err == nil || out != "1 2\n" {
+		t.Errorf("failing run: output %q, error %v", out, err)
+	}
+	if out, _, _ := runProgram(t, "+", "", 0, WithNumericOutput(" ")); out != "" {
+		t.Errorf("no output: %q", out)
+	}
+}
+
+func BenchmarkOutput(b *testing.B) {
+	// print 6400 bytes, about what mandelbrot prints
+	prog := parse(b, "++++++++[>++++++++[>" + strings.Repeat("+", 100) + "[>.<-]<-]<-]")
+	for _, bb := range []struct {
+		name string
+		opts []Option
+	}{
+		{"buffered", nil},
+		{"unbuffered", []Option{WithUnbufferedOutput()}},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			var writes int
+			for i := 0; i < b.N; i++ {
+				var out countWriter
+				rt, err := NewRuntime(append([]Option{WithOutput(&out)}, bb.opts...)...)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := rt.Run(prog); err != nil {
+					b.Fatal(err)
+				}
+				writes += out.writes
+			}
+			b.ReportMetric(float64(writes) / float64(b.N), "writes/op")
+		})
+	}
+}
@@ -0,0 +1,486 @@
+package bf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ParseError is a syntax or read error at a position in the source.
+// File is set for a program parsed from several files. Rune is the
+// command at fault, or zero, and Err the underlying error for a
+// failed read.
+type ParseError struct {
+	File string
+	Offset int
+	Line int
+	Col int
+	Rune rune
+	Msg string
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	at := fmt.Sprintf("%d:%d", e.Line, e.Col)
+	if e.File != "" {
+		at = e.File + ":" + at
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", at, e.Msg, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", at, e.Msg)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// msgUnclosed and msgUnclosedProc are the Msgs of the errors for a [
+// without a ] and a ( without a ).
+const (
+	msgUnclosed = "unclosed open bracket"
+	msgUnclosedProc = "unclosed procedure"
+)
+
+// Incomplete reports whether err from Parse is only for loops or
+// procedures left open at the end, so the program might be finished by more input.
+func Incomplete(err error) bool {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, e := range errs {
+		pe, ok := e.(*ParseError)
+		if !ok || (pe.Msg != msgUnclosed && pe.Msg != msgUnclosedProc) {
+			return false
+		}
+	}
+	return err != nil
+}
+
+// maxParseErrors is how many errors Parse reports before giving up.
+const maxParseErrors = 20
+
+// errorAt records a ParseError at pos, stopping the parse once there
+// are maxParseErrors of them.
+func (p *Parser) errorAt(pos Pos, ch rune, msg string, err error) {
+	p.errs = append(p.errs, &ParseError{pos.file, pos.pos, pos.lno, pos.linepos, ch, msg, err})
+	if len(p.errs) >= maxParseErrors {
+		p.stop = true
+	}
+}
+
+// Parser reads Brainf*ck source into a tree of Runners. Characters
+// other than the commands of its Dialect are comments. Each call to Parse
+// starts afresh, so a Parser can be reused for several programs.
+type Parser struct {
+	input io.ByteReader // nil when parsing src
+	reader io.Reader // that input reads from
+	src []byte
+	files []fileStart // still to be reached in src
+	pos Pos // of the last byte read
+	tok Pos // of the last command returned by next
+	ahead []byte // read from input by peek but not yet by readByte
+	aheadErr error // that ended a peek
+	newline bool // the last byte read was a newline
+	errs []*ParseError
+	stop bool // after a read failure or too many errors
+	// Dialect selects the commands the parser accepts.
+	Dialect Dialect
+	// Map, if set, takes the place of Dialect, spelling the eight
+	// commands of plain Brainf*ck as its tokens.
+	Map *TokenMap
+	// Debug makes the parser accept # as a Dump command.
+	Debug bool
+	// KeepComments makes the parser keep the text between commands
+	// for Comments.
+	KeepComments bool
+	// Counts makes the parser accept a decimal count just before
+	// + - < or >, so 65+ is 65 of +.
+	Counts bool
+	counting bool // reading the digits of a count
+	count int
+	countPos Pos // of its first digit
+	repeat int // how many of the last command returned by next
+	// Includes makes the parser replace each line that starts with
+	// %include "file" by the contents of file, found relative to the
+	// including one.
+	Includes bool
+	// Bang makes the parser end the program at the first ! that is
+	// not a command and not inside a loop, leaving what follows for
+	// Rest, so a comment loop such as [Hello!] keeps its !. It has no
+	// effect on Ook!, whose words end in !.
+	Bang bool
+	banged bool // the parse ended at a !
+	nesting int // how many loops and procedures the parse is inside
+	comments []Comment
+	pending Comment
+	text []byte // of pending
+}
+
+// ParseFile parses the program in the file fn. With Includes set, its
+// positions record their files, as for ParseFiles.
+func (p *Parser) ParseFile(fn string) (Runner, error) {
+	if p.Includes {
+		return p.ParseFiles(fn)
+	}
+	src, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	return p.ParseBytes(src)
+}
+
+// Parse parses the program read from input. Each error in the
+// program, up to maxParseErrors, and any failure reading it is
+// reported as a *ParseError, joined with errors.Join in source order.
+func (p *Parser) Parse(input io.Reader) (Runner, error) {
+	if p.Includes {
+		src, err := io.ReadAll(input)
+		if err != nil {
+			return nil, &ParseError{Msg: "read failed", Err: err}
+		}
+		return p.ParseBytes(src)
+	}
+	p.files = nil
+	// bufio also copes with reads that return nothing, or data
+	// along with io.EOF.
+	if br, ok := input.(io.ByteReader); ok {
+		p.input = br
+		p.reader = input
+	} else {
+		br := bufio.NewReader(input)
+		p.input = br
+		p.reader = br
+	}
+	p.src = nil
+	return p.parse()
+}
+
+// ParseBytes is like Parse but takes the program from src, which
+// avoids reading it a byte at a time.
+func (p *Parser) ParseBytes(src []byte) (Runner, error) {
+	p.files = nil
+	if p.Includes {
+		inc := &includer{}
+		if err := inc.expand("", src); err != nil {
+			return nil, err
+		}
+		src, p.files = inc.src, inc.files
+	}
+	p.input = nil
+	p.src = src
+	return p.parse()
+}
+
+// fileStart is where a file, or the rest of one after an %include,
+// begins in the source of a program parsed from several. line is the
+// line it begins on.
+type fileStart struct {
+	offset int
+	name string
+	line int
+}
+
+// ParseFiles parses the files fns, in order, as a single program,
+// so loops may span them. Each position records its file, with lines
+// and columns counted within it.
+func (p *Parser) ParseFiles(fns ...string) (Runner, error) {
+	inc := &includer{}
+	for _, fn := range fns {
+		b, err := os.ReadFile(fn)
+		if err != nil {
+			return nil, err
+		}
+		inc.files = append(inc.files, fileStart{len(inc.src), fn, 1})
+		if !p.Includes {
+			inc.src = append(inc.src, b...)
+		} else if err := inc.expand(fn, b); err != nil {
+			return nil, err
+		}
+	}
+	p.input = nil
+	p.src = inc.src
+	p.files = inc.files
+	return p.parse()
+}
+
+func (p *Parser) parse() (Runner, error) {
+	p.pos = Pos{lno: 1}
+	p.tok = p.pos
+	p.ahead = p.ahead[:0]
+	p.aheadErr = nil
+	p.banged = false
+	p.nesting = 0
+	p.counting = false
+	p.repeat = 1
+	p.newline = false
+	p.errs = nil
+	p.stop = false
+	p.comments = nil
+	p.text = p.text[:0]
+
+	block := &Block{p.pos, []Runner{}}
+	p.parseBlock(block, 0)
+	if len(p.errs) > 0 {
+		sort.SliceStable(p.errs, func(i, j int) bool {
+			return p.errs[i].Offset < p.errs[j].Offset
+		})
+		errs := make([]error, len(p.errs))
+		for i, e := range p.errs {
+			errs[i] = e
+		}
+		return nil, errors.Join(errs...)
+	}
+	return block, nil
+}
+
+// parseBlock parses commands into block until close, the ] or ) that
+// ends it, or the end of input at the top level where close is zero,
+// and reports whether it found close.
+func (p *Parser) parseBlock(block *Block, close rune) bool {
+	for !p.stop {
+		ch := p.next()
+		if ch == 0 {
+			break
+		}
+
+		switch ch {
+		case '<':
+			block.Add(&Move{p.tok, -p.repeat})
+		case '>':
+			block.Add(&Move{p.tok, p.repeat})
+		case '[':
+			open := p.tok
+			inner := &Block{open, []Runner{}}
+			p.nesting++
+			if !p.parseBlock(inner, ']') && !p.stop {
+				p.errorAt(open, ch, msgUnclosed, nil)
+			}
+			p.nesting--
+			block.Add(&Loop{open, inner, p.tok})
+		case ']':
+			if close != ch {
+				p.errorAt(p.tok, ch, "unexpected close bracket", nil)
+				continue
+			}
+			return true
+		case '(':
+			open := p.tok
+			inner := &Block{open, []Runner{}}
+			p.nesting++
+			if !p.parseBlock(inner, ')') && !p.stop {
+				p.errorAt(open, ch, msgUnclosedProc, nil)
+			}
+			p.nesting--
+			block.Add(&DefineProc{open, inner, p.tok})
+		case ')':
+			if close != ch {
+				p.errorAt(p.tok, ch, "unexpected close parenthesis", nil)
+				continue
+			}
+			return true
+		case ':':
+			block.Add(&CallProc{p.tok})
+		case 'Y':
+			block.Add(&Fork{p.tok})
+		case '@':
+			block.Add(&Halt{p.tok})
+		case '$':
+			block.Add(&Store{p.tok})
+		case '!':
+			block.Add(&Retrieve{p.tok})
+		case '+':
+			block.Add(&Update{p.tok, p.repeat, 0})
+		case '-':
+			block.Add(&Update{p.tok, -p.repeat, 0})
+		case '.':
+			block.Add(&Putchar{p.tok, 0})
+		case ',':
+			block.Add(&Getchar{p.tok, 0})
+		case '#':
+			block.Add(&Dump{p.tok})
+		default:
+			panic("cant happen")
+		}
+	}
+	return false
+}
+
+// next returns the next command, or zero for EOF, leaving p.tok at
+// its position. io errors are recorded internally.
+func (p *Parser) next() rune {
+	if p.banged {
+		return 0
+	}
+	if p.Map != nil {
+		return p.nextMapped()
+	}
+	if p.Dialect == DialectOok {
+		return p.nextOok()
+	}
+	for {
+		b, ok := p.advance()
+		if !ok {
+			if p.counting {
+				p.endCount(0)
+			}
+			if p.KeepComments {
+				p.endComment(Pos{})
+			}
+			return 0
+		}
+
+		ch := rune(b)
+		//fmt.Printf("parser next %c at %+v\n", ch, p.pos)
+		if strings.Contains(p.Dialect.commands(), string(ch)) || (p.Debug && ch == '#') {
+			if p.KeepComments {
+				p.endComment(p.pos)
+			}
+			p.tok = p.pos
+			p.repeat = 1
+			if p.counting && !p.endCount(ch) {
+				continue
+			}
+			return ch
+		}
+		if p.Counts && '0' <= ch && ch <= '9' {
+			p.addDigit(ch)
+		} else if p.counting {
+			// not a count after all
+			p.counting = false
+		}
+		if p.Bang && ch == '!' && p.nesting == 0 {
+			p.banged = true
+			if p.KeepComments {
+				p.endComment(Pos{})
+			}
+			return 0
+		}
+		if p.KeepComments {
+			p.keepComment(b)
+		}
+	}
+}
+
+// Rest returns the input that follows the ! that ended the last
+// Parse when Bang is set, which is the program's input in the
+// program ! input convention, or nil if there was no !. Nothing after
+// the ! has been read from the reader given to Parse, apart from what
+// a bufio.Reader wrapped around it holds, which Rest includes.
+func (p *Parser) Rest() io.Reader {
+	if !p.banged {
+		return nil
+	}
+	if p.input == nil {
+		return bytes.NewReader(p.src[p.pos.pos:])
+	}
+	return io.MultiReader(bytes.NewReader(append([]byte(nil), p.ahead...)), p.reader)
+}
+
+// advance reads the next input byte, leaving p.pos at its position.
+// It returns false at EOF or after a failed read, which it records.
+func (p *Parser) advance() (byte, bool) {
+	b, err := p.readByte()
+	if err != nil {
+		//fmt.Printf("parser %v at %+v\n", err, p.pos)
+		if err != io.EOF {
+			p.errorAt(p.pos, 0, "read failed", err)
+			p.stop = true
+		}
+		return 0, false
+	}
+	for len(p.files) > 0 && p.files[0].offset == p.pos.pos {
+		p.pos.file = p.files[0].name
+		p.pos.lno = p.files[0].line
+		p.pos.linepos = 0
+		p.newline = false
+		p.files = p.files[1:]
+	}
+	if p.newline {
+		p.pos.lno ++
+		p.pos.linepos = 0
+	}
+	p.pos.pos ++
+	p.pos.linepos ++
+	p.newline = b == '\n'
+	return b, true
+}
+
+// readByte returns the byte after p.pos, from src or input.
+func (p *Parser) readByte() (byte, error) {
+	if p.input == nil {
+		if p.pos.pos >= len(p.src) {
+			return 0, io.EOF
+		}
+		return p.src[p.pos.pos], nil
+	}
+	if len(p.ahead) > 0 {
+		b := p.ahead[0]
+		p.ahead = p.ahead[1:]
+		return b, nil
+	}
+	if p.aheadErr != nil {
+		return 0, p.aheadErr
+	}
+	return p.input.ReadByte()
+}
+
+// peek returns up to n of the bytes after p.pos without reading them,
+// fewer only at the end of input or a failed read.
+func (p *Parser) peek(n int) []byte {
+	if p.input == nil {
+		end := p.pos.pos + n
+		if end > len(p.src) {
+			end = len(p.src)
+		}
+		return p.src[p.pos.pos:end]
+	}
+	for len(p.ahead) < n && p.aheadErr == nil {
+		b, err := p.input.ReadByte()
+		if err != nil {
+			p.aheadErr = err
+			break
+		}
+		p.ahead = append(p.ahead, b)
+	}
+	if len(p.ahead) < n {
+		return p.ahead
+	}
+	return p.ahead[:n]
+}
+
+// Parse parses the program read from input with a default Parser.
+func Parse(input io.Reader) (Runner, error) {
+	p := &Parser{}
+	return p.Parse(input)
+}
+
+// ParseFiles parses the files fns as a single program with a
+// default Parser.
+func ParseFiles(fns ...string) (Runner, error) {
+	p := &Parser{}
+	return p.ParseFiles(fns...)
+}
+
+// ParseString parses the program src with a default Parser.
+func ParseString(src string) (Runner, error) {
+	return ParseBytes([]byte(src))
+}
+
+// ParseBytes parses the program src with a default Parser.
+func ParseBytes(src []byte) (Runner, error) {
+	p := &Parser{}
+	return p.ParseBytes(src)
+}
+
+// ParseFile parses the program in the file fn with a default Parser.
+func ParseFile(fn string) (Runner, error) {
+	p := &Parser{}
+	return p.ParseFile(fn)
+}
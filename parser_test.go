@@ -0,0 +1,252 @@
+package bf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestParseErrorAs(t *testing.T) {
+	_, err := ParseString("+\n+]")
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("error %v is not a ParseError", err)
+	}
+	// offsets count from 1, like lines and columns
+	want := ParseError{Offset: 4, Line: 2, Col: 2, Rune: ']', Msg: "unexpected close bracket"}
+	if *pe != want {
+		t.Errorf("got %+v, want %+v", *pe, want)
+	}
+	if got := err.Error(); got != "2:2: unexpected close bracket" {
+		t.Errorf("message %q", got)
+	}
+}
+
+func TestParseReadError(t *testing.T) {
+	boom := errors.New("boom")
+	_, err := Parse(io.MultiReader(strings.NewReader("+\n+"), iotest.ErrReader(boom)))
+	var pe *ParseError
+	if !errors.As(err, &pe) || !errors.Is(err, boom) {
+		t.Fatalf("error %v does not wrap the read error in a ParseError", err)
+	}
+	if got := err.Error(); got != "2:1: read failed: boom" {
+		t.Errorf("message %q", got)
+	}
+}
+
+func TestPosString(t *testing.T) {
+	prog := parse(t, "+\n\n  >")
+	if got := fmt.Sprint(prog.(*Block).Commands()[1].(*Move).Pos()); got != "3:3" {
+		t.Errorf("Pos prints as %q", got)
+	}
+}
+
+func TestUnclosedBrackets(t *testing.T) {
+	tests := []struct {
+		src string
+		err string
+	}{
+		{"+[+", "1:2: unclosed open bracket"},
+		{"[\n+[>[-]\n", "1:1: unclosed open bracket\n2:2: unclosed open bracket"},
+		{"[", "1:1: unclosed open bracket"},
+		{"+[>+<-]", ""},
+	}
+	for _, tt := range tests {
+		prog, err := ParseString(tt.src)
+		if tt.err == "" {
+			if err != nil || prog == nil {
+				t.Errorf("%q: %v", tt.src, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.err || prog != nil {
+			t.Errorf("%q: error %v and program %v, want %q", tt.src, err, prog, tt.err)
+		}
+		if !Incomplete(err) {
+			t.Errorf("%q: error is not Incomplete", tt.src)
+		}
+	}
+}
+
+func TestAllBracketErrors(t *testing.T) {
+	_, err := ParseString("+]\n[-]]\n>[<\n]]\n[")
+	var got []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var pe *ParseError
+		if !errors.As(e, &pe) {
+			t.Fatalf("%v is not a ParseError", e)
+		}
+		got = append(got, fmt.Sprintf("%d:%d %s", pe.Line, pe.Col, pe.Msg))
+	}
+	want := []string{
+		"1:2 unexpected close bracket",
+		"2:4 unexpected close bracket",
+		"4:2 unexpected close bracket",
+		"5:1 unclosed open bracket",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if _, err := ParseString("[[\n]]]]"); err == nil || err.Error() != "2:3: unexpected close bracket\n2:4: unexpected close bracket" {
+		t.Errorf("error %v", err)
+	}
+}
+
+func TestParseErrorCap(t *testing.T) {
+	_, err := ParseString(strings.Repeat("]", 50))
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != maxParseErrors {
+		t.Errorf("%d errors reported, want %d", n, maxParseErrors)
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	src := "+ comment\n  [->\n>+ <<\n]  .\n"
+	want := "" +
+		"+ at 1:1\n" +
+		"[ at 2:3\n" +
+		"  - at 2:4\n" +
+		"  > at 2:5\n" +
+		"  > at 3:1\n" +
+		"  + at 3:2\n" +
+		"  < at 3:4\n" +
+		"  < at 3:5\n" +
+		"] at 4:1\n" +
+		". at 4:4\n"
+	if got := treeString(t, parse(t, src)); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	loop := parse(t, src).(*Block).Commands()[1].(*Loop)
+	if got := fmt.Sprintf("%v %v", loop.Pos(), loop.Body().Pos()); got != "2:3 2:3" {
+		t.Errorf("loop and body at %s, want both at the [", got)
+	}
+}
+
+func TestParseBackToBack(t *testing.T) {
+	if _, err := ParseString("+]"); err == nil {
+		t.Fatal("stray ] accepted")
+	}
+	prog, err := ParseString("\n>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := treeString(t, prog); got != "> at 2:1\n" {
+		t.Errorf("second program parsed as %q", got)
+	}
+	prog, err = Parse(strings.NewReader("-"))
+	if err != nil || treeString(t, prog) != "- at 1:1\n" {
+		t.Errorf("third program parsed as %v, %v", prog, err)
+	}
+
+	// a Parser is reset by each parse too
+	p := &Parser{}
+	if _, err := p.ParseBytes([]byte("[")); err == nil {
+		t.Fatal("unclosed [ accepted")
+	}
+	prog, err = p.ParseBytes([]byte("+"))
+	if err != nil || treeString(t, prog) != "+ at 1:1\n" {
+		t.Errorf("reused parser gave %v, %v", prog, err)
+	}
+}
+
+// largeProgram returns a synthetic program of about n bytes, with
+// comments and newlines between balanced loops.
+func largeProgram(n int) []byte {
+	chunk := "++[>+++<-]> inc\n[-<+>]<. out\n"
+	return []byte(strings.Repeat(chunk, n / len(chunk)))
+}
+
+func TestParseBytesMatchesParse(t *testing.T) {
+	srcs := []string{"", "+", "+[\n-]>.,", "x]y", "[[\n", string(largeProgram(1000))}
+	for _, p := range corpus(t, "*.bf") {
+		srcs = append(srcs, p.src)
+	}
+	for _, src := range srcs {
+		fromBytes, berr := ParseBytes([]byte(src))
+		fromReader, rerr := Parse(strings.NewReader(src))
+		if fmt.Sprint(berr) != fmt.Sprint(rerr) {
+			t.Errorf("%.20q: error %v from bytes, %v from a reader", src, berr, rerr)
+			continue
+		}
+		if berr != nil {
+			continue
+		}
+		if b, r := treeString(t, fromBytes), treeString(t, fromReader); b != r {
+			t.Errorf("%.20q: trees differ:\n%s\nfrom a reader:\n%s", src, b, r)
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	src := largeProgram(1 << 20)
+	b.Run("bytes", func(b *testing.B) {
+		b.SetBytes(int64(len(src)))
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseBytes(src); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("reader", func(b *testing.B) {
+		b.SetBytes(int64(len(src)))
+		for i := 0; i < b.N; i++ {
+			if _, err := Parse(bytes.NewReader(src)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestParseOneByteReader(t *testing.T) {
+	srcs := []string{"+[\n-]>.,", "x]y\n[", string(largeProgram(1000))}
+	for _, p := range corpus(t, "*.bf") {
+		srcs = append(srcs, p.src)
+	}
+	for _, src := range srcs {
+		want, werr := ParseString(src)
+		got, gerr := Parse(iotest.OneByteReader(strings.NewReader(src)))
+		if fmt.Sprint(werr) != fmt.Sprint(gerr) {
+			t.Errorf("%.20q: error %v a byte at a time, want %v", src, gerr, werr)
+			continue
+		}
+		if werr == nil && treeString(t, got) != treeString(t, want) {
+			t.Errorf("%.20q: trees differ read a byte at a time", src)
+		}
+	}
+}
+
+func BenchmarkParseFile(b *testing.B) {
+	fn := filepath.Join(b.TempDir(), "large.bf")
+	src := largeProgram(1 << 20)
+	if err := os.WriteFile(fn, src, 0644); err != nil {
+		b.Fatal(err)
+	}
+	b.Run("file", func(b *testing.B) {
+		b.SetBytes(int64(len(src)))
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseFile(fn); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	// an unbuffered file read one byte per call, as before buffering
+	b.Run("unbuffered", func(b *testing.B) {
+		b.SetBytes(int64(len(src)))
+		for i := 0; i < b.N; i++ {
+			f, err := os.Open(fn)
+			if err != nil {
+				b.Fatal(err)
+			}
+			_, err = Parse(iotest.OneByteReader(f))
+			f.Close()
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package bf
+
+import (
+	"fmt"
+)
+
+// DefaultMaxCallDepth is how deeply procedure calls may nest unless
+// SetMaxCallDepth says otherwise.
+const DefaultMaxCallDepth = 10000
+
+// DefineProc is the pbrain ( ... ), which makes its block the
+// procedure numbered by the current cell, replacing any procedure
+// defined for that number before. pos is the position of its ( and
+// end of its ).
+type DefineProc struct {
+	pos Pos
+	block *Block
+	end Pos
+}
+
+func (r *DefineProc) Run(rt *Runtime) error {
+	if err := rt.step(1, r.pos); err != nil {
+		return err
+	}
+	if rt.trace {
+		rt.traceRun(r, r.pos)
+	}
+	return rt.define(procedure{body: r.block, end: r.end}, r.pos)
+}
+
+// CallProc is the pbrain :, which runs the procedure numbered by the
+// current cell.
+type CallProc struct {
+	pos Pos
+}
+
+func (r *CallProc) Run(rt *Runtime) error {
+	if err := rt.step(1, r.pos); err != nil {
+		return err
+	}
+	if rt.trace {
+		rt.traceRun(r, r.pos)
+	}
+	p, err := rt.call(-1, r.pos)
+	if err != nil {
+		return err
+	}
+	if err := p.body.Run(rt); err != nil {
+		return err
+	}
+	_, err = rt.ret(p.end)
+	return err
+}
+
+// procedure is a defined pbrain procedure: its body for the tree
+// interpreter, or the pc after its OpDefine for bytecode, and the
+// position of its ).
+type procedure struct {
+	body *Block
+	pc int
+	end Pos
+}
+
+// SetMaxCallDepth limits how deeply procedure calls may nest,
+// including recursive ones.
+func (rt *Runtime) SetMaxCallDepth(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("call depth %d must be positive", n)
+	}
+	rt.maxCallDepth = n
+	return nil
+}
+
+// resetProcs forgets the procedures and calls of an earlier run.
+func (rt *Runtime) resetProcs() {
+	rt.procs = nil
+	rt.calls = rt.calls[:0]
+}
+
+// procNumber returns the current cell as a procedure number.
+func (rt *Runtime) procNumber(at Pos) (int64, error) {
+	if rt.bigs != nil {
+		v := rt.big(rt.pos)
+		if !v.IsInt64() {
+			return 0, fmt.Errorf("procedure number %s is out of range at %+v", v, at)
+		}
+		return v.Int64(), nil
+	}
+	return int64(rt.get(rt.pos)), nil
+}
+
+// define records p as the procedure numbered by the current cell.
+func (rt *Runtime) define(p procedure, at Pos) error {
+	n, err := rt.procNumber(at)
+	if err != nil {
+		return err
+	}
+	if rt.procs == nil {
+		rt.procs = map[int64]procedure{}
+	}
+	rt.procs[n] = p
+	return nil
+}
+
+// call looks up the procedure numbered by the current cell and
+// pushes ret, the pc to return to, onto the call stack.
+func (rt *Runtime) call(ret int, at Pos) (procedure, error) {
+	n, err := rt.procNumber(at)
+	if err != nil {
+		return procedure{}, err
+	}
+	p, ok := rt.procs[n]
+	if !ok {
+		return procedure{}, fmt.Errorf("call of undefined procedure %d at %+v", n, at)
+	}
+	max := rt.maxCallDepth
+	if max == 0 {
+		max = DefaultMaxCallDepth
+	}
+	if len(rt.calls) >= max {
+		return procedure{}, fmt.Errorf("procedure calls nested more than %d deep at %+v", max, at)
+	}
+	rt.calls = append(rt.calls, ret)
+	return p, nil
+}
+
+// ret charges the step for a procedure's ) and pops the call stack,
+// returning the pc the call pushed.
+func (rt *Runtime) ret(at Pos) (int, error) {
+	if err := rt.step(1, at); err != nil {
+		return 0, err
+	}
+	if len(rt.calls) == 0 {
+		return 0, fmt.Errorf("return without a call at %+v", at)
+	}
+	pc := rt.calls[len(rt.calls) - 1]
+	rt.calls = rt.calls[:len(rt.calls) - 1]
+	return pc, nil
+}
@@ -0,0 +1,85 @@
+package bf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runPbrain parses src as pbrain and runs it with the tree interpreter
+// or as bytecode, returning the output and the error it ended with.
+func runPbrain(t *testing.T, src string, interpret bool, depth int) (string, error) {
+	t.Helper()
+	prog, err := (&Parser{Dialect: DialectPbrain}).ParseBytes([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	rt := New(nil, &out)
+	rt.interpret = interpret
+	if depth > 0 {
+		if err := rt.SetMaxCallDepth(depth); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = rt.Run(prog)
+	return out.String(), err
+}
+
+func TestPbrainSamples(t *testing.T) {
+	fns, err := filepath.Glob("testdata/pbrain/*.pb")
+	if err != nil || len(fns) == 0 {
+		t.Fatalf("no pbrain samples: %v", err)
+	}
+	for _, fn := range fns {
+		src, err := os.ReadFile(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(strings.TrimSuffix(fn, ".pb") + ".out")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, interpret := range []bool{true, false} {
+			if out, err := runPbrain(t, string(src), interpret, 0); err != nil || out != string(want) {
+				t.Errorf("%s interpret %v: output %q, error %v, want %q", fn, interpret, out, err, want)
+			}
+		}
+	}
+}
+
+func TestPbrainErrors(t *testing.T) {
+	src, err := os.ReadFile("testdata/pbrain/recurse.pb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		src string
+		depth int
+		out string
+		err string
+	}{
+		{"+:", 0, "", "call of undefined procedure 1 at 1:2"},
+		// recurse nests a call for each star it prints; the loops the
+		// error is in differ between the interpreters, so aren't checked
+		{string(src), 3, "***", "procedure calls nested more than 3 deep at 5:9, in loop at 5:3"},
+		// a later ( replaces the procedure
+		{"+(+)(++):.", 0, "\x03", ""},
+	}
+	for _, tt := range tests {
+		for _, interpret := range []bool{true, false} {
+			out, err := runPbrain(t, tt.src, interpret, tt.depth)
+			if out != tt.out || tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.err)) {
+				t.Errorf("%q interpret %v: output %q, error %v, want %q, %q", tt.src, interpret, out, err, tt.out, tt.err)
+			}
+		}
+	}
+}
+
+func TestPbrainCommandsAreCommentsInBF(t *testing.T) {
+	if got := treeString(t, parse(t, "+(-):")); got != "+ at 1:1\n- at 1:3\n" {
+		t.Errorf("got:\n%s", got)
+	}
+}
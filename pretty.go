@@ -0,0 +1,182 @@
+package bf
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// formatWidth is how long Format lets a line of commands grow before
+// starting another, though it never splits a run of one command.
+const formatWidth = 64
+
+// Format rewrites the program src to w with each loop body indented
+// on the lines between its [ and ], and the other commands in lines
+// of up to formatWidth. Comments are kept, each line of them on a
+// line of its own, unless strip is set. The program must parse, and
+// formatting doesn't change what it does, though # stays with the
+// comments and is lost with them.
+func Format(w io.Writer, src []byte, strip bool) error {
+	if _, err := ParseBytes(src); err != nil {
+		return err
+	}
+	f := &formatter{w: bufio.NewWriter(w), strip: strip}
+	for _, ch := range src {
+		switch ch {
+		case '[':
+			f.flush()
+			f.writeLine("[")
+			f.depth++
+		case ']':
+			f.flush()
+			f.depth--
+			f.writeLine("]")
+		case '<', '>', '+', '-', '.', ',':
+			f.command(ch)
+		default:
+			f.comment.WriteByte(ch)
+		}
+	}
+	f.flush()
+	return f.w.Flush()
+}
+
+type formatter struct {
+	w *bufio.Writer
+	strip bool
+	depth int
+	line []byte // commands waiting to be written
+	comment strings.Builder // comment text since the last command
+}
+
+// command adds ch to the current line, first writing out any comment
+// before it, and the line if it is full and ch doesn't continue a run.
+func (f *formatter) command(ch byte) {
+	if f.comment.Len() > 0 {
+		f.flushComment()
+	}
+	if n := len(f.line); n >= formatWidth && f.line[n-1] != ch {
+		f.flush()
+	}
+	f.line = append(f.line, ch)
+}
+
+// flush writes out the pending commands and then any comment.
+func (f *formatter) flush() {
+	f.flushComment()
+	if len(f.line) > 0 {
+		f.writeLine(string(f.line))
+		f.line = f.line[:0]
+	}
+}
+
+// flushComment writes the lines of pending comment text, trimmed, on
+// lines of their own after the commands before them.
+func (f *formatter) flushComment() {
+	text := f.comment.String()
+	f.comment.Reset()
+	if f.strip || strings.TrimSpace(text) == "" {
+		return
+	}
+	if len(f.line) > 0 {
+		f.writeLine(string(f.line))
+		f.line = f.line[:0]
+	}
+	for _, l := range strings.Split(text, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			f.writeLine(l)
+		}
+	}
+}
+
+func (f *formatter) writeLine(s string) {
+	for i := 0; i < f.depth; i++ {
+		f.w.WriteString("  ")
+	}
+	f.w.WriteString(s)
+	f.w.WriteByte('\n')
+}
+
+// MinifyRule selects one of the ways Minify shrinks a program beyond
+// dropping its comments.
+type MinifyRule uint
+
+const (
+	// MinifyCancel deletes adjacent +- -+ <> and >< pairs, which
+	// assumes cells wrap and the pointer stays on the tape.
+	MinifyCancel MinifyRule = 1 << iota
+	// MinifyDeadLoops deletes loops that start at the beginning of
+	// the program or just after another loop, when the cell is zero.
+	MinifyDeadLoops
+
+	AllMinifyRules = MinifyCancel | MinifyDeadLoops
+)
+
+// Minify returns the commands of the program src with comments and
+// whitespace removed, further shrunk by the selected rules until none
+// of them applies. The program must parse.
+func Minify(src []byte, rules MinifyRule) ([]byte, error) {
+	if _, err := ParseBytes(src); err != nil {
+		return nil, err
+	}
+	var out []byte
+	for _, ch := range src {
+		if strings.IndexByte("<>+-.,[]", ch) >= 0 {
+			out = append(out, ch)
+		}
+	}
+	for {
+		n := len(out)
+		if rules & MinifyCancel != 0 {
+			out = cancelPairs(out)
+		}
+		if rules & MinifyDeadLoops != 0 {
+			out = dropDeadLoops(out)
+		}
+		if len(out) == n {
+			return out, nil
+		}
+	}
+}
+
+var inverse = map[byte]byte{'+': '-', '-': '+', '<': '>', '>': '<'}
+
+// cancelPairs removes commands that undo the one before them.
+func cancelPairs(cmds []byte) []byte {
+	out := make([]byte, 0, len(cmds))
+	for _, ch := range cmds {
+		if n := len(out); n > 0 && inverse[ch] != 0 && out[n-1] == inverse[ch] {
+			out = out[:n-1]
+			continue
+		}
+		out = append(out, ch)
+	}
+	return out
+}
+
+// dropDeadLoops removes loops where the current cell must be zero.
+func dropDeadLoops(cmds []byte) []byte {
+	out := make([]byte, 0, len(cmds))
+	zero := true
+	for i := 0; i < len(cmds); i++ {
+		switch ch := cmds[i]; {
+		case ch == '[' && zero:
+			for depth := 0; ; i++ {
+				if cmds[i] == '[' {
+					depth++
+				} else if cmds[i] == ']' {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+		case ch == ']':
+			out = append(out, ch)
+			zero = true
+		default:
+			out = append(out, ch)
+			zero = false
+		}
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package bf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// formatted formats src or fails the test.
+func formatted(t *testing.T, src []byte, strip bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Format(&buf, src, strip); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFormatGolden(t *testing.T) {
+	for _, name := range []string{"hello", "nested", "rot13"} {
+		src, err := os.ReadFile(filepath.Join("testdata", name + ".bf"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for ext, strip := range map[string]bool{".fmt": false, ".strip": true} {
+			want, err := os.ReadFile(filepath.Join("testdata", "fmt", name + ext))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := formatted(t, src, strip)
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s%s: got:\n%s\nwant:\n%s", name, ext, got, want)
+			}
+			// formatting keeps the program the same, apart from the
+			// positions, and formatting again changes nothing
+			if source(parse(t, string(got))) != source(parse(t, string(src))) {
+				t.Errorf("%s%s: formatting changed the program", name, ext)
+			}
+			if again := formatted(t, got, strip); !bytes.Equal(again, got) {
+				t.Errorf("%s%s: formatting again gave:\n%s", name, ext, again)
+			}
+		}
+	}
+}
+
+func TestMinifyRules(t *testing.T) {
+	src := "[a loop that never runs ,.]+-+ add one\n><.[-][.]"
+	tests := []struct {
+		rules MinifyRule
+		want string
+	}{
+		{0, "[,.]+-+><.[-][.]"},
+		{MinifyCancel, "[,.]+.[-][.]"},
+		{MinifyDeadLoops, "+-+><.[-]"},
+		{AllMinifyRules, "+.[-]"},
+	}
+	for _, tt := range tests {
+		got, err := Minify([]byte(src), tt.rules)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("rules %d: got %q with error %v, want %q", tt.rules, got, err, tt.want)
+		}
+	}
+	if _, err := Minify([]byte("+]"), AllMinifyRules); err == nil {
+		t.Errorf("minified a program that does not parse")
+	}
+}
+
+func TestMinifySameOutput(t *testing.T) {
+	for _, p := range corpus(t, "*.bf") {
+		want, _, werr := runProgram(t, p.src, p.input, 0)
+		for _, rules := range []MinifyRule{0, MinifyCancel, MinifyDeadLoops, AllMinifyRules} {
+			min, err := Minify([]byte(p.src), rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _, gerr := runProgram(t, string(min), p.input, 0)
+			if got != want || (gerr == nil) != (werr == nil) {
+				t.Errorf("%s rules %d: output %q with error %v, want %q with %v", p.name, rules, got, gerr, want, werr)
+			}
+			if len(min) > len(p.src) {
+				t.Errorf("%s rules %d: minified program is longer", p.name, rules)
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package bf
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Profile counts how many times each instruction of a bytecode run
+// executed. A loop's [ and ] are counted each time its condition is
+// tested.
+type Profile struct {
+	code []Instruction
+	counts []int64
+}
+
+// EnableProfile makes the next bytecode run record a Profile.
+func (rt *Runtime) EnableProfile() {
+	rt.profile = &Profile{}
+}
+
+// Profile returns the profile of the last run, or nil if profiling
+// is not enabled.
+func (rt *Runtime) Profile() *Profile {
+	return rt.profile
+}
+
+// Write prints the n most executed instructions to w, with the
+// position and commands each came from.
+func (p *Profile) Write(w io.Writer, n int) error {
+	idx := []int{}
+	for i, c := range p.counts {
+		if c > 0 {
+			idx = append(idx, i)
+		}
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return p.counts[idx[a]] > p.counts[idx[b]]
+	})
+	if len(idx) > n {
+		idx = idx[:n]
+	}
+	if _, err := fmt.Fprintf(w, "%12s  %-8s %s\n", "count", "line:col", "command"); err != nil {
+		return err
+	}
+	for _, i := range idx {
+		if _, err := fmt.Fprintf(w, "%12d  %-8s %s\n", p.counts[i], lineCol(p.code[i].pos), p.code[i].String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String renders an instruction as the commands it stands for, with
+// long runs shortened to a count, as in +5 or >12.
+func (in Instruction) String() string {
+	s := ""
+	switch in.op {
+	case OpMove:
+		s = repeat('>', '<', in.arg)
+	case OpUpdate:
+		s = repeat('+', '-', in.arg)
+	case OpSet:
+		if in.step != 0 {
+			s = "[" + repeat('+', '-', in.step) + "]"
+		} else {
+			s = fmt.Sprintf("=%d", in.arg)
+		}
+	case OpMulAdd:
+		s = "[-"
+		for _, t := range in.terms {
+			s += fmt.Sprintf(" %+d*%d", t.off, t.factor)
+		}
+		s += "]"
+	case OpScan:
+		s = "[" + repeat('>', '<', in.arg) + "]"
+	case OpGetchar:
+		s = ","
+	case OpPutchar:
+		s = "."
+	case OpDump:
+		s = "#"
+	case OpOpen:
+		s = "["
+	case OpClose:
+		s = "]"
+	case OpDefine:
+		s = "("
+	case OpCall:
+		s = ":"
+	case OpReturn:
+		s = ")"
+	case OpFork:
+		s = "Y"
+	case OpHalt:
+		s = "@"
+	case OpStore:
+		s = "$"
+	case OpRetrieve:
+		s = "!"
+	case OpHang:
+		s = "[]"
+	}
+	if in.off != 0 {
+		s = fmt.Sprintf("%s@%+d", s, in.off)
+	}
+	return s
+}
+
+// repeat writes |n| copies of up or down, by the sign of n, spelling
+// out short runs and counting long ones.
+func repeat(up, down byte, n int) string {
+	ch := up
+	if n < 0 {
+		ch, n = down, -n
+	}
+	if n <= 3 {
+		return strings.Repeat(string(ch), n)
+	}
+	return fmt.Sprintf("%c%d", ch, n)
+}
@@ -0,0 +1,47 @@
+package bf
+
+import (
+	"strings"
+	"testing"
+)
+
+// withProfile is an Option enabling the profiler.
+func withProfile(rt *Runtime) error {
+	rt.EnableProfile()
+	return nil
+}
+
+func TestProfile(t *testing.T) {
+	_, rt, err := runProgram(t, "+++[>++<-]>.", "", PassCoalesce, withProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	if err := rt.Profile().Write(&buf, 5); err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"       count  line:col command\n" +
+		"           3  1:5      >\n" +
+		"           3  1:6      ++\n" +
+		"           3  1:8      <\n" +
+		"           3  1:9      -\n" +
+		"           3  1:10     ]\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	buf.Reset()
+	if err := rt.Profile().Write(&buf, 100); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "           1  1:1      +++\n") || !strings.Contains(buf.String(), "           1  1:4      [\n") {
+		t.Errorf("profile lacks the commands run once:\n%s", buf.String())
+	}
+}
+
+func TestProfileDisabled(t *testing.T) {
+	_, rt, err := runProgram(t, "+.", "", 0)
+	if err != nil || rt.Profile() != nil {
+		t.Errorf("unprofiled run made profile %v with error %v", rt.Profile(), err)
+	}
+}
@@ -0,0 +1,190 @@
+package bf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// A program file holds compiled bytecode, so it can be run without
+// parsing and optimizing the source again. Numbers after the version
+// are signed varints. Positions don't keep their file names:
+//
+//	magic	"BFPG"
+//	version	uint16 little endian, currently 1
+//	count	the number of instructions, then for each:
+//		op, arg, off, pos, line, col, min, max, step,
+//		and the number of terms followed by each term's off and factor
+
+const (
+	programMagic = "BFPG"
+	programVersion = 1
+)
+
+// ErrNotProgram is returned by LoadProgram for input that does not
+// start like a program file.
+var ErrNotProgram = errors.New("not a compiled program file")
+
+// SaveProgram compiles prog and writes it to w as a program file.
+func SaveProgram(w io.Writer, prog Runner) error {
+	code, err := Compile(prog)
+	if err != nil {
+		return err
+	}
+	return SaveCode(w, code)
+}
+
+// SaveCode writes compiled code to w as a program file.
+func SaveCode(w io.Writer, code []Instruction) error {
+	bw := bufio.NewWriter(w)
+	buf := make([]byte, binary.MaxVarintLen64)
+	put := func(vs ...int) {
+		for _, v := range vs {
+			n := binary.PutVarint(buf, int64(v))
+			bw.Write(buf[:n])
+		}
+	}
+	bw.WriteString(programMagic)
+	binary.Write(bw, binary.LittleEndian, uint16(programVersion))
+	put(len(code))
+	for _, in := range code {
+		put(int(in.op), in.arg, in.off, in.pos.pos, in.pos.lno, in.pos.linepos, in.min, in.max, in.step)
+		put(len(in.terms))
+		for _, t := range in.terms {
+			put(t.off, t.factor)
+		}
+	}
+	return bw.Flush()
+}
+
+// Compiled is a program loaded from a program file. Running it runs
+// its bytecode, even when tracing.
+type Compiled struct {
+	code []Instruction
+}
+
+func (r *Compiled) Run(rt *Runtime) error {
+	return rt.RunBytecode(r.code)
+}
+
+// Code returns the compiled program's bytecode.
+func (r *Compiled) Code() []Instruction {
+	return r.code
+}
+
+// IsProgram reports whether src starts like a program file.
+func IsProgram(src []byte) bool {
+	return bytes.HasPrefix(src, []byte(programMagic))
+}
+
+// LoadProgram reads a program file written by SaveProgram, returning
+// a *Compiled. A file that is truncated, corrupt or from a newer
+// version of the format is an error.
+func LoadProgram(r io.Reader) (Runner, error) {
+	code, err := loadCode(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Compiled{code}, nil
+}
+
+func loadCode(r io.Reader) ([]Instruction, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(programMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != programMagic {
+		return nil, ErrNotProgram
+	}
+	var version uint16
+	if err := binary.Read(br, binary.LittleEndian, &version); err != nil {
+		return nil, errTruncated(err)
+	}
+	if version > programVersion {
+		return nil, fmt.Errorf("program file version %d is newer than this bf supports, recompile it from source", version)
+	}
+	if version != programVersion {
+		return nil, fmt.Errorf("program file version %d is not supported", version)
+	}
+
+	var err error
+	get := func() int {
+		if err != nil {
+			return 0
+		}
+		var v int64
+		v, err = binary.ReadVarint(br)
+		return int(v)
+	}
+	n := get()
+	if err == nil && n < 0 {
+		return nil, errors.New("program file is corrupt")
+	}
+	// don't trust the count for the allocation, only the loop
+	var code []Instruction
+	for i := 0; i < n && err == nil; i++ {
+		op := get()
+		if err == nil && (op < 0 || op >= int(numOps)) {
+			return nil, fmt.Errorf("program file is corrupt: unknown opcode %d", op)
+		}
+		in := Instruction{op: Opcode(op), arg: get(), off: get()}
+		in.pos = Pos{pos: get(), lno: get(), linepos: get()}
+		in.min, in.max, in.step = get(), get(), get()
+		terms := get()
+		if err == nil && (terms < 0 || terms > n) {
+			return nil, errors.New("program file is corrupt")
+		}
+		for j := 0; j < terms && err == nil; j++ {
+			in.terms = append(in.terms, MulTerm{get(), get()})
+		}
+		code = append(code, in)
+	}
+	if err != nil {
+		return nil, errTruncated(err)
+	}
+	if err := checkCode(code); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+func errTruncated(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errors.New("program file is truncated")
+	}
+	return err
+}
+
+// checkCode makes sure loaded code is safe to run: every bracket and
+// procedure refers to its other end and every MulAdd term lies in
+// its extent.
+func checkCode(code []Instruction) error {
+	for i, in := range code {
+		switch {
+		case in.op == OpMulAdd && !termsInRange(in):
+			return fmt.Errorf("program file is corrupt: bad multiply at %s", in.pos)
+		case in.op == OpOpen && (in.arg <= i || in.arg >= len(code) || code[in.arg].op != OpClose || code[in.arg].arg != i):
+			return fmt.Errorf("program file is corrupt: unmatched [ at %s", in.pos)
+		case in.op == OpClose && (in.arg < 0 || in.arg >= i || code[in.arg].op != OpOpen || code[in.arg].arg != i):
+			return fmt.Errorf("program file is corrupt: unmatched ] at %s", in.pos)
+		case in.op == OpDefine && (in.arg <= i || in.arg >= len(code) || code[in.arg].op != OpReturn || code[in.arg].arg != i):
+			return fmt.Errorf("program file is corrupt: unmatched ( at %s", in.pos)
+		case in.op == OpReturn && (in.arg < 0 || in.arg >= i || code[in.arg].op != OpDefine || code[in.arg].arg != i):
+			return fmt.Errorf("program file is corrupt: unmatched ) at %s", in.pos)
+		}
+	}
+	return nil
+}
+
+func termsInRange(in Instruction) bool {
+	if in.min > 0 || in.max < 0 {
+		return false
+	}
+	for _, t := range in.terms {
+		if t.off < in.min || t.off > in.max {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+package bf
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// everyOp compiles programs that between them use every opcode.
+func everyOp(t *testing.T) [][]Instruction {
+	t.Helper()
+	progs := []struct {
+		dialect Dialect
+		src string
+		passes Pass
+	}{
+		{DialectBF, "+[>+<-]>[-]<[>]>,[.,]#", AllPasses},
+		{DialectBF, "+[]", PassHangs},
+		{DialectPbrain, "+(-):", 0},
+		{DialectBrainfork, "+Y", 0},
+		{DialectEBF1, "+$>!@", 0},
+	}
+	var codes [][]Instruction
+	seen := map[Opcode]bool{}
+	for _, p := range progs {
+		parser := &Parser{Dialect: p.dialect, Debug: true}
+		prog, err := parser.ParseBytes([]byte(p.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, err := Compile(Optimize(prog, p.passes))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, in := range code {
+			seen[in.op] = true
+		}
+		codes = append(codes, code)
+	}
+	for op := Opcode(0); op < numOps; op++ {
+		if !seen[op] {
+			t.Fatalf("no program uses opcode %d", op)
+		}
+	}
+	return codes
+}
+
+func TestProgramRoundTrip(t *testing.T) {
+	for _, code := range everyOp(t) {
+		var saved bytes.Buffer
+		if err := SaveCode(&saved, code); err != nil {
+			t.Fatal(err)
+		}
+		prog, err := LoadProgram(bytes.NewReader(saved.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded := prog.(*Compiled).Code()
+		if !reflect.DeepEqual(loaded, code) {
+			t.Errorf("loaded:\n%v\nwant:\n%v", loaded, code)
+		}
+		var again bytes.Buffer
+		if err := SaveCode(&again, loaded); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(saved.Bytes(), again.Bytes()) {
+			t.Errorf("program file changed after loading it")
+		}
+	}
+}
+
+func TestProgramVersion(t *testing.T) {
+	var saved bytes.Buffer
+	if err := SaveProgram(&saved, parse(t, "+.")); err != nil {
+		t.Fatal(err)
+	}
+	for version, want := range map[byte]string{
+		0: "program file version 0 is not supported",
+		2: "program file version 2 is newer than this bf supports, recompile it from source",
+	} {
+		b := append([]byte(nil), saved.Bytes()...)
+		b[len(programMagic)] = version
+		if _, err := LoadProgram(bytes.NewReader(b)); err == nil || err.Error() != want {
+			t.Errorf("version %d: error %v, want %q", version, err, want)
+		}
+	}
+	if _, err := LoadProgram(strings.NewReader("+.")); err != ErrNotProgram {
+		t.Errorf("loading source gave error %v", err)
+	}
+}
+
+func TestRunCompiledHello(t *testing.T) {
+	hello := corpus(t, "hello.bf")[0]
+	fn := filepath.Join(t.TempDir(), "hello.bfc")
+	var saved bytes.Buffer
+	if err := SaveProgram(&saved, Optimize(parse(t, hello.src), AllPasses)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fn, saved.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	prog, err := LoadProgram(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compiled bytes.Buffer
+	if err := New(nil, &compiled).Run(prog); err != nil {
+		t.Fatal(err)
+	}
+	source, _, err := runProgram(t, hello.src, "", 0)
+	if err != nil || compiled.String() != source {
+		t.Errorf("compiled program printed %q, source %q with error %v", compiled.String(), source, err)
+	}
+}
+
+func TestLoadGarbage(t *testing.T) {
+	var saved bytes.Buffer
+	if err := SaveProgram(&saved, Optimize(parse(t, "+[>+<-]>[-],[.,]"), AllPasses)); err != nil {
+		t.Fatal(err)
+	}
+	good := saved.Bytes()
+	// every truncation fails cleanly
+	for n := 0; n < len(good); n++ {
+		if _, err := LoadProgram(bytes.NewReader(good[:n])); err == nil {
+			t.Errorf("loaded a file truncated to %d of %d bytes", n, len(good))
+		}
+	}
+	// and so does garbage after the header, whatever it is
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		b := append([]byte(nil), good[:len(programMagic) + 2]...)
+		junk := make([]byte, rnd.Intn(64))
+		rnd.Read(junk)
+		b = append(b, junk...)
+		if prog, err := LoadProgram(bytes.NewReader(b)); err == nil {
+			// junk that happens to be a valid program must at least run
+			rt, err := NewRuntime(WithInput(bytes.NewReader(nil)), WithOutput(io.Discard), WithMaxSteps(1000))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rt.Run(prog)
+		}
+	}
+}
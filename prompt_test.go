@@ -0,0 +1,63 @@
+package bf
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+// promptLog is an output and a prompt writer that share one log, so
+// the order of prompts and output shows.
+type promptLog struct {
+	log *strings.Builder
+	tag string
+}
+
+func (w promptLog) Write(p []byte) (int, error) {
+	w.log.WriteString(w.tag)
+	return w.log.Write(p)
+}
+
+func TestInputPrompt(t *testing.T) {
+	tests := []struct {
+		src, input string
+		prompt string
+		want string
+	}{
+		// the output before a , is written before its prompt
+		{"+++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++.,.", "\n", "> ", "out:?prompt:> out:\n"},
+		// reads once the input has ended don't prompt
+		{",.,.,,,", "ab", "> ", "prompt:> out:aprompt:> out:bprompt:> "},
+		{",.,", "a", "", "out:a"},
+	}
+	for _, tt := range tests {
+		var log strings.Builder
+		rt, err := NewRuntime(WithInput(strings.NewReader(tt.input)), WithOutput(promptLog{&log, "out:"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rt.SetInputPrompt(tt.prompt, promptLog{&log, "prompt:"})
+		if err := rt.Run(parse(t, tt.src)); err != nil {
+			t.Fatal(err)
+		}
+		if log.String() != tt.want {
+			t.Errorf("%q on %q: %q, want %q", tt.src, tt.input, log.String(), tt.want)
+		}
+	}
+}
+
+func TestInputPromptBuffered(t *testing.T) {
+	// reads of input already buffered neither prompt nor flush
+	var log strings.Builder
+	rt, err := NewRuntime(WithInput(bufio.NewReader(strings.NewReader("ab"))), WithOutput(promptLog{&log, "out:"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt.SetInputPrompt("> ", promptLog{&log, "prompt:"})
+	if err := rt.Run(parse(t, ",.,.,")); err != nil {
+		t.Fatal(err)
+	}
+	if want := "prompt:> out:abprompt:> "; log.String() != want {
+		t.Errorf("%q, want %q", log.String(), want)
+	}
+}
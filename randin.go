@@ -0,0 +1,42 @@
+package bf
+
+import (
+	"io"
+	"math/rand"
+)
+
+// RandReader is an input of pseudo-random bytes, which are the same
+// for the same seed, for testing programs on input no one wrote.
+type RandReader struct {
+	r *rand.Rand
+	left int64 // bytes before EOF, or negative for no end
+}
+
+// NewRandReader returns a RandReader of n bytes from seed, then EOF,
+// or of endless bytes if n is negative.
+func NewRandReader(seed int64, n int64) *RandReader {
+	return &RandReader{r: rand.New(rand.NewSource(seed)), left: n}
+}
+
+// ReadByte returns the next byte, so a Runtime reads the input
+// without buffering ahead of the program.
+func (r *RandReader) ReadByte() (byte, error) {
+	if r.left == 0 {
+		return 0, io.EOF
+	}
+	if r.left > 0 {
+		r.left--
+	}
+	return byte(r.r.Uint32()), nil
+}
+
+func (r *RandReader) Read(p []byte) (int, error) {
+	for i := range p {
+		b, err := r.ReadByte()
+		if err != nil {
+			return i, err
+		}
+		p[i] = b
+	}
+	return len(p), nil
+}
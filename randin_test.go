@@ -0,0 +1,58 @@
+package bf
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRandReader(t *testing.T) {
+	a, err := io.ReadAll(NewRandReader(7, 1000))
+	if err != nil || len(a) != 1000 {
+		t.Fatalf("read %d bytes, %v", len(a), err)
+	}
+	if b, _ := io.ReadAll(NewRandReader(7, 1000)); !bytes.Equal(a, b) {
+		t.Error("same seed, different bytes")
+	}
+	if b, _ := io.ReadAll(NewRandReader(8, 1000)); bytes.Equal(a, b) {
+		t.Error("different seeds, same bytes")
+	}
+	// a shorter input is the start of a longer one
+	if b, _ := io.ReadAll(NewRandReader(7, 10)); !bytes.Equal(a[:10], b) {
+		t.Errorf("10 bytes %x, want %x", b, a[:10])
+	}
+	r := NewRandReader(7, 3)
+	p := make([]byte, 5)
+	if n, err := r.Read(p); n != 3 || err != io.EOF || !bytes.Equal(p[:3], a[:3]) {
+		t.Errorf("short read: %d, %v", n, err)
+	}
+	if _, err := r.ReadByte(); err != io.EOF {
+		t.Errorf("after the end: %v", err)
+	}
+	endless := NewRandReader(7, -1)
+	if n, err := io.ReadFull(endless, make([]byte, 1 << 16)); n != 1 << 16 || err != nil {
+		t.Errorf("endless: %d, %v", n, err)
+	}
+}
+
+func TestRandInput(t *testing.T) {
+	want, _ := io.ReadAll(NewRandReader(42, 500))
+	want = append(want, make([]byte, 100)...)
+	// the program reads 600 times and writes what it read; past the
+	// 500 bytes it reads EOF, which stores 0
+	prog := Optimize(parse(t, strings.Repeat(",.", 600)), AllPasses)
+	for i := 0; i < 2; i++ {
+		var out bytes.Buffer
+		rt, err := NewRuntime(WithInput(NewRandReader(42, 500)), WithOutput(&out), WithEOFMode("0"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rt.Run(prog); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out.Bytes(), want) {
+			t.Errorf("run %d: output %x, want %x", i + 1, out.Bytes(), want)
+		}
+	}
+}
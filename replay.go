@@ -0,0 +1,83 @@
+package bf
+
+import (
+	"fmt"
+)
+
+// ReplayError is returned when a run replaying a transcript first
+// differs from it. Event is the index of the transcript event it
+// differs at, from 1, and Step and Pos are where the run was. Want is
+// that event, or nil if the run went past the end of the transcript,
+// and Got is what the run did instead, or nil if it ended.
+type ReplayError struct {
+	Event int
+	Step int64
+	Pos Pos
+	Want *TranscriptEvent
+	Got *TranscriptEvent
+}
+
+func (e *ReplayError) Error() string {
+	run := describeEvent(e.Got)
+	if e.Got != nil && e.Got.Dir == "in" {
+		run = "reads"
+	}
+	return fmt.Sprintf("replay differs from event %d at step %d at %+v: transcript %s, run %s", e.Event, e.Step, e.Pos, describeEvent(e.Want), run)
+}
+
+// describeEvent says what e did, or that the run ended if it is nil.
+func describeEvent(e *TranscriptEvent) string {
+	switch {
+	case e == nil:
+		return "ends"
+	case e.Dir == "in" && e.EOF:
+		return "reads EOF"
+	case e.Dir == "in":
+		return fmt.Sprintf("reads %#02x", e.Byte)
+	}
+	return fmt.Sprintf("writes %#02x", e.Byte)
+}
+
+// replay is a transcript being replayed.
+type replay struct {
+	events []TranscriptEvent
+	next int
+}
+
+// Replay makes later runs take their input from the transcript events
+// instead of the Runtime's input, in order, and fail with a
+// ReplayError where they read or write anything other than the next
+// event, or end before the last. Steps are not compared, as they
+// change with optimization. A nil events stops replaying.
+func (rt *Runtime) Replay(events []TranscriptEvent) {
+	rt.replay = nil
+	if events != nil {
+		rt.replay = &replay{events: events}
+	}
+}
+
+// checkReplay matches what the run did, got, with the next event, which it
+// returns, or returns a ReplayError. For a read only got's Dir counts.
+func (rt *Runtime) checkReplay(got TranscriptEvent, at Pos) (TranscriptEvent, error) {
+	p := rt.replay
+	err := &ReplayError{Event: p.next + 1, Step: rt.steps, Pos: at, Got: &got}
+	if p.next == len(p.events) {
+		return got, err
+	}
+	want := p.events[p.next]
+	if want.Dir != got.Dir || want.Dir == "out" && want.Byte != got.Byte {
+		err.Want = &want
+		return got, err
+	}
+	p.next++
+	return want, nil
+}
+
+// endReplay reports a run that ended before its transcript.
+func (rt *Runtime) endReplay() error {
+	p := rt.replay
+	if p == nil || p.next == len(p.events) {
+		return nil
+	}
+	return &ReplayError{Event: p.next + 1, Step: rt.steps, Pos: rt.at, Want: &p.events[p.next]}
+}
@@ -0,0 +1,54 @@
+package bf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// record returns the transcript events of running src on input.
+func record(t *testing.T, src, input string) []TranscriptEvent {
+	t.Helper()
+	var rec strings.Builder
+	if _, _, err := runProgram(t, src, input, 0, WithEOFMode("0"), WithTranscript(&rec)); err != nil {
+		t.Fatal(err)
+	}
+	events, err := ReadTranscript(strings.NewReader(rec.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func TestReplay(t *testing.T) {
+	events := record(t, ",[.,]", "hi")
+	// the input comes from the transcript, so the reader's is not used
+	for _, passes := range []Pass{0, AllPasses} {
+		out, _, err := runProgram(t, ",[.,]", "not this", passes, WithEOFMode("0"), WithReplay(events))
+		if err != nil || out != "hi" {
+			t.Errorf("passes %d: output %q, error %v", passes, out, err)
+		}
+	}
+}
+
+func TestReplayDiffers(t *testing.T) {
+	events := record(t, ",[.,]", "hi")
+	tests := []struct {
+		src string
+		want string
+		event int
+	}{
+		{",[+.,]", "replay differs from event 2 at step 4 at 1:4: transcript writes 0x68, run writes 0x69, in loop at 1:2", 2},
+		// the run must read EOF where the transcript did
+		{",.,.", "replay differs from event 5 at step 4 at 1:4: transcript reads EOF, run ends", 5},
+		{",[.,],", "replay differs from event 6 at step 9 at 1:6: transcript ends, run reads", 6},
+		{".", "replay differs from event 1 at step 1 at 1:1: transcript reads 0x68, run writes 0x00", 1},
+	}
+	for _, tt := range tests {
+		_, _, err := runProgram(t, tt.src, "", 0, WithEOFMode("0"), WithReplay(events))
+		var re *ReplayError
+		if !errors.As(err, &re) || re.Event != tt.event || err.Error() != tt.want {
+			t.Errorf("%q: error %v, want %q", tt.src, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package bf
+
+import (
+	"bytes"
+)
+
+// RunError is returned by Run for a program that parsed but failed
+// while running. Parse errors are ParseErrors instead.
+type RunError struct {
+	Err error
+}
+
+func (e *RunError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// Run parses program, runs it optimized on input, and returns what it
+// wrote. It is the simplest way to embed the interpreter. The Runtime
+// has a DefaultTapeSize tape fixed at its ends, and opts, applied
+// after the input and output are set, can change it or add limits
+// such as WithMaxSteps. Output written before an error is returned
+// with it. A program that fails to parse returns its ParseError, or
+// several joined, and one that fails running returns a RunError.
+func Run(program string, input []byte, opts ...Option) ([]byte, error) {
+	prog, err := ParseString(program)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	rt, err := NewRuntime(append([]Option{WithInput(bytes.NewReader(input)), WithOutput(&out)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	passes := AllPasses
+	if rt.bigs != nil {
+		passes &= ExactPasses
+	} else if rt.strict {
+		passes &= StrictPasses
+	}
+	if err := rt.Run(Optimize(prog, passes)); err != nil {
+		return out.Bytes(), &RunError{Err: err}
+	}
+	return out.Bytes(), nil
+}
+
+// RunString is Run for string input and output.
+func RunString(program, input string, opts ...Option) (string, error) {
+	out, err := Run(program, []byte(input), opts...)
+	return string(out), err
+}
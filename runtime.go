@@ -0,0 +1,375 @@
+package bf
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// Runtime holds the state of a running program: the tape, the
+// pointer, input and output, and the settings that control how
+// commands behave. Make one with New.
+type Runtime struct {
+	input io.ByteReader
+	exhausted bool // input has reached EOF, so , no longer reads it
+	output io.Writer
+	buf *bufio.Writer // buffers output unless unbuffered is set
+	unbuffered bool
+	outByte [1]byte // what . writes, kept here so writing it doesn't allocate
+	numSep string // between the values . writes in decimal, if set
+	numbers int64 // values written in decimal this run
+	transcript *transcript // of input and output, if recording
+	replay *replay // the transcript input comes from, if replaying
+	prompt string // written to promptOut before , waits for input
+	promptOut io.Writer
+
+	store []byte
+	trace bool
+	interpret bool // run the tree interpreter even when not tracing
+	traceOut io.Writer
+	traceFormat TraceFormat
+	traceLimit int64
+	traced int64
+	traceBuf []byte
+	pos int
+
+	mode TapeMode
+	maxCells int
+	maxMem int64 // bytes the tape may take, or 0 for no limit
+	origin int // store index of logical cell 0
+
+	wide []uint32
+	mask uint32
+	bigs []*big.Int
+	bigLow bool
+	strict bool
+	eof EOFMode
+
+	steps int64
+	maxSteps int64
+	at Pos // of the command running or last run
+	code []Instruction // of the bytecode run
+	pc int // index in code of the instruction running
+	inputOffset int64 // bytes read by getchar
+	checkpointPath string
+	checkpointWanted int32 // set atomically by RequestCheckpoint
+	resume bool // start the next run at pc
+	sliced bool // a RunSteps is running, with budget instructions left
+	budget int
+	procs map[int64]procedure // by number
+	calls []int // pcs to return to, -1 in the tree interpreter
+	maxCallDepth int // DefaultMaxCallDepth if zero
+	reg uint32 // the Extended Type I storage register
+	bigReg *big.Int // the register for big cells
+	forks *forkGroup // of the run's threads, once it has forked
+	ctx context.Context // checked every cancelInterval ticks
+	snaps *snapshots // nil in forked threads
+	ticks uint
+	profile *Profile
+	hot *HotLoops
+	cover *Coverage
+	stats *Stats
+	debug *Debugger
+	watches []*Watchpoint
+	watchOut io.Writer
+	dumpOut io.Writer // where # writes, stderr if nil
+}
+
+// New returns a Runtime with a DefaultTapeSize tape of 8 bit cells
+// that reads from input and writes to output. Input that is not
+// already an io.ByteReader is read through a bufio.Reader, and nil
+// input is empty, so , sees EOF straight away.
+func New(input io.Reader, output io.Writer) *Runtime {
+	rt := &Runtime{
+		input: byteReader(input),
+		output: output,
+		snaps: &snapshots{},
+	}
+	rt.SetTapeSize(DefaultTapeSize)
+	return rt
+}
+
+// SetInput replaces where , reads from.
+func (rt *Runtime) SetInput(input io.Reader) {
+	rt.input = byteReader(input)
+	rt.exhausted = false
+}
+
+// byteReader returns r if it can already read a byte at a time, or
+// else r behind a bufio.Reader. A buffered reader reads ahead of the
+// program, so anything else reading r afterwards may miss input.
+// A nil r reads as empty.
+func byteReader(r io.Reader) io.ByteReader {
+	if r == nil {
+		return bytes.NewReader(nil)
+	}
+	if br, ok := r.(io.ByteReader); ok {
+		return br
+	}
+	return bufio.NewReader(r)
+}
+
+// SetInputPrompt writes prompt to w each time , is about to wait for
+// input, after the output so far, so a program waiting at a terminal
+// doesn't look hung. The caller decides whether the input is
+// interactive enough for a prompt. An empty prompt writes none.
+func (rt *Runtime) SetInputPrompt(prompt string, w io.Writer) {
+	rt.prompt = prompt
+	rt.promptOut = w
+}
+
+// SetStrictCells makes cell overflow and underflow an error instead
+// of wrapping around.
+func (rt *Runtime) SetStrictCells(on bool) {
+	rt.strict = on
+}
+
+// Pointer returns the logical index of the current cell, where 0 is
+// the cell the pointer started on.
+func (rt *Runtime) Pointer() int {
+	return rt.pos - rt.origin
+}
+
+// Position returns the position of the command that is running or
+// last ran.
+func (rt *Runtime) Position() Pos {
+	return rt.at
+}
+
+// Steps returns the number of steps run so far. See SetMaxSteps.
+func (rt *Runtime) Steps() int64 {
+	return rt.steps
+}
+
+// The methods below implement each command's effect on the tape.
+// They are shared by the tree interpreter and the bytecode loop so
+// the two can't disagree about semantics.
+
+func (rt *Runtime) move(dir int, at Pos) error {
+	if err := rt.step(dir, at); err != nil {
+		return err
+	}
+	pos := rt.pos + dir
+	if pos < 0 || pos >= rt.size() {
+		if rt.mode == TapeFixed {
+			return rt.moveError(dir, at)
+		}
+		var err error
+		if pos, err = rt.index(pos, at); err != nil {
+			return err
+		}
+	}
+	rt.pos = pos
+	return nil
+}
+
+func (rt *Runtime) update(off int, n int, at Pos) error {
+	if err := rt.step(n, at); err != nil {
+		return err
+	}
+	i, err := rt.addr(off, at)
+	if err != nil {
+		return err
+	}
+	if rt.bigs != nil {
+		rt.big(i).Add(rt.big(i), big.NewInt(int64(n)))
+		return nil
+	}
+	if rt.strict {
+		if err := rt.checkAdd(i, int64(n), at); err != nil {
+			return err
+		}
+	}
+	rt.put(i, rt.get(i) + uint32(n))
+	return nil
+}
+
+func (rt *Runtime) set(off int, v byte, step int, at Pos) error {
+	if err := rt.step(1, at); err != nil {
+		return err
+	}
+	i, err := rt.addr(off, at)
+	if err != nil {
+		return err
+	}
+	if rt.strict && rt.bigs == nil && step == 1 && !rt.zero(i) {
+		return rt.overflow(i, int64(rt.get(i)), at)
+	}
+	if rt.bigs != nil {
+		rt.big(i).SetInt64(int64(v))
+		return nil
+	}
+	rt.put(i, uint32(v))
+	return nil
+}
+
+func (rt *Runtime) mulAdd(terms []MulTerm, min, max int, at Pos) error {
+	if err := rt.step(1, at); err != nil {
+		return err
+	}
+	if rt.zero(rt.pos) {
+		return nil
+	}
+	if _, err := rt.addr(min, at); err != nil {
+		return err
+	}
+	if _, err := rt.addr(max, at); err != nil {
+		return err
+	}
+	if rt.strict && rt.bigs == nil {
+		v := int64(rt.get(rt.pos))
+		for _, t := range terms {
+			i, err := rt.addr(t.off, at)
+			if err != nil {
+				return err
+			}
+			if err := rt.checkAdd(i, v * int64(t.factor), at); err != nil {
+				return err
+			}
+		}
+	}
+	if rt.mode == TapeFixed && rt.store != nil {
+		v := rt.store[rt.pos]
+		for _, t := range terms {
+			rt.store[rt.pos + t.off] += v * byte(t.factor)
+		}
+		rt.store[rt.pos] = 0
+		return nil
+	}
+	for _, t := range terms {
+		i, err := rt.addr(t.off, at)
+		if err != nil {
+			return err
+		}
+		if rt.bigs != nil {
+			prod := new(big.Int).Mul(rt.big(rt.pos), big.NewInt(int64(t.factor)))
+			rt.big(i).Add(rt.big(i), prod)
+		} else {
+			rt.put(i, rt.get(i) + rt.get(rt.pos) * uint32(t.factor))
+		}
+	}
+	if rt.bigs != nil {
+		rt.big(rt.pos).SetInt64(0)
+	} else {
+		rt.put(rt.pos, 0)
+	}
+	return nil
+}
+
+func (rt *Runtime) scan(dir int, at Pos) error {
+	if dir == 1 && rt.store != nil && rt.maxSteps == 0 {
+		if i := bytes.IndexByte(rt.store[rt.pos:], 0); i >= 0 {
+			rt.pos += i
+			rt.steps += int64(i)
+			return nil
+		}
+		rt.steps += int64(len(rt.store) - 1 - rt.pos)
+		rt.pos = len(rt.store) - 1
+	}
+	for !rt.zero(rt.pos) {
+		if err := rt.move(dir, at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rt *Runtime) getchar(off int, at Pos) error {
+	if err := rt.step(1, at); err != nil {
+		return err
+	}
+	i, err := rt.addr(off, at)
+	if err != nil {
+		return err
+	}
+	// flush any prompt before waiting for input, but not when
+	// the input is already buffered
+	if br, ok := rt.input.(*bufio.Reader); !ok || br.Buffered() == 0 {
+		if err := rt.flush(); err != nil {
+			return fmt.Errorf("%v in getchar at %+v", err, at)
+		}
+		if rt.prompt != "" && !rt.exhausted && rt.replay == nil {
+			io.WriteString(rt.promptOut, rt.prompt)
+		}
+	}
+	// ReadByte returns a byte or an error, never both, so reads
+	// that return nothing or data along with io.EOF are handled by
+	// the reader, which bufio does. Once input ends it stays ended,
+	// whatever the reader might return if asked again.
+	b, err := byte(0), io.EOF
+	if rt.replay != nil {
+		e, rerr := rt.checkReplay(TranscriptEvent{Dir: "in"}, at)
+		if rerr != nil {
+			return rerr
+		}
+		b, err = e.Byte, nil
+		if e.EOF {
+			err = io.EOF
+		}
+	} else if !rt.exhausted {
+		b, err = rt.input.ReadByte()
+	}
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("%v in getchar at %+v", err, at)
+	}
+	if err == nil {
+		rt.inputOffset++
+	}
+	if rt.transcript != nil {
+		rt.transcript.record(TranscriptEvent{Dir: "in", Step: rt.steps, Byte: b, EOF: err == io.EOF})
+	}
+	if err == io.EOF {
+		rt.exhausted = true
+		switch rt.eof {
+		case EOFNoChange:
+			return nil
+		case EOFZero:
+			b = 0
+		default:
+			b = 0xff
+		}
+	}
+	if rt.bigs != nil {
+		rt.big(i).SetInt64(int64(b))
+		return nil
+	}
+	rt.put(i, uint32(b))
+	return nil
+}
+
+func (rt *Runtime) putchar(off int, at Pos) error {
+	if err := rt.step(1, at); err != nil {
+		return err
+	}
+	i, err := rt.addr(off, at)
+	if err != nil {
+		return err
+	}
+	if rt.numSep != "" {
+		return rt.putNumber(i, at)
+	}
+	bs := rt.outByte[:]
+	if rt.bigs != nil {
+		if bs[0], err = rt.bigByte(i, at); err != nil {
+			return err
+		}
+	} else {
+		bs[0] = byte(rt.get(i))
+	}
+	if rt.transcript != nil {
+		rt.transcript.record(TranscriptEvent{Dir: "out", Step: rt.steps, Byte: bs[0]})
+	}
+	if rt.replay != nil {
+		if _, err := rt.checkReplay(TranscriptEvent{Dir: "out", Byte: bs[0]}, at); err != nil {
+			return err
+		}
+	}
+	_, err = rt.writer().Write(bs)
+	if err != nil {
+		return fmt.Errorf("%v in putchar at %+v", err, at)
+	}
+	return nil
+}
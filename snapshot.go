@@ -0,0 +1,87 @@
+package bf
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"sync/atomic"
+)
+
+// Snapshot is a copy of the state of a running program, taken by
+// Runtime.Snapshot. Cells holds the logical cells from Start on.
+type Snapshot struct {
+	Steps int64
+	Pos Pos
+	Pointer int
+	Start int
+	Cells []*big.Int
+}
+
+// snapshots holds the Snapshot calls waiting for the run to reach a
+// safe point.
+type snapshots struct {
+	mu sync.Mutex
+	wanted int32 // set atomically while requests are pending
+	requests []snapshotRequest
+}
+
+type snapshotRequest struct {
+	window int
+	reply chan *Snapshot
+}
+
+// Snapshot returns a copy of the running program's state, with the
+// cells up to window either side of the pointer that the tape has.
+// It is safe to call from another goroutine while Run is running and
+// asks the run to take the copy the next time it checks for
+// cancellation, which is at most every few thousand steps, so it
+// costs the run nothing unless it is called. An instruction that
+// takes longer, such as a , waiting for input, holds it up, and
+// without a run in progress it waits for one to start or for ctx to
+// be done. Only the first thread of a program that forks is copied.
+func (rt *Runtime) Snapshot(ctx context.Context, window int) (*Snapshot, error) {
+	reply := make(chan *Snapshot, 1)
+	s := rt.snaps
+	s.mu.Lock()
+	s.requests = append(s.requests, snapshotRequest{window, reply})
+	atomic.StoreInt32(&s.wanted, 1)
+	s.mu.Unlock()
+	select {
+	case snap := <-reply:
+		return snap, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// serveSnapshots answers the pending Snapshot calls.
+func (rt *Runtime) serveSnapshots() {
+	s := rt.snaps
+	if s == nil || atomic.LoadInt32(&s.wanted) == 0 {
+		return
+	}
+	s.mu.Lock()
+	requests := s.requests
+	s.requests = nil
+	atomic.StoreInt32(&s.wanted, 0)
+	s.mu.Unlock()
+	for _, req := range requests {
+		req.reply <- rt.snapshot(req.window)
+	}
+}
+
+func (rt *Runtime) snapshot(window int) *Snapshot {
+	snap := &Snapshot{Steps: rt.steps, Pos: rt.at, Pointer: rt.Pointer()}
+	start, end := snap.Pointer - window, snap.Pointer + window
+	if lo := -rt.origin; start < lo {
+		start = lo
+	}
+	if hi := rt.size() - rt.origin - 1; end > hi {
+		end = hi
+	}
+	snap.Start = start
+	for i := start; i <= end; i++ {
+		snap.Cells = append(snap.Cells, rt.Cell(i))
+	}
+	return snap
+}
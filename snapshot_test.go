@@ -0,0 +1,93 @@
+package bf
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// TestSnapshotRace has several goroutines sample a long run as fast as
+// they can; run it with -race to check that the copies don't race
+// with the run.
+func TestSnapshotRace(t *testing.T) {
+	// three nested loops of 256 and one of 50, about 10^9 steps unoptimized
+	// if left to finish, so the run is still going when sampled
+	src := "-[>-[>-[>++++++++++[>+<-]<-]<-]<-]"
+	if testing.Short() {
+		src = "-[>-[>-[-]<-]<-]"
+	}
+	rt, err := NewRuntime(WithOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- rt.RunContext(ctx, parse(t, src))
+	}()
+	samplers := make(chan error, 4)
+	for i := 0; i < cap(samplers); i++ {
+		go func() {
+			var last int64
+			for n := 0; n < 50; n++ {
+				sctx, stop := context.WithTimeout(ctx, time.Second)
+				snap, err := rt.Snapshot(sctx, 2)
+				stop()
+				if err != nil {
+					// the run ended first
+					samplers <- nil
+					return
+				}
+				if snap.Steps < last || snap.Pointer < 0 || snap.Pointer > 4 || snap.Start != snap.Pointer - 2 && snap.Start != 0 || len(snap.Cells) > 5 {
+					samplers <- errors.New("inconsistent snapshot")
+					return
+				}
+				last = snap.Steps
+			}
+			samplers <- nil
+		}()
+	}
+	for i := 0; i < cap(samplers); i++ {
+		if err := <-samplers; err != nil {
+			t.Error(err)
+		}
+	}
+	cancel()
+	if err := <-done; err != nil && !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotState(t *testing.T) {
+	rt, err := NewRuntime(WithOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// without a run to answer it, Snapshot waits for ctx
+	ctx, cancel := context.WithTimeout(context.Background(), 10 * time.Millisecond)
+	if _, err := rt.Snapshot(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("no run: error %v", err)
+	}
+	cancel()
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		// +[] never ends, with the pointer on cell 1 and cell 0 set to 7
+		done <- rt.RunContext(ctx, parse(t, "+++++++>+[]"))
+	}()
+	snap, err := rt.Snapshot(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the window stops at the start of the tape
+	if snap.Pointer != 1 || snap.Start != 0 || len(snap.Cells) != 5 || snap.Cells[0].Int64() != 7 || snap.Cells[1].Int64() != 1 || snap.Steps < cancelInterval - 1 || snap.Pos.String() != "1:11" {
+		t.Errorf("snapshot %+v", snap)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("run ended with %v", err)
+	}
+}
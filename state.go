@@ -0,0 +1,162 @@
+package bf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// stateCells is how many cells DumpState shows, and stateRow how many
+// it puts on a line.
+const (
+	stateCells = 32
+	stateRow = 16
+)
+
+// DumpState writes a diagnostic block to w: the position of the
+// current command, the step count, the pointer, and a hex and ASCII
+// dump of the cells around the logical cell center, with the pointer
+// marked. The window keeps its size near the ends of the tape.
+func (rt *Runtime) DumpState(w io.Writer, center int) {
+	ptr := rt.pos - rt.origin
+	fmt.Fprintf(w, "at %s step %d ptr %d\n", lineCol(rt.at), rt.steps, ptr)
+
+	first, last := -rt.origin, rt.size() - 1 - rt.origin
+	lo := center - stateCells / 2
+	if lo + stateCells - 1 > last {
+		lo = last - stateCells + 1
+	}
+	if lo < first {
+		lo = first
+	}
+	hi := lo + stateCells - 1
+	if hi > last {
+		hi = last
+	}
+
+	for row := lo; row <= hi; row += stateRow {
+		end := row + stateRow - 1
+		if end > hi {
+			end = hi
+		}
+		rt.writeRow(w, row, end, 0)
+	}
+}
+
+// writeRow writes the logical cells from row to end in hex and ASCII,
+// after lead blank cells to line them up with full rows, with the
+// pointer marked below them if it is among them.
+func (rt *Runtime) writeRow(w io.Writer, row, end, lead int) {
+	var hex, ascii, mark strings.Builder
+	if lead > 0 {
+		blank := strings.Repeat(" ", len(rt.cellHex(row + rt.origin)) + 1)
+		hex.WriteString(strings.Repeat(blank, lead))
+		ascii.WriteString(strings.Repeat(" ", lead))
+		mark.WriteString(strings.Repeat(blank, lead))
+	}
+	for c := row; c <= end; c++ {
+		i := c + rt.origin
+		v := rt.cellHex(i)
+		hex.WriteString(" " + v)
+		ch := byte('.')
+		if rt.bigs == nil {
+			if x := rt.get(i); x >= ' ' && x <= '~' {
+				ch = byte(x)
+			}
+		} else if b := rt.big(i); b.IsInt64() && b.Int64() >= ' ' && b.Int64() <= '~' {
+			ch = byte(b.Int64())
+		}
+		ascii.WriteByte(ch)
+		pad := " "
+		if i == rt.pos {
+			pad = "^"
+		}
+		mark.WriteString(" " + strings.Repeat(pad, len(v)))
+	}
+	fmt.Fprintf(w, "%6d:%s  |%s|\n", row, hex.String(), ascii.String())
+	if rt.pos - rt.origin >= row && rt.pos - rt.origin <= end {
+		fmt.Fprintf(w, "%7s%s\n", "", strings.TrimRight(mark.String(), " "))
+	}
+}
+
+// ShowTape writes the part of the tape from the first cell that is
+// not zero to the last, and the pointer, to w in rows like DumpState,
+// with the pointer marked. Rows hold the cells from a multiple of 16,
+// so the first row of a tape that grew left may be short. Two or more
+// rows of zeros in a row are shown as one line saying how many cells
+// they hold.
+func (rt *Runtime) ShowTape(w io.Writer) {
+	ptr := rt.pos - rt.origin
+	first, last := -rt.origin, rt.size() - 1 - rt.origin
+	lo, hi := ptr, ptr
+	for c := first; c <= last; c++ {
+		if !rt.zero(c + rt.origin) {
+			if c < lo {
+				lo = c
+			}
+			if c > hi {
+				hi = c
+			}
+		}
+	}
+	fmt.Fprintf(w, "cells %d to %d, pointer at %d\n", lo, hi, ptr)
+
+	// rows start at multiples of stateRow, but the first not before
+	// the tape, which may leave it short
+	start := rowStart(lo)
+	if start < first {
+		start = first
+	}
+	from, zeros := 0, 0 // the first and number of rows of zeros not yet written
+	for row := start; row <= hi; row = rowEnd(row) + 1 {
+		end := rowEnd(row)
+		if end > hi {
+			end = hi
+		}
+		if rt.zeroRow(row, end) {
+			if zeros == 0 {
+				from = row
+			}
+			zeros++
+			continue
+		}
+		rt.writeZeros(w, from, row, zeros)
+		zeros = 0
+		rt.writeRow(w, row, end, row - rowStart(row))
+	}
+}
+
+// rowStart returns the first logical cell of the row holding c.
+func rowStart(c int) int {
+	return c - (c % stateRow + stateRow) % stateRow
+}
+
+// rowEnd returns the last logical cell of the row holding c.
+func rowEnd(c int) int {
+	return rowStart(c) + stateRow - 1
+}
+
+// zeroRow reports whether the cells from row to end are all zeros and
+// don't hold the pointer.
+func (rt *Runtime) zeroRow(row, end int) bool {
+	for c := row; c <= end; c++ {
+		if c == rt.pos - rt.origin || !rt.zero(c + rt.origin) {
+			return false
+		}
+	}
+	return true
+}
+
+// writeZeros writes the n rows of zeros from the row starting at from
+// to the one before next, one at a time or, for more than one, as a
+// single line.
+func (rt *Runtime) writeZeros(w io.Writer, from, next, n int) {
+	switch {
+	case n == 0:
+		return
+	case n == 1:
+		rt.writeRow(w, from, next - 1, from - rowStart(from))
+		return
+	}
+	fmt.Fprintf(w, "%6d: … %d zero cells\n", from, next - from)
+}
@@ -0,0 +1,78 @@
+package bf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDumpStateEdges(t *testing.T) {
+	tests := []struct {
+		name string
+		src string
+		size int
+		want string
+	}{
+		{"start", "+++>", 40, "" +
+			"at 1:4 step 4 ptr 1\n" +
+			"     0: 03 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00  |................|\n" +
+			"           ^^\n" +
+			"    16: 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00  |................|\n"},
+		// the window stops at the last cell rather than shrinking
+		{"end", strings.Repeat(">", 39) + strings.Repeat("+", 65) + ">", 40, "" +
+			"at 1:105 step 105 ptr 39\n" +
+			"     8: 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00  |................|\n" +
+			"    24: 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 41  |...............A|\n" +
+			"                                                     ^^\n"},
+		{"short", "+", 10, "" +
+			"at 1:1 step 1 ptr 0\n" +
+			"     0: 01 00 00 00 00 00 00 00 00 00  |..........|\n" +
+			"        ^^\n"},
+	}
+	for _, tt := range tests {
+		_, rt, err := runProgram(t, tt.src, "", 0, WithTapeSize(tt.size))
+		if tt.name == "end" && err == nil {
+			t.Errorf("%s: ran off the tape without error", tt.name)
+		}
+		var buf strings.Builder
+		rt.DumpState(&buf, rt.Pointer())
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestShowTape(t *testing.T) {
+	infinite := []Option{WithTapeSize(10), WithTapeMode("infinite", 1000)}
+	tests := []struct {
+		golden string
+		src string
+		opts []Option
+	}{
+		// two rows of zeros collapse into one line, a single one doesn't
+		{"aligned.tape", "+>++" + strings.Repeat(">", 60) + "+++<<", nil},
+		{"single.tape", "+" + strings.Repeat(">", 40) + "+", nil},
+		// growing left moves the origin to a cell that doesn't start a
+		// row, so the first row is short and lined up with the others
+		{"unaligned.tape", "<<<<<+" + strings.Repeat(">", 45) + "++>>", infinite},
+		{"unaligned-near.tape", "<<<<<+" + strings.Repeat(">", 9) + "++>>", infinite},
+		// the tape is shown after an error too
+		{"error.tape", "++>+++<<", nil},
+	}
+	for _, tt := range tests {
+		_, rt, err := runProgram(t, tt.src, "", 0, tt.opts...)
+		if (err != nil) != (tt.golden == "error.tape") {
+			t.Errorf("%s: error %v", tt.golden, err)
+		}
+		want, err := os.ReadFile(filepath.Join("testdata", "dump", tt.golden))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf strings.Builder
+		rt.ShowTape(&buf)
+		if got := buf.String(); got != string(want) {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tt.golden, got, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package bf
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Stats summarizes a bytecode run: the instructions it executed by
+// opcode, the bytes it read and wrote, the highest logical cell it
+// touched, and how long it took.
+type Stats struct {
+	Ops [numOps]int64
+	Input int64
+	Output int64
+	MaxCell int
+	Time time.Duration
+
+	start time.Time
+	input int64 // inputOffset at the start
+}
+
+// EnableStats makes the next bytecode run record Stats.
+func (rt *Runtime) EnableStats() {
+	rt.stats = &Stats{}
+}
+
+// Stats returns the statistics of the last run, or nil if they are
+// not enabled.
+func (rt *Runtime) Stats() *Stats {
+	return rt.stats
+}
+
+func (s *Stats) reset(rt *Runtime) {
+	*s = Stats{start: time.Now(), input: rt.inputOffset}
+}
+
+// before counts in and notes the cells it is about to touch.
+func (s *Stats) before(rt *Runtime, in *Instruction) {
+	s.Ops[in.op]++
+	hi := rt.pos - rt.origin + in.off
+	if in.op == OpMulAdd {
+		hi += in.max
+	}
+	if hi > s.MaxCell {
+		s.MaxCell = hi
+	}
+}
+
+func (s *Stats) finish(rt *Runtime) {
+	s.Time = time.Since(s.start)
+	s.Input = rt.inputOffset - s.input
+	s.Output = s.Ops[OpPutchar]
+	if cell := rt.pos - rt.origin; cell > s.MaxCell {
+		s.MaxCell = cell
+	}
+}
+
+// Total returns how many instructions the run executed.
+func (s *Stats) Total() int64 {
+	var n int64
+	for _, c := range s.Ops {
+		n += c
+	}
+	return n
+}
+
+var opNames = [...]string{
+	OpMove: "move",
+	OpUpdate: "update",
+	OpSet: "set",
+	OpMulAdd: "muladd",
+	OpScan: "scan",
+	OpGetchar: "getchar",
+	OpPutchar: "putchar",
+	OpDump: "dump",
+	OpOpen: "open",
+	OpClose: "close",
+	OpDefine: "define",
+	OpCall: "call",
+	OpReturn: "return",
+	OpFork: "fork",
+	OpHalt: "halt",
+	OpStore: "store",
+	OpRetrieve: "retrieve",
+	OpHang: "hang",
+}
+
+// Write prints the statistics to w.
+func (s *Stats) Write(w io.Writer) error {
+	total := s.Total()
+	rate := 0.0
+	if s.Time > 0 {
+		rate = float64(total) / s.Time.Seconds()
+	}
+	if _, err := fmt.Fprintf(w, "%d instructions in %v, %.0f per second\n", total, s.Time, rate); err != nil {
+		return err
+	}
+	for op, c := range s.Ops {
+		if c == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%12d  %s\n", c, opNames[op]); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "read %d bytes, wrote %d, highest cell %d\n", s.Input, s.Output, s.MaxCell)
+	return err
+}
@@ -0,0 +1,79 @@
+package bf
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	const src = "+++[>++<-]>.,<"
+	tests := []struct {
+		passes Pass
+		ops map[Opcode]int64
+	}{
+		// three trips round the loop, each with two moves and three updates
+		{0, map[Opcode]int64{OpMove: 2 + 3 * 2, OpUpdate: 3 + 3 * 3, OpOpen: 1, OpClose: 3, OpGetchar: 1, OpPutchar: 1}},
+		{AllPasses, map[Opcode]int64{OpUpdate: 1, OpMulAdd: 1, OpGetchar: 1, OpPutchar: 1}},
+	}
+	for _, tt := range tests {
+		rt, err := NewRuntime(WithInput(strings.NewReader("x")), WithOutput(io.Discard))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rt.EnableStats()
+		if err := rt.Run(Optimize(parse(t, src), tt.passes)); err != nil {
+			t.Fatal(err)
+		}
+		s := rt.Stats()
+		var total int64
+		for op, c := range s.Ops {
+			if c != tt.ops[Opcode(op)] {
+				t.Errorf("passes %d: %d %s, want %d", tt.passes, c, opNames[op], tt.ops[Opcode(op)])
+			}
+			total += tt.ops[Opcode(op)]
+		}
+		if s.Total() != total || s.Input != 1 || s.Output != 1 || s.MaxCell != 1 {
+			t.Errorf("passes %d: total %d input %d output %d highest cell %d", tt.passes, s.Total(), s.Input, s.Output, s.MaxCell)
+		}
+		var buf bytes.Buffer
+		if err := s.Write(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(buf.String(), "read 1 bytes, wrote 1, highest cell 1\n") {
+			t.Errorf("passes %d: wrote %q", tt.passes, buf.String())
+		}
+	}
+	rt, err := NewRuntime(WithOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rt.Run(Optimize(parse(t, src), 0)); rt.Stats() != nil {
+		t.Errorf("stats recorded without EnableStats")
+	}
+}
+
+func BenchmarkStats(b *testing.B) {
+	prog := Optimize(parse(b, loopHeavy), 0)
+	for _, enable := range []bool{false, true} {
+		name := "off"
+		if enable {
+			name = "on"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				rt, err := NewRuntime(WithOutput(io.Discard))
+				if err != nil {
+					b.Fatal(err)
+				}
+				if enable {
+					rt.EnableStats()
+				}
+				if err := rt.Run(prog); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package bf
+
+import (
+	"context"
+	"fmt"
+)
+
+// cancelInterval is how many commands run between checks of the
+// context passed to RunContext. It must be a power of two.
+const cancelInterval = 4096
+
+// StepLimitError is returned when a program runs out of steps.
+// Pos is the command that would have gone over the limit and Steps
+// is the number of steps executed before it.
+type StepLimitError struct {
+	Pos Pos
+	Steps int64
+}
+
+func (e *StepLimitError) Error() string {
+	return fmt.Sprintf("step limit reached after %d steps at %+v", e.Steps, e.Pos)
+}
+
+// SetMaxSteps limits how many steps the program may run, or removes
+// the limit if n is zero.
+//
+// Steps approximate the commands the unoptimized program would have
+// executed: an Update or Move counts one step per + - < or > it
+// stands for, each test of a loop condition counts one, a Scan counts
+// one per cell it moves, and Set, MulAdd and Hang count one each.
+// Moves the optimizer folded into offsets are not counted.
+func (rt *Runtime) SetMaxSteps(n int64) error {
+	if n < 0 {
+		return fmt.Errorf("step limit %d must not be negative", n)
+	}
+	rt.maxSteps = n
+	return nil
+}
+
+// RunContext is like Run but stops with ctx's error, wrapped with
+// the position reached, once ctx is done. The context is checked
+// every few thousand commands, and not while , waits for input.
+func (rt *Runtime) RunContext(ctx context.Context, prog Runner) error {
+	rt.ctx = ctx
+	defer func() { rt.ctx = nil }()
+	return rt.Run(prog)
+}
+
+// step charges n steps for the command at at, which becomes the
+// current position, and checks for cancellation, checkpoint and
+// snapshot requests every cancelInterval commands. It is called before a
+// command changes anything, so the state it sees is between
+// instructions.
+func (rt *Runtime) step(n int, at Pos) error {
+	rt.ticks++
+	rt.at = at
+	if rt.ticks & (cancelInterval - 1) == 0 {
+		if rt.ctx != nil {
+			if err := rt.ctx.Err(); err != nil {
+				return fmt.Errorf("%w at %+v", err, at)
+			}
+		}
+		rt.signalCheckpoint()
+		rt.serveSnapshots()
+	}
+	if n < 0 {
+		n = -n
+	}
+	if rt.maxSteps > 0 && rt.steps + int64(n) > rt.maxSteps {
+		return &StepLimitError{at, rt.steps}
+	}
+	rt.steps += int64(n)
+	return nil
+}
@@ -0,0 +1,106 @@
+package bf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStepLimitInfiniteLoop(t *testing.T) {
+	for _, passes := range []Pass{0, AllPasses} {
+		_, rt, err := runProgram(t, "+[]", "", passes, WithMaxSteps(1000))
+		var limit *StepLimitError
+		if !errors.As(err, &limit) {
+			t.Fatalf("passes %d: error %v, want a StepLimitError", passes, err)
+		}
+		if limit.Steps != 1000 || fmt.Sprintf("%+v", limit.Pos) != "1:3" || rt.Steps() != 1000 {
+			t.Errorf("passes %d: stopped at %+v after %d steps, runtime counted %d", passes, limit.Pos, limit.Steps, rt.Steps())
+		}
+	}
+}
+
+func TestStepCounts(t *testing.T) {
+	tests := []struct {
+		src string
+		steps int64
+	}{
+		// each + - < > counts one, coalesced or not
+		{strings.Repeat("+", 255), 255},
+		{">>>>", 4},
+		// one for each test of the loop condition as well as the body
+		{"+++[-]", 3 + 4 + 3},
+		{"+++[>+<-]", 3 + 4 + 3 * 4},
+	}
+	for _, tt := range tests {
+		for _, passes := range []Pass{0, PassCoalesce} {
+			_, rt, err := runProgram(t, tt.src, "", passes)
+			if err != nil || rt.Steps() != tt.steps {
+				t.Errorf("%q passes %d: %d steps with error %v, want %d", tt.src, passes, rt.Steps(), err, tt.steps)
+			}
+			_, _, err = runProgram(t, tt.src, "", passes, WithMaxSteps(uint64(tt.steps - 1)))
+			var limit *StepLimitError
+			if !errors.As(err, &limit) {
+				t.Errorf("%q passes %d: error %v with a limit one short", tt.src, passes, err)
+			}
+		}
+	}
+	// moves that cancel are dropped, so only the net distance counts
+	_, rt, err := runProgram(t, ">>>><<", "", PassCoalesce)
+	if err != nil || rt.Steps() != 2 {
+		t.Errorf(">>>><< coalesced: %d steps with error %v, want 2", rt.Steps(), err)
+	}
+}
+
+func TestRunContextTimeout(t *testing.T) {
+	for _, passes := range []Pass{0, AllPasses} {
+		rt, err := NewRuntime(WithOutput(io.Discard))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50 * time.Millisecond)
+		start := time.Now()
+		err = rt.RunContext(ctx, Optimize(parse(t, "+[>+<]"), passes))
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("passes %d: error %v, want a deadline", passes, err)
+		}
+		if d := time.Since(start); d > 2 * time.Second {
+			t.Errorf("passes %d: took %v to stop", passes, d)
+		}
+		if !strings.HasPrefix(err.Error(), "context deadline exceeded at 1:") {
+			t.Errorf("passes %d: error %q does not say where it stopped", passes, err)
+		}
+	}
+}
+
+func BenchmarkRunContext(b *testing.B) {
+	prog := Optimize(parse(b, loopHeavy), 0)
+	for _, bb := range []struct {
+		name string
+		ctx context.Context
+	}{
+		{"none", nil},
+		{"context", context.Background()},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				rt, err := NewRuntime(WithOutput(io.Discard))
+				if err != nil {
+					b.Fatal(err)
+				}
+				if bb.ctx == nil {
+					err = rt.Run(prog)
+				} else {
+					err = rt.RunContext(bb.ctx, prog)
+				}
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
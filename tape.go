@@ -0,0 +1,181 @@
+package bf
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const (
+	DefaultTapeSize = 30000
+	MaxTapeSize = 1 << 30
+)
+
+// TapeMode selects what happens when the pointer leaves the tape.
+type TapeMode int
+
+const (
+	// TapeFixed makes leaving the tape a runtime error.
+	TapeFixed TapeMode = iota
+	// TapeGrow extends the tape to the right on demand.
+	// Moving left of cell 0 is still an error.
+	TapeGrow
+	// TapeWrap wraps the pointer around modulo the tape length.
+	TapeWrap
+	// TapeInfinite extends the tape in both directions on demand.
+	// Cells left of the start have negative logical indices.
+	TapeInfinite
+)
+
+var tapeModes = map[string]TapeMode{
+	"fixed": TapeFixed,
+	"grow": TapeGrow,
+	"wrap": TapeWrap,
+	"infinite": TapeInfinite,
+}
+
+// SetTapeSize replaces the tape with n zeroed cells.
+func (rt *Runtime) SetTapeSize(n int) error {
+	if n <= 0 || n > MaxTapeSize {
+		return fmt.Errorf("tape size %d must be between 1 and %d", n, MaxTapeSize)
+	}
+	switch {
+	case rt.wide != nil:
+		rt.wide = make([]uint32, n)
+	case rt.bigs != nil:
+		rt.bigs = make([]*big.Int, n)
+	default:
+		rt.store = make([]byte, n)
+	}
+	rt.pos = 0
+	rt.origin = 0
+	return nil
+}
+
+// SetTapeMode selects the named tape mode. maxCells caps how large a
+// growing tape may become.
+func (rt *Runtime) SetTapeMode(name string, maxCells int) error {
+	mode, ok := tapeModes[name]
+	if !ok {
+		return fmt.Errorf("unknown tape mode %q", name)
+	}
+	if maxCells < rt.size() || maxCells > MaxTapeSize {
+		return fmt.Errorf("tape limit %d must be between the tape size %d and %d", maxCells, rt.size(), MaxTapeSize)
+	}
+	rt.mode = mode
+	rt.maxCells = maxCells
+	return nil
+}
+
+// RangeError is returned when the program goes off the end of a tape
+// that doesn't grow to meet it. Index is the position it tried to
+// reach, Size the size of the tape and Pos the command. For a move,
+// Moved is set and From is where it moved from. For a growing tape,
+// Limit is the size it could not grow past.
+type RangeError struct {
+	Pos Pos
+	Index int
+	Size int
+	Moved bool
+	From int
+	Limit int
+}
+
+func (e *RangeError) Error() string {
+	if e.Limit > 0 {
+		return fmt.Sprintf("tape cannot grow past %d cells at %+v", e.Limit, e.Pos)
+	}
+	if e.Moved {
+		return fmt.Sprintf("position %d (moving %+d from %d) is out of range for %d cell tape at %+v", e.Index, e.Index - e.From, e.From, e.Size, e.Pos)
+	}
+	return fmt.Sprintf("position %d is out of range for %d cell tape at %+v", e.Index, e.Size, e.Pos)
+}
+
+func (rt *Runtime) rangeError(i int, at Pos) error {
+	return &RangeError{Pos: at, Index: i, Size: rt.size()}
+}
+
+// moveError reports a move by dir from the current position.
+func (rt *Runtime) moveError(dir int, at Pos) error {
+	return &RangeError{Pos: at, Index: rt.pos + dir, Size: rt.size(), Moved: true, From: rt.pos}
+}
+
+// addr returns the tape index off cells away from the pointer,
+// or an error attributed to pos if that is out of range.
+func (rt *Runtime) addr(off int, pos Pos) (int, error) {
+	i := rt.pos + off
+	if i < 0 || i >= rt.size() {
+		return rt.index(i, pos)
+	}
+	return i, nil
+}
+
+// index resolves a tape index that may lie outside the store,
+// according to the tape mode. Growing an infinite tape to the left
+// shifts every cell, so callers must use the returned index and
+// re-read rt.pos rather than rely on values computed beforehand.
+func (rt *Runtime) index(i int, at Pos) (int, error) {
+	if i >= 0 && i < rt.size() {
+		return i, nil
+	}
+	switch rt.mode {
+	case TapeGrow:
+		if i >= 0 {
+			return i, rt.grow(i + 1, at)
+		}
+	case TapeWrap:
+		n := rt.size()
+		return ((i % n) + n) % n, nil
+	case TapeInfinite:
+		if i >= 0 {
+			return i, rt.grow(i + 1, at)
+		}
+		shift, err := rt.growLeft(-i, at)
+		return i + shift, err
+	}
+	return 0, rt.rangeError(i, at)
+}
+
+// grow extends the store to at least n cells, doubling it to keep
+// repeated growth cheap, but never past the tape or memory limits.
+func (rt *Runtime) grow(n int, at Pos) error {
+	if n > rt.maxCells {
+		return &RangeError{Pos: at, Index: n - 1, Size: rt.size(), Limit: rt.maxCells}
+	}
+	max := rt.memCells(rt.maxCells)
+	if n > max {
+		return &MemoryLimitError{at, rt.size(), rt.maxMem}
+	}
+	size := 2 * rt.size()
+	if size < n {
+		size = n
+	}
+	if size > max {
+		size = max
+	}
+	rt.realloc(0, size)
+	return nil
+}
+
+// growLeft prepends at least n cells to the store, doubling it like
+// grow, and shifts the pointer and origin so that every existing
+// cell keeps its logical index. It returns the number of cells added.
+func (rt *Runtime) growLeft(n int, at Pos) (int, error) {
+	if rt.size() + n > rt.maxCells {
+		return 0, &RangeError{Pos: at, Index: -n, Size: rt.size(), Limit: rt.maxCells}
+	}
+	max := rt.memCells(rt.maxCells)
+	if rt.size() + n > max {
+		return 0, &MemoryLimitError{at, rt.size(), rt.maxMem}
+	}
+	shift := rt.size()
+	if shift < n {
+		shift = n
+	}
+	if rt.size() + shift > max {
+		shift = max - rt.size()
+	}
+	rt.realloc(shift, rt.size() + shift)
+	rt.pos += shift
+	rt.origin += shift
+	return shift, nil
+}
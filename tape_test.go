@@ -0,0 +1,191 @@
+package bf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCoalescedMoveEdges(t *testing.T) {
+	tests := []struct {
+		src string
+		ptr int
+		err string
+	}{
+		{">>>>>>>>>+", 9, ""},
+		{">>>>>><<<<<<+", 0, ""},
+		{">>>>>>>>><<<<<<<<<+", 0, ""},
+		{">>>>>>>>>>", 0, "position 10 (moving +10 from 0) is out of range for 10 cell tape at 1:1"},
+		{">>><<<<", 0, "position -1 (moving -1 from 0) is out of range for 10 cell tape at 1:7"},
+		{"+>>>>>>>>>>", 0, "position 10 (moving +10 from 0) is out of range for 10 cell tape at 1:2"},
+	}
+	for _, tt := range tests {
+		for _, passes := range []Pass{0, PassCoalesce} {
+			_, rt, err := runProgram(t, tt.src, "", passes, WithTapeSize(10))
+			if tt.err != "" {
+				// unmerged moves stop at the first step off the tape
+				if err == nil || passes != 0 && err.Error() != tt.err {
+					t.Errorf("%q passes %d: error %v, want %q", tt.src, passes, err, tt.err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%q passes %d: %v", tt.src, passes, err)
+				continue
+			}
+			if rt.Pointer() != tt.ptr || rt.Cell(tt.ptr).Int64() != 1 {
+				t.Errorf("%q passes %d: pointer at %d with %v, want cell %d set", tt.src, passes, rt.Pointer(), tape(rt, 0, 9), tt.ptr)
+			}
+		}
+	}
+}
+
+func TestCoalesceMoves(t *testing.T) {
+	prog := Optimize(parse(t, ">>><.<<>>"), PassCoalesce)
+	if got, want := treeString(t, prog), ">> at 1:1\n. at 1:5\n"; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	prog = Optimize(parse(t, "><<>"), PassCoalesce)
+	if got := treeString(t, prog); got != "" {
+		t.Errorf("moves that cancel left %q", got)
+	}
+	if !strings.Contains(treeString(t, Optimize(parse(t, "<<<<<<"), PassCoalesce)), "<6 at 1:1") {
+		t.Errorf("<<<<<< did not coalesce")
+	}
+}
+
+func TestTapeSizes(t *testing.T) {
+	src := strings.Repeat(">", 99) + "+"
+	for _, n := range []int{50, 99, 100, 200} {
+		_, rt, err := runProgram(t, src, "", 0, WithTapeSize(n))
+		if n < 100 {
+			want := fmt.Sprintf("position %d (moving +1 from %d) is out of range for %d cell tape at 1:%d", n, n - 1, n, n)
+			if err == nil || err.Error() != want {
+				t.Errorf("tape %d: error %v, want %q", n, err, want)
+			}
+			continue
+		}
+		if err != nil || rt.Cell(99).Int64() != 1 {
+			t.Errorf("tape %d: error %v, tape ends %v", n, err, tape(rt, 98, 99))
+		}
+	}
+	for _, n := range []int{0, -1, MaxTapeSize + 1} {
+		if _, err := NewRuntime(WithTapeSize(n)); err == nil {
+			t.Errorf("tape size %d accepted", n)
+		}
+	}
+}
+
+func TestGrowTape(t *testing.T) {
+	// walk 1000 cells right of a 4 cell tape, leaving a trail of ones,
+	// then come back and print the count of cells passed
+	src := strings.Repeat(">+", 1000) + "[<]>" + strings.Repeat("-", 200) + "."
+	for _, passes := range []Pass{0, AllPasses} {
+		out, rt, err := runProgram(t, src, "", passes, WithTapeSize(4), WithTapeMode("grow", 1 << 20))
+		if err != nil {
+			t.Fatalf("passes %d: %v", passes, err)
+		}
+		if out != "\x39" || rt.size() < 1001 || rt.size() > 2048 {
+			t.Errorf("passes %d: output %q with %d cells", passes, out, rt.size())
+		}
+		if got := strings.Join(tape(rt, 998, 1001), " "); got != "1 1 1 0" {
+			t.Errorf("passes %d: cells 998-1001 are %s", passes, got)
+		}
+	}
+	// a scan runs off the end into cells that do not exist yet
+	sameRun(t, "+>+>+>+>+>+[>]+", "", AllPasses, WithTapeSize(4), WithTapeMode("grow", 64))
+	if _, _, err := runProgram(t, "<", "", 0, WithTapeSize(4), WithTapeMode("grow", 64)); err == nil {
+		t.Errorf("growing tape moved left of cell 0")
+	}
+}
+
+func TestGrowTapeCap(t *testing.T) {
+	tests := []struct {
+		passes Pass
+		err string
+	}{
+		{0, "tape cannot grow past 100 cells at 1:3, in loop at 1:2"},
+		{AllPasses &^ PassFuse, "tape cannot grow past 100 cells at 1:3, in loop at 1:2"},
+		// fusing moves the + at 1:4 ahead of the > and makes it the
+		// first command to touch the missing cell
+		{AllPasses, "tape cannot grow past 100 cells at 1:4, in loop at 1:2"},
+	}
+	for _, tt := range tests {
+		passes := tt.passes
+		_, rt, err := runProgram(t, "+[>+]", "", passes, WithTapeSize(4), WithTapeMode("grow", 100))
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("passes %d: error %v, want %q", passes, err, tt.err)
+		}
+		if rt.size() > 100 {
+			t.Errorf("passes %d: tape grew to %d cells", passes, rt.size())
+		}
+	}
+}
+
+func TestWrapTape(t *testing.T) {
+	tests := []struct {
+		src string
+		ptr int
+	}{
+		{"<+", 9},
+		{">>>>>>>>>>+", 0},
+		{strings.Repeat(">", 25) + "+", 5},
+		{strings.Repeat("<", 23) + "+", 7},
+		{strings.Repeat("<", 100) + "+", 0},
+	}
+	for _, tt := range tests {
+		for _, passes := range []Pass{0, PassCoalesce, AllPasses} {
+			_, rt, err := runProgram(t, tt.src, "", passes, WithTapeSize(10), WithTapeMode("wrap", 10))
+			if err != nil {
+				t.Errorf("%.12q passes %d: %v", tt.src, passes, err)
+				continue
+			}
+			if rt.Pointer() != tt.ptr || rt.Cell(tt.ptr).Int64() != 1 {
+				t.Errorf("%.12q passes %d: pointer at %d with %v, want cell %d set", tt.src, passes, rt.Pointer(), tape(rt, 0, 9), tt.ptr)
+			}
+		}
+	}
+	// scans wrap too, from either side
+	sameRun(t, "+>+>+<<<+<+[>]+", "", AllPasses, WithTapeSize(10), WithTapeMode("wrap", 10))
+	sameRun(t, "+<+<+>>>+>+[<]+", "", AllPasses, WithTapeSize(10), WithTapeMode("wrap", 10))
+}
+
+func TestWrapTrace(t *testing.T) {
+	var trace strings.Builder
+	if _, _, err := runProgram(t, "<+", "", 0, WithTapeSize(10), WithTapeMode("wrap", 10), WithTrace(&trace)); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(trace.String(), "\n")
+	if len(lines) < 2 || !strings.Contains(lines[0], "ptr 9 cell 0") || !strings.Contains(lines[1], "ptr 9 cell 1") {
+		t.Errorf("trace does not show the wrapped pointer:\n%s", trace.String())
+	}
+}
+
+func TestInfiniteTape(t *testing.T) {
+	// write 1 to 5 at cells -1000 to -996, walk back to the start, then
+	// return and print them
+	var src strings.Builder
+	src.WriteString(strings.Repeat("<", 1000))
+	for i := 1; i <= 5; i++ {
+		src.WriteString(strings.Repeat("+", i) + ">")
+	}
+	src.WriteString(strings.Repeat(">", 995) + "+" + strings.Repeat("<", 1000) + ".>.>.>.>.")
+	for _, passes := range []Pass{0, AllPasses} {
+		out, rt, err := runProgram(t, src.String(), "", passes, WithTapeSize(16), WithTapeMode("infinite", 1 << 20))
+		if err != nil {
+			t.Fatalf("passes %d: %v", passes, err)
+		}
+		if out != "\x01\x02\x03\x04\x05" {
+			t.Errorf("passes %d: output %q", passes, out)
+		}
+		if got := strings.Join(tape(rt, -1000, -995), " "); got != "1 2 3 4 5 0" {
+			t.Errorf("passes %d: cells -1000 to -995 are %s", passes, got)
+		}
+		if rt.Cell(0).Int64() != 1 || rt.Pointer() != -996 {
+			t.Errorf("passes %d: cell 0 is %v with pointer at %d", passes, rt.Cell(0), rt.Pointer())
+		}
+		if rt.size() < 1016 || rt.size() > 4096 {
+			t.Errorf("passes %d: %d cells allocated for a 1016 cell span", passes, rt.size())
+		}
+	}
+}
@@ -0,0 +1,4 @@
+count runs three nested loops of a hundred whose innermost body
+holds a loop of its own so the optimizer cannot fold it away
+
+++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++[>++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++[>++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++[>>+[<+>-]<<-]<-]<-]
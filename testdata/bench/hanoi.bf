@@ -0,0 +1,29 @@
+hanoi prints the moves that solve the towers of hanoi for twelve disks
+keeping each call of the recursion as a frame on the tape
+
+>>>>>>>>>>>>>>>>[-]+>>>[-]++++++++++++>[-]+>[-]++>[-]+++>>>>>>>>>>>[-]+>
+>>>>>>>>>>>>>>>[-]+>>>>>>>>>>>>>>>>[-]+>>>>>>>>>>>>>>>>[-]+>>>>>>>>>>>>>
+>>>[-]+>>>>>>>>>>>>>>>>[-]+>>>>>>>>>>>>>>>>[-]+>>>>>>>>>>>>>>>>[-]+>>>>>
+>>>>>>>>>>>[-]+>>>>>>>>>>>>>>>>[-]+>>>>>>>>>>>>>>>>[-]+>>>>>>>>>>>>>>>>[
+-]+>>>>>>>>>>>>>>>>[-]+>>>>>>>>>>>>>>>>[-]+<<<<<<<<<<<<<<<<<<<<<<<<<<<<<
+<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<
+<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<
+<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<[>>[->>>>>+>+<<<<<<]
+>>>>>>[-<<<<<<+>>>>>>]>>>>>[-]+<<<<<<[>>>>>>[-]<<<<<<->>>>>>>+<<<<<<<[>>
+>>>>>[-]>+<<<<<<<<[-]][-]]>>>>>>[<<<<[-]+<<<<<<[->>>>+>+<<<<<]>>>>>[-<<<
+<<+>>>>>]<[>>[-]<<<<<<<[-]+>>>>>>>>>>>>>>+>-<<<<<<<<<<<<<<[->>>>>>>>>>>>
+>>>>+<<<<<<<<<<<+<<<<<]>>>>>[-<<<<<+>>>>>]>>>>>>>>>>>-<<<<<<<<<<<<<<<[->
+>>>>>>>>>>>>>>>+<<<<<<<<<<<<+<<<<]>>>>[-<<<<+>>>>]<<[->>>>>>>>>>>>>>>+<<
+<<<<<<<<<<<+<<]>>[-<<+>>]<<<[->>>>>>>>>>>>>>>>>+<<<<<<<<<<<<<<+<<<]>>>[-
+<<<+>>>]<[-]]>>[>>>+<<<[-]]>>>>[-]]>[<<<<<<<<<<[->>>+>+<<<<]>>>>[-<<<<+>
+>>>]<++++++++++++++++++++++++++++++++++++++++++++++++.[-]>>>[-]+++++++++
++++++++++++++++++++++++.[-]+++++++++++++++++++++++++++++++++++++++++++++
+.[-]++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++.[-]++
+++++++++++++++++++++++++++++++.[-]<<<<[->+>+<<]>>[-<<+>>]<++++++++++++++
+++++++++++++++++++++++++++++++++++.[-]>>>[-]++++++++++.[-]<<<<<<<<[-]++>
+>>>>>>>>>>>>>+>-<<<<<<<<<<<<<<[->>>>>>>>>>>>>>>>+<<<<<<<<<<<+<<<<<]>>>>>
+[-<<<<<+>>>>>]>>>>>>>>>>>-<<<<<<<<<<<<<<[->>>>>>>>>>>>>>>+<<<<<<<<<<<<+<
+<<]>>>[-<<<+>>>]<<<<[->>>>>>>>>>>>>>>>>+<<<<<<<<<<<<<+<<<<]>>>>[-<<<<+>>
+>>]<<[->>>>>>>>>>>>>>>>+<<<<<<<<<<<<<<+<<]>>[-<<+>>]>>>>>>[-]]>[<<<+>>>[
+-]]<<<[<<<<<<<<<<[-]>[-]>[-]>[-]>[-]<<<<<<[-]>+>>>>>>>>>>>[-]]>>>>>>>>>>
+>>>>>>>>>>>[<<<<<<<<<<<<<<<<]<]
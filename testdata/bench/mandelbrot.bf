@@ -0,0 +1,68 @@
+mandelbrot draws the mandelbrot set as a 48 by 21 grid of characters
+in fixed point sixteenths on eight bit cells with sixteen iterations
+a digit counts the iterations before a point escapes and a hash marks
+a point that never does
+
+[-]+++++++++++++++++++++>>>[-]--------------------<<<[>[-]++++++++++++++
+++++++++++++++++++++++++++++++++++>[-]----------------------------------
+--<[>>>[-]>[-]>[-]++++++++++++++++>[-]+[<<<[->>>>>>>>>>>>>>>>>>>>>>>>>>+
+<<<<<<<<<<<<+<<<<<<<<<<<<<<]>>>>>>>>>>>>>>[-<<<<<<<<<<<<<<+>>>>>>>>>>>>>
+>]>>>>>>>>>>>>>[-]++++++++++++++++++++++++++++++++++++++++++++++++++++++
+++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+++<[->-[>+>>]>[+[-<+>]>+>>]<<<<<]>>>[-<<<<<<<<<<<<<<<<<<<<<<<<+>>>>>>>>>
+>>>>>>>>>>>>>>>]<<<<<<<<<<<<<<<<<<<<<<<<[->>>>>>>>+>+<<<<<<<<<]>>>>>>>>>
+[-<<<<<<<<<+>>>>>>>>>]<[<<<<<<<<<+++++++++++++++++++++++++++++++++++++++
+++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
++++++++++++++++++>>>>>>>>>>>>>>>>>>>>>>>>[-<<<<<<<<<<<<<<<<<<<<<<<<->>>>
+>>>>>>>>>>>>>>>>>>>>]<<<<<<<<<<<<<<<[-]]>>>>>>>>>>>>>>>[-<<<<<<<<<<<<<<<
+<<<<<<<<<+>>>>>>>>>>>>>>>>>>>>>>>>]<[-]>[-]<<<<<<<<<<<<<<<<<<<<<<<<<<<[-
+>>>>>>>>>>>>>>>>>>>>>>>>>+<<<<<<<<<<<<+<<<<<<<<<<<<<]>>>>>>>>>>>>>[-<<<<
+<<<<<<<<<+>>>>>>>>>>>>>]>>>>>>>>>>>>>[-]++++++++++++++++++++++++++++++++
+++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+++++++++++++++++++++++++<[->-[>+>>]>[+[-<+>]>+>>]<<<<<]>>>[-<<<<<<<<<<<<
+<<<<<<<<<<+>>>>>>>>>>>>>>>>>>>>>>]<<<<<<<<<<<<<<<<<<<<<<[->>>>>>+>+<<<<<
+<<]>>>>>>>[-<<<<<<<+>>>>>>>]<[<<<<<<<+++++++++++++++++++++++++++++++++++
+++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
++++++++++++++++++++++>>>>>>>>>>>>>>>>>>>>>>[-<<<<<<<<<<<<<<<<<<<<<<->>>>
+>>>>>>>>>>>>>>>>>>]<<<<<<<<<<<<<<<[-]]>>>>>>>>>>>>>>>[-<<<<<<<<<<<<<<<<<
+<<<<<+>>>>>>>>>>>>>>>>>>>>>>]<[-]>[-]<<<<<<<<<<<<<<<<<<<<<<<<[->>>>>>>>>
+>>>>>>>>>>>>>+<<<<<<<<<<<<+<<<<<<<<<<]>>>>>>>>>>[-<<<<<<<<<<+>>>>>>>>>>]
+>>>>>>>>>>>>>[-]+++++++++++++++++++++++++++++++++<[->-[>+>>]>[+[-<+>]>+>
+>]<<<<<]>>>[-<<<<<<<<<<<<<<<<<<+>>>>>>>>>>>>>>>>>>]<<[-]>[-]<<<<<<<<<<<<
+<<<<<<<<<<[->>>>>>>>>>>>>>>>>>>>+<<<<<<<<<<<<+<<<<<<<<]>>>>>>>>[-<<<<<<<
+<+>>>>>>>>]>>>>>>>>>>>>>[-]+++++++++++++++++++++++++++++++++<[->-[>+>>]>
+[+[-<+>]>+>>]<<<<<]>>>[-<<<<<<<<<<<<<<<<<<+>>>>>>>>>>>>>>>>>>]<<[-]>[-]<
+<<<<<<<<<<<<<<<[-]+<[>[-]<<<<<<<<<[-]>>>>>>>>[-]]>[>>>>>>>>>>>>>>>[-]+++
++++++++++++++<<<<<<<<<<<<<<<<<<<<<<<[->>>>>>>>>>>+<+<<<<<<<<<<]>>>>>>>>>
+>[-<<<<<<<<<<+>>>>>>>>>>]>[-<<<<<<<<<<<[->>>>>>>>>>>>+<<+<<<<<<<<<<]>>>>
+>>>>>>[-<<<<<<<<<<+>>>>>>>>>>]>>[->>>>>>>>>>+[->-[>+>>]>[+[-<+>]>+>>]<<<
+<<]<<<<<<<<<<]<]>>>>>>>>>>>>>>[-<<<<<<<<<<<<<<<<<<<<<+>>>>>>>>>>>>>>>>>>
+>>>]<<[-]>[-]<[-]++++++++++++++++<<<<<<<<<<<<<<<<<<<<<[->>>>>>>>>+<+<<<<
+<<<<]>>>>>>>>[-<<<<<<<<+>>>>>>>>]>[-<<<<<<<<<[->>>>>>>>>>+<<+<<<<<<<<]>>
+>>>>>>[-<<<<<<<<+>>>>>>>>]>>[->>>>>>>>>>+[->-[>+>>]>[+[-<+>]>+>>]<<<<<]<
+<<<<<<<<<]<]>>>>>>>>>>>>>>[-<<<<<<<<<<<<<<<<<<<<+>>>>>>>>>>>>>>>>>>>>]<<
+[-]>[-]<<<<<<<<<<<<<<<<<<<<[->>>>>>>>>>>>>>>>>>+<<<<<<<<<<<<+<<<<<<]>>>>
+>>[-<<<<<<+>>>>>>]<<<<<[->>>>>>>>>>>>>>>>>+<<<<<<<<<<<<+<<<<<]>>>>>[-<<<
+<<+>>>>>]>>>>>>>>>>>>>[-]+++++++++++++++++++++++++++++++++++++++++++++++
+++++++++++++++++++<[->-[>+>>]>[+[-<+>]>+>>]<<<<<]>>>[-<<<<<<<<<<<<<<<<<<
++>>>>>>>>>>>>>>>>>>]<<[-]>[-]<<<<<<<<<<<<<<<<[-]+<[>[-]<<<<<<<<<[-]>>>>>
+>>>[-]]>[>>>>>>>>>>>>>>>[-]++++++++++++++++<<<<<<<<<<<<<<<<<<<<<<<[->>>>
+>>>>>>>+<+<<<<<<<<<<]>>>>>>>>>>[-<<<<<<<<<<+>>>>>>>>>>]>[-<<<<<<<<<[->>>
+>>>>>>>+<<+<<<<<<<<]>>>>>>>>[-<<<<<<<<+>>>>>>>>]>>[->>>>>>>>>>+[->-[>+>>
+]>[+[-<+>]>+>>]<<<<<]<<<<<<<<<<]<]>>>>>>>>>>>>>>[-<<<<<<<<<<<<<<<<<<<+>>
+>>>>>>>>>>>>>>>>>]<<[-]>[-]<<<<<<<<<<<<<<<<<<<<<<<<<<<<[-]>>>>>>>>[-<<<<
+<<<<+>>>>>>>>]>[-<<<<<<<<<->>>>>>>>>]<<<<<<<<<<<[->>+>>>>>>>>>>>>>>+<<<<
+<<<<<<<<<<<<]>>>>>>>>>>>>>>>>[-<<<<<<<<<<<<<<<<+>>>>>>>>>>>>>>>>]<<<<<<<
+<<[->>>>>>>>+>+<<<<<<<<<]>>>>>>>>>[-<<<<<<<<<+>>>>>>>>>]<[<<<[->>>>-<<<<
+]>>>>[-<<<<+>>>>]<[-]]<<<<<<[->>>>>>+>+<<<<<<<]>>>>>>>[-<<<<<<<+>>>>>>>]
+<[<<<[->>>>-<<<<]>>>>[-<<<<+>>>>]<[-]]<<<<<<<<<<<<[-]>>>>>>>>>[-<<<<<<<<
+<++>>>>>>>>>]<<<<<<<<<<<[->>+>>>>>>>>>>>>>+<<<<<<<<<<<<<<<]>>>>>>>>>>>>>
+>>[-<<<<<<<<<<<<<<<+>>>>>>>>>>>>>>>]<<<<<<<<<<<<->>>>>>>>>>>>>[-]+<<<<<<
+<<<<<<<[->>>>>>>>>>>+>+<<<<<<<<<<<<]>>>>>>>>>>>>[-<<<<<<<<<<<<+>>>>>>>>>
+>>>]<[>>[-]<<[-]]>>[<<<<<<<<<<<<[-]>>>>>>>>>>>>[-]]<<<[-]]<<<<[-]>[-]>>>
+[-]]<<<<<<<<[-]>[-]>[-]>[-]<<<<]>>>>>>>>>>>>[-]+<<<<<<<<<<<<<[->>>>>>>>>
+>>+>+<<<<<<<<<<<<]>>>>>>>>>>>>[-<<<<<<<<<<<<+>>>>>>>>>>>>]<[>>[-]>[-]+++
++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++<<<<<<<<<<<
+<<<[->>>>>>>>>>>>>>-<<<<<<<<<<<<<<]>>>>>>>>>>>>>>.[-]<<<[-]]>>[>[-]+++++
+++++++++++++++++++++++++++++++.[-]<[-]]<<<<<<<<<<<<<[-]<<<<+<-]>>>>>>>>>
+>>>>>>>>>>[-]++++++++++.[-]<<<<<<<<<<<<<<<<<++<<<-]
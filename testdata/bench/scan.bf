@@ -0,0 +1,5 @@
+scan lays a trail of five thousand ones from cell 3 then runs the
+pointer from one end of it to the other and back fifty times
+
+++++++++++++++++++++++++++++++++++++++++++++++++++[>++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++[>>[>]+[<]<-]<-]
+++++++++++++++++++++++++++++++++++++++++++++++++++[>>>[>]<[<]<<-]
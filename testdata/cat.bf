@@ -0,0 +1,2 @@
+cat copies its input to its output and stops at the end of input
+,+[-.,+]
@@ -0,0 +1,2 @@
+error moves off the left end of the tape
++[<+]
@@ -0,0 +1,7 @@
+nested has six loops each doubling the one inside it to leave 64 in
+cell 6 which plus one is A
+
+++[>++[>++[>++[>++[>++[>+<-]<-]<-]<-]<-]<-]
+>>>>>>+.
+[[-]>[-]<]
+++++++++++.
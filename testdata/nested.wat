@@ -0,0 +1,116 @@
+;; Generated by bf -emit=wat.
+;; The host supplies getchar (returning a byte, or -1 at EOF), putchar,
+;; and fail, which is called with the offending cell index and the
+;; line and column of the command before the module traps.
+(module
+  (import "env" "getchar" (func $getchar (result i32)))
+  (import "env" "putchar" (func $putchar (param i32)))
+  (import "env" "fail" (func $fail (param i32 i32 i32)))
+  (memory (export "memory") 1)
+  (global $p (mut i32) (i32.const 0))
+
+  ;; at returns the address of the cell off cells from the pointer.
+  (func $at (param $off i32) (param $line i32) (param $col i32) (result i32)
+    (local $i i32)
+    (local.set $i (i32.add (global.get $p) (local.get $off)))
+    (if (i32.ge_u (local.get $i) (i32.const 30000))
+      (then
+        (call $fail (local.get $i) (local.get $line) (local.get $col))
+        (unreachable)))
+    (local.get $i))
+
+  (func (export "run")
+    (local $a i32)
+    (local $v i32)
+    (local.set $a (global.get $p)) ;; 4:1
+    (i32.store8 (local.get $a) (i32.add (i32.load8_u (local.get $a)) (i32.const 2)))
+    ;; [ at 4:3
+    (block $b1
+      (loop $l1
+        (br_if $b1 (i32.eqz (i32.load8_u (global.get $p))))
+        (local.set $a (call $at (i32.const 1) (i32.const 4) (i32.const 5))) ;; 4:5
+        (i32.store8 (local.get $a) (i32.add (i32.load8_u (local.get $a)) (i32.const 2)))
+        (global.set $p (call $at (i32.const 1) (i32.const 4) (i32.const 4))) ;; 4:4
+        ;; [ at 4:7
+        (block $b4
+          (loop $l4
+            (br_if $b4 (i32.eqz (i32.load8_u (global.get $p))))
+            (local.set $a (call $at (i32.const 1) (i32.const 4) (i32.const 9))) ;; 4:9
+            (i32.store8 (local.get $a) (i32.add (i32.load8_u (local.get $a)) (i32.const 2)))
+            (global.set $p (call $at (i32.const 1) (i32.const 4) (i32.const 8))) ;; 4:8
+            ;; [ at 4:11
+            (block $b7
+              (loop $l7
+                (br_if $b7 (i32.eqz (i32.load8_u (global.get $p))))
+                (local.set $a (call $at (i32.const 1) (i32.const 4) (i32.const 13))) ;; 4:13
+                (i32.store8 (local.get $a) (i32.add (i32.load8_u (local.get $a)) (i32.const 2)))
+                (global.set $p (call $at (i32.const 1) (i32.const 4) (i32.const 12))) ;; 4:12
+                ;; [ at 4:15
+                (block $b10
+                  (loop $l10
+                    (br_if $b10 (i32.eqz (i32.load8_u (global.get $p))))
+                    (local.set $a (call $at (i32.const 1) (i32.const 4) (i32.const 17))) ;; 4:17
+                    (i32.store8 (local.get $a) (i32.add (i32.load8_u (local.get $a)) (i32.const 2)))
+                    (global.set $p (call $at (i32.const 1) (i32.const 4) (i32.const 16))) ;; 4:16
+                    ;; [ at 4:19
+                    (block $b13
+                      (loop $l13
+                        (br_if $b13 (i32.eqz (i32.load8_u (global.get $p))))
+                        (local.set $a (call $at (i32.const 1) (i32.const 4) (i32.const 21))) ;; 4:21
+                        (i32.store8 (local.get $a) (i32.add (i32.load8_u (local.get $a)) (i32.const 2)))
+                        (global.set $p (call $at (i32.const 1) (i32.const 4) (i32.const 20))) ;; 4:20
+                        (local.set $v (i32.load8_u (global.get $p))) ;; 4:23
+                        (if (local.get $v)
+                          (then
+                            (drop (call $at (i32.const 1) (i32.const 4) (i32.const 23)))
+                            (local.set $a (i32.add (global.get $p) (i32.const 1)))
+                            (i32.store8 (local.get $a) (i32.add (i32.load8_u (local.get $a)) (i32.mul (local.get $v) (i32.const 1))))
+                            (i32.store8 (global.get $p) (i32.const 0))))
+                        (local.set $a (call $at (i32.const -1) (i32.const 4) (i32.const 30))) ;; 4:30
+                        (i32.store8 (local.get $a) (i32.add (i32.load8_u (local.get $a)) (i32.const 255)))
+                        (global.set $p (call $at (i32.const -1) (i32.const 4) (i32.const 29))) ;; 4:29
+                        (br $l13)
+                      )
+                    )
+                    (local.set $a (call $at (i32.const -1) (i32.const 4) (i32.const 33))) ;; 4:33
+                    (i32.store8 (local.get $a) (i32.add (i32.load8_u (local.get $a)) (i32.const 255)))
+                    (global.set $p (call $at (i32.const -1) (i32.const 4) (i32.const 32))) ;; 4:32
+                    (br $l10)
+                  )
+                )
+                (local.set $a (call $at (i32.const -1) (i32.const 4) (i32.const 36))) ;; 4:36
+                (i32.store8 (local.get $a) (i32.add (i32.load8_u (local.get $a)) (i32.const 255)))
+                (global.set $p (call $at (i32.const -1) (i32.const 4) (i32.const 35))) ;; 4:35
+                (br $l7)
+              )
+            )
+            (local.set $a (call $at (i32.const -1) (i32.const 4) (i32.const 39))) ;; 4:39
+            (i32.store8 (local.get $a) (i32.add (i32.load8_u (local.get $a)) (i32.const 255)))
+            (global.set $p (call $at (i32.const -1) (i32.const 4) (i32.const 38))) ;; 4:38
+            (br $l4)
+          )
+        )
+        (local.set $a (call $at (i32.const -1) (i32.const 4) (i32.const 42))) ;; 4:42
+        (i32.store8 (local.get $a) (i32.add (i32.load8_u (local.get $a)) (i32.const 255)))
+        (global.set $p (call $at (i32.const -1) (i32.const 4) (i32.const 41))) ;; 4:41
+        (br $l1)
+      )
+    )
+    (local.set $a (call $at (i32.const 6) (i32.const 5) (i32.const 7))) ;; 5:7
+    (i32.store8 (local.get $a) (i32.add (i32.load8_u (local.get $a)) (i32.const 1)))
+    (call $putchar (i32.load8_u (call $at (i32.const 6) (i32.const 5) (i32.const 8)))) ;; 5:8
+    (global.set $p (call $at (i32.const 6) (i32.const 5) (i32.const 1))) ;; 5:1
+    ;; [ at 6:1
+    (block $b35
+      (loop $l35
+        (br_if $b35 (i32.eqz (i32.load8_u (global.get $p))))
+        (i32.store8 (global.get $p) (i32.const 0)) ;; 6:2
+        (i32.store8 (call $at (i32.const 1) (i32.const 6) (i32.const 6)) (i32.const 0)) ;; 6:6
+        (br $l35)
+      )
+    )
+    (local.set $a (global.get $p)) ;; 7:1
+    (i32.store8 (local.get $a) (i32.add (i32.load8_u (local.get $a)) (i32.const 10)))
+    (call $putchar (i32.load8_u (global.get $p))) ;; 7:11
+  )
+)
@@ -0,0 +1,7 @@
+calls defines procedure 1 to print the next cell and add one to it
+then calls it three times on A and ends the line
+
++(>.+<)
+>>++++++++[<++++++++>-]<+<
+:::
+>>++++++++++.
@@ -0,0 +1,12 @@
+pbrain sample where procedure 1 prints the digit in the cell to the right
+of the pointer then calls itself with one less until it reaches zero
+
++(
+  >[
+    [->+>+<<]>>[-<<+>>]<
+    ++++++++++++++++++++++++++++++++++++++++++++++++.[-]
+    <-<:>
+  ]<
+)
+>+++++<:
+>>++++++++++.
@@ -0,0 +1,8 @@
+recurse counts cell 0 down from five by having procedure 1 call
+itself while cell 0 is not zero and print a star each time
+
++++++>+
+(<[->>.<:<]>)
+>>++++++[<+++++++>-]<<
+:
+>>++++++++++.
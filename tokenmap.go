@@ -0,0 +1,141 @@
+package bf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// mapOrder is the order of the commands in a TokenMap file that lists
+// only the tokens.
+const mapOrder = "><+-.,[]"
+
+// TokenMap spells the eight commands as other strings, as the many
+// languages that just rename them do. A program using it is read by
+// longest match against the tokens, and everything else is comment.
+type TokenMap struct {
+	tokens [len(mapOrder)]string // by the command's index in mapOrder
+	longest int
+}
+
+// ParseTokenMap reads a TokenMap from r, which gives the tokens for
+// > < + - . , [ and ] one per line in that order, or as lines such as
+// +=Ook. with the command before the = in any order. Blank lines are
+// ignored, and space around a token is trimmed. A token that is
+// empty, given twice, or found within another is an error, since the
+// program could not be read unambiguously.
+func ParseTokenMap(r io.Reader) (*TokenMap, error) {
+	m := &TokenMap{}
+	lines := make([]int, len(mapOrder)) // of each token, by command
+	sc := bufio.NewScanner(r)
+	lno, n := 0, 0
+	keyed := false
+	for sc.Scan() {
+		lno++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		if n == 0 {
+			keyed = len(line) >= 2 && line[1] == '=' && strings.IndexByte(mapOrder, line[0]) >= 0
+		}
+		i := n
+		if keyed {
+			if len(line) < 2 || line[1] != '=' || strings.IndexByte(mapOrder, line[0]) < 0 {
+				return nil, fmt.Errorf("line %d: want a command, =, and its token", lno)
+			}
+			i = strings.IndexByte(mapOrder, line[0])
+			line = strings.TrimSpace(line[2:])
+			if lines[i] != 0 {
+				return nil, fmt.Errorf("line %d: %c was given on line %d", lno, mapOrder[i], lines[i])
+			}
+		} else if n >= len(mapOrder) {
+			return nil, fmt.Errorf("line %d: more than %d tokens", lno, len(mapOrder))
+		}
+		if line == "" {
+			return nil, fmt.Errorf("line %d: empty token for %c", lno, mapOrder[i])
+		}
+		m.tokens[i] = line
+		lines[i] = lno
+		n++
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	for i, t := range m.tokens {
+		if t == "" {
+			return nil, fmt.Errorf("no token for %c", mapOrder[i])
+		}
+		if len(t) > m.longest {
+			m.longest = len(t)
+		}
+		for j, u := range m.tokens {
+			switch {
+			case j <= i || u == "":
+			case u == t:
+				return nil, fmt.Errorf("line %d: token %q for %c is also the token for %c", lines[j], t, mapOrder[i], mapOrder[j])
+			case strings.Contains(u, t) || strings.Contains(t, u):
+				return nil, fmt.Errorf("line %d: token %q for %c overlaps %q for %c on line %d", lines[j], u, mapOrder[j], t, mapOrder[i], lines[i])
+			}
+		}
+	}
+	return m, nil
+}
+
+// LoadTokenMap reads a TokenMap from the file fn.
+func LoadTokenMap(fn string) (*TokenMap, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseTokenMap(f)
+}
+
+// Token returns the token for the command ch, or "" if ch is not one
+// of the eight.
+func (m *TokenMap) Token(ch rune) string {
+	i := strings.IndexRune(mapOrder, ch)
+	if i < 0 {
+		return ""
+	}
+	return m.tokens[i]
+}
+
+// nextMapped is next for a Parser with a Map. It returns the command
+// for the token at the next byte, or skips the byte as comment if
+// none matches, leaving p.tok at the token's first byte. As no token
+// is within another, at most one can match, which is the longest.
+func (p *Parser) nextMapped() rune {
+	for !p.stop {
+		ahead := p.peek(p.Map.longest)
+		if len(ahead) == 0 {
+			// let advance record a failed read
+			p.advance()
+			return 0
+		}
+		best := -1
+		for i, t := range p.Map.tokens {
+			if strings.HasPrefix(string(ahead), t) {
+				best = i
+				break
+			}
+		}
+		if best < 0 {
+			if b, _ := p.advance(); p.Bang && b == '!' && p.nesting == 0 {
+				p.banged = true
+				return 0
+			}
+			continue
+		}
+		p.advance()
+		p.tok = p.pos
+		for k := 1; k < len(p.Map.tokens[best]); k++ {
+			p.advance()
+		}
+		return rune(mapOrder[best])
+	}
+	return 0
+}
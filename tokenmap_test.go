@@ -0,0 +1,78 @@
+package bf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// wordMap spells the commands as words, some longer than others.
+const wordMap = "+=up\n-=down\n>=right\n<=left\n.=say\n,=ask\n[=loop\n]=pool\n"
+
+// words returns wordMap as a TokenMap.
+func words(t *testing.T) *TokenMap {
+	t.Helper()
+	m, err := ParseTokenMap(strings.NewReader(wordMap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// mapParse parses src with the words map.
+func mapParse(t *testing.T, src string) (Runner, error) {
+	t.Helper()
+	return (&Parser{Map: words(t)}).ParseBytes([]byte(src))
+}
+
+func TestTokenMapRun(t *testing.T) {
+	m := words(t)
+	var tokens []string
+	for _, ch := range corpus(t, "hello.bf")[0].src {
+		if tok := m.Token(ch); tok != "" {
+			tokens = append(tokens, tok)
+		}
+	}
+	// anything that is not a token is comment, even between tokens
+	prog, err := mapParse(t, "hello, said in words\n" + strings.Join(tokens, " ~ "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := New(nil, &out).Run(Optimize(prog, AllPasses)); err != nil || out.String() != "Hello World!\n" {
+		t.Errorf("output %q, error %v", out.String(), err)
+	}
+}
+
+func TestTokenMapPositions(t *testing.T) {
+	prog, err := mapParse(t, "upup\n  loop downpool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := treeString(t, prog), "+ at 1:1\n+ at 1:3\n[ at 2:3\n  - at 2:8\n] at 2:12\n"; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if _, err := mapParse(t, "up\nup pool"); err == nil || err.Error() != "2:4: unexpected close bracket" {
+		t.Errorf("error %v", err)
+	}
+}
+
+func TestParseTokenMapErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		err string
+	}{
+		{"a\nb\nc\nd\ne\nf\ng\n", "no token for ]"},
+		{"a\nb\nc\nd\ne\nf\ng\nh\ni\n", "line 9: more than 8 tokens"},
+		{"a\nb\nc\nd\ne\nf\ng\na\n", "line 8: token \"a\" for > is also the token for ]"},
+		{"a\nb\nc\nd\ne\nf\ng\nxax\n", "line 8: token \"xax\" for ] overlaps \"a\" for > on line 1"},
+		{"+=up\n+=down\n", "line 2: + was given on line 1"},
+		{"+=up\n-= \n", "line 2: empty token for -"},
+		{"+=up\ndown\n", "line 2: want a command, =, and its token"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseTokenMap(strings.NewReader(tt.src)); err == nil || err.Error() != tt.err {
+			t.Errorf("%q: error %v, want %s", tt.src, err, tt.err)
+		}
+	}
+}
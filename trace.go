@@ -0,0 +1,89 @@
+package bf
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// TraceFormat selects how trace lines are written.
+type TraceFormat int
+
+const (
+	// TraceText writes a readable line per command.
+	TraceText TraceFormat = iota
+	// TraceJSON writes a JSON object per command, one per line, as in
+	// {"op":"+","line":3,"col":7,"ptr":12,"cell":41,"step":1234}.
+	TraceJSON
+)
+
+var traceFormats = map[string]TraceFormat{
+	"text": TraceText,
+	"json": TraceJSON,
+}
+
+// SetTrace makes the tree interpreter write a line to w for each
+// command it runs, or turns tracing off if w is nil.
+func (rt *Runtime) SetTrace(w io.Writer) {
+	rt.trace = w != nil
+	rt.traceOut = w
+}
+
+// SetTraceFormat selects the named trace format.
+func (rt *Runtime) SetTraceFormat(name string) error {
+	format, ok := traceFormats[name]
+	if !ok {
+		return fmt.Errorf("unknown trace format %q", name)
+	}
+	rt.traceFormat = format
+	return nil
+}
+
+// SetTraceLimit stops tracing after n lines, or never if n is zero.
+// The program keeps running.
+func (rt *Runtime) SetTraceLimit(n int64) {
+	rt.traceLimit = n
+}
+
+// traceRun writes the trace line for r, which has just run (or for a
+// Loop, just tested its condition): the command, its position, and
+// the pointer and current cell afterwards. Program output is flushed
+// first so the two stay in order when they share a terminal.
+func (rt *Runtime) traceRun(r Runner, at Pos) {
+	if rt.traceLimit > 0 && rt.traced >= rt.traceLimit {
+		return
+	}
+	rt.traced++
+	rt.flush()
+	in, _ := instruction(r)
+	if rt.traceFormat == TraceJSON {
+		rt.traceJSON(in.String(), at)
+		return
+	}
+	fmt.Fprintf(rt.traceOut, "%-10s %-8s ptr %d cell %s\n", in.String(), lineCol(at), rt.pos - rt.origin, rt.cellString(rt.pos))
+}
+
+// traceJSON writes a JSON trace line, reusing one buffer rather than
+// marshalling, since traces can run to millions of lines.
+func (rt *Runtime) traceJSON(op string, at Pos) {
+	b := rt.traceBuf[:0]
+	b = append(b, `{"op":`...)
+	b = strconv.AppendQuote(b, op)
+	b = append(b, `,"line":`...)
+	b = strconv.AppendInt(b, int64(at.lno), 10)
+	b = append(b, `,"col":`...)
+	b = strconv.AppendInt(b, int64(at.linepos), 10)
+	b = append(b, `,"ptr":`...)
+	b = strconv.AppendInt(b, int64(rt.pos - rt.origin), 10)
+	b = append(b, `,"cell":`...)
+	if rt.bigs != nil {
+		b = rt.big(rt.pos).Append(b, 10)
+	} else {
+		b = strconv.AppendUint(b, uint64(rt.get(rt.pos)), 10)
+	}
+	b = append(b, `,"step":`...)
+	b = strconv.AppendInt(b, rt.steps, 10)
+	b = append(b, "}\n"...)
+	rt.traceOut.Write(b)
+	rt.traceBuf = b
+}
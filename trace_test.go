@@ -0,0 +1,83 @@
+package bf
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	var trace, out strings.Builder
+	rt, err := NewRuntime(WithOutput(&out), WithTrace(&trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Run(parse(t, "+[>+<-]>.")); err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"+          1:1      ptr 0 cell 1\n" +
+		"[          1:2      ptr 0 cell 1\n" +
+		">          1:3      ptr 1 cell 0\n" +
+		"+          1:4      ptr 1 cell 1\n" +
+		"<          1:5      ptr 0 cell 1\n" +
+		"-          1:6      ptr 0 cell 0\n" +
+		"[          1:2      ptr 0 cell 0\n" +
+		">          1:8      ptr 1 cell 1\n" +
+		".          1:9      ptr 1 cell 1\n"
+	if got := trace.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if out.String() != "\x01" {
+		t.Errorf("trace changed the output to %q", out.String())
+	}
+}
+
+// traceEvent is a line of a JSON trace.
+type traceEvent struct {
+	Op string `json:"op"`
+	Line int `json:"line"`
+	Col int `json:"col"`
+	Ptr int `json:"ptr"`
+	Cell int `json:"cell"`
+	Step int64 `json:"step"`
+}
+
+func TestTraceJSON(t *testing.T) {
+	var trace strings.Builder
+	_, _, err := runProgram(t, "+[>+<-]>.", "", 0, WithTrace(&trace), func(rt *Runtime) error {
+		rt.SetTraceLimit(8)
+		return rt.SetTraceFormat("json")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []traceEvent{
+		{"+", 1, 1, 0, 1, 1},
+		{"[", 1, 2, 0, 1, 2},
+		{">", 1, 3, 1, 0, 3},
+		{"+", 1, 4, 1, 1, 4},
+		{"<", 1, 5, 0, 1, 5},
+		{"-", 1, 6, 0, 0, 6},
+		{"[", 1, 2, 0, 0, 7},
+		{">", 1, 8, 1, 1, 8},
+	}
+	lines := strings.Split(strings.TrimSuffix(trace.String(), "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("%d trace lines with a limit of %d:\n%s", len(lines), len(want), trace.String())
+	}
+	for i, line := range lines {
+		var ev traceEvent
+		dec := json.NewDecoder(strings.NewReader(line))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatalf("line %d %q: %v", i + 1, line, err)
+		}
+		if ev != want[i] {
+			t.Errorf("line %d is %+v, want %+v", i + 1, ev, want[i])
+		}
+	}
+	if _, err := NewRuntime(func(rt *Runtime) error { return rt.SetTraceFormat("xml") }); err == nil {
+		t.Errorf("trace format xml accepted")
+	}
+}
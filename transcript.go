@@ -0,0 +1,86 @@
+package bf
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// TranscriptEvent is one read by , or byte written by . in a
+// transcript. A transcript is a file of these as JSON, one per line,
+// in the order they happened:
+//
+//	{"dir":"in","step":3,"byte":104}
+//	{"dir":"out","step":5,"byte":104}
+//	{"dir":"in","step":9,"byte":0,"eof":true}
+//
+// Dir is "in" or "out", and Step is the step count once the command
+// ran. A read at the end of input has EOF set and a byte of 0, and
+// each read after it is recorded the same way.
+type TranscriptEvent struct {
+	Dir string `json:"dir"`
+	Step int64 `json:"step"`
+	Byte byte `json:"byte"`
+	EOF bool `json:"eof,omitempty"`
+}
+
+// transcript records a run's input and output events.
+type transcript struct {
+	w *bufio.Writer
+	err error
+}
+
+// RecordTranscript writes a transcript of the input and output of
+// later runs to w, flushed as each run ends, even with an error. The
+// values written by numeric output and the input and output of the
+// threads a fork starts are not recorded. A nil w stops recording.
+func (rt *Runtime) RecordTranscript(w io.Writer) {
+	rt.transcript = nil
+	if w != nil {
+		rt.transcript = &transcript{w: bufio.NewWriter(w)}
+	}
+}
+
+// record adds an event, keeping the first error to report at flush.
+func (t *transcript) record(e TranscriptEvent) {
+	if t.err != nil {
+		return
+	}
+	line, err := json.Marshal(e)
+	if err == nil {
+		line = append(line, '\n')
+		_, err = t.w.Write(line)
+	}
+	t.err = err
+}
+
+func (t *transcript) flush() error {
+	if t.err == nil {
+		t.err = t.w.Flush()
+	}
+	if t.err != nil {
+		return fmt.Errorf("%v writing transcript", t.err)
+	}
+	return nil
+}
+
+// ReadTranscript reads the events of a transcript.
+func ReadTranscript(r io.Reader) ([]TranscriptEvent, error) {
+	var events []TranscriptEvent
+	dec := json.NewDecoder(r)
+	for {
+		var e TranscriptEvent
+		err := dec.Decode(&e)
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("transcript event %d: %v", len(events) + 1, err)
+		}
+		if e.Dir != "in" && e.Dir != "out" {
+			return nil, fmt.Errorf("transcript event %d: unknown dir %q", len(events) + 1, e.Dir)
+		}
+		events = append(events, e)
+	}
+}
@@ -0,0 +1,63 @@
+package bf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTranscript(t *testing.T) {
+	var rec strings.Builder
+	// echoes its input until the end, then writes ! once more
+	out, _, err := runProgram(t, ",[.,]+++++++++++++++++++++++++++++++++.", "hi", 0, WithEOFMode("0"), WithTranscript(&rec))
+	if err != nil || out != "hi!" {
+		t.Fatalf("output %q, error %v", out, err)
+	}
+	want := []string{
+		`{"dir":"in","step":1,"byte":104}`,
+		`{"dir":"out","step":3,"byte":104}`,
+		`{"dir":"in","step":4,"byte":105}`,
+		`{"dir":"out","step":6,"byte":105}`,
+		`{"dir":"in","step":7,"byte":0,"eof":true}`,
+		`{"dir":"out","step":42,"byte":33}`,
+	}
+	got := strings.Split(strings.TrimSuffix(rec.String(), "\n"), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("transcript:\n%s", rec.String())
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d: %s, want %s", i + 1, got[i], want[i])
+		}
+	}
+	events, err := ReadTranscript(strings.NewReader(rec.String()))
+	if err != nil || len(events) != len(want) || !events[4].EOF || events[5] != (TranscriptEvent{"out", 42, '!', false}) {
+		t.Errorf("read back: %+v, %v", events, err)
+	}
+}
+
+func TestTranscriptUnchanged(t *testing.T) {
+	// each EOF mode sees the same input with a transcript as without
+	for _, mode := range []string{"0", "-1", "nochange"} {
+		src := ",.,.,."
+		plain, _, plainErr := runProgram(t, src, "a", 0, WithEOFMode(mode))
+		var rec strings.Builder
+		recorded, _, err := runProgram(t, src, "a", 0, WithEOFMode(mode), WithTranscript(&rec))
+		if plain != recorded || (plainErr == nil) != (err == nil) {
+			t.Errorf("eof %s: output %q with a transcript, %q without", mode, recorded, plain)
+		}
+		if n := strings.Count(rec.String(), `"eof":true`); n != 2 {
+			t.Errorf("eof %s: %d end of input events:\n%s", mode, n, rec.String())
+		}
+	}
+}
+
+func TestTranscriptOnError(t *testing.T) {
+	var rec strings.Builder
+	_, _, err := runProgram(t, "+.<", "", 0, WithTranscript(&rec))
+	if err == nil || rec.String() != `{"dir":"out","step":2,"byte":1}` + "\n" {
+		t.Errorf("error %v, transcript %q", err, rec.String())
+	}
+	if _, err := ReadTranscript(strings.NewReader(`{"dir":"sideways","step":1}`)); err == nil || !strings.Contains(err.Error(), `unknown dir "sideways"`) {
+		t.Errorf("bad dir: %v", err)
+	}
+}
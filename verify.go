@@ -0,0 +1,152 @@
+package bf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// ErrMismatch is wrapped by the error Verify returns when the
+// optimized run of a program differs from the unoptimized one.
+var ErrMismatch = errors.New("optimized run differs from the unoptimized one")
+
+// Verify runs prog twice on fresh Runtimes from newRuntime with the
+// same input: once unoptimized on the tree interpreter, and once
+// optimized by passes as bytecode, then writes the optimized run's
+// output to out. If the runs' output, final tapes, or errors and
+// where they happened differ, it returns an error wrapping
+// ErrMismatch, and otherwise the error both runs had. Like Optimize,
+// it rewrites prog in place, so prog must not be optimized already.
+//
+// Steps are only approximate once optimized, so runs that stop at
+// the step limit are not compared. The optimized code may report a
+// cell that wraps at a different command of a rewritten loop, and as
+// merged moves can skip a step off the tape and back, an unoptimized
+// run that goes off the tape is only checked for output that agrees
+// as far as both runs got.
+func Verify(prog Runner, passes Pass, newRuntime func(io.Reader, io.Writer) (*Runtime, error), input []byte, out io.Writer) error {
+	if CountNodes(prog)["Fork"] > 0 {
+		return errors.New("cannot verify a program that forks")
+	}
+	var plainOut, optOut bytes.Buffer
+	plain, err := newRuntime(bytes.NewReader(input), &plainOut)
+	if err != nil {
+		return err
+	}
+	plain.interpret = true
+	plainErr := plain.Run(prog)
+	opt, err := newRuntime(bytes.NewReader(input), &optOut)
+	if err != nil {
+		return err
+	}
+	optErr := opt.Run(Optimize(prog, passes))
+	if _, err := out.Write(optOut.Bytes()); err != nil {
+		return err
+	}
+	var limit *StepLimitError
+	if errors.As(plainErr, &limit) || errors.As(optErr, &limit) {
+		if optErr == nil {
+			return plainErr
+		}
+		return optErr
+	}
+	if plainErr != nil && failure(plainErr) == "tape" {
+		// the runs may stop at different places, but what they wrote
+		// up to there must agree
+		if d := diffPrefix(plainOut.Bytes(), optOut.Bytes()); d != "" {
+			return fmt.Errorf("%w: output %s", ErrMismatch, d)
+		}
+		return optErr
+	}
+
+	if d := diffBytes(plainOut.Bytes(), optOut.Bytes()); d != "" {
+		return fmt.Errorf("%w: output %s", ErrMismatch, d)
+	}
+	switch {
+	case (plainErr == nil) != (optErr == nil):
+		return fmt.Errorf("%w: unoptimized error %v, optimized error %v", ErrMismatch, plainErr, optErr)
+	case plainErr != nil && plain.Position() != opt.Position() && (failure(plainErr) == "" || failure(plainErr) != failure(optErr)):
+		return fmt.Errorf("%w: unoptimized error at %+v, optimized at %+v: %v", ErrMismatch, plain.Position(), opt.Position(), optErr)
+	case plainErr != nil:
+		// rewritten code leaves the tapes apart when a run fails
+		return optErr
+	}
+	if plain.Pointer() != opt.Pointer() {
+		return fmt.Errorf("%w: pointer ends at cell %d unoptimized, %d optimized", ErrMismatch, plain.Pointer(), opt.Pointer())
+	}
+	if d := diffTapes(plain, opt); d != "" {
+		return fmt.Errorf("%w: %s", ErrMismatch, d)
+	}
+	return optErr
+}
+
+// failure returns "tape" if err is from going off the tape, "wrap"
+// if it is from a strict cell wrapping, and "" otherwise.
+func failure(err error) string {
+	var rerr *RangeError
+	var merr *MemoryLimitError
+	var werr *WrapError
+	switch {
+	case errors.As(err, &rerr) || errors.As(err, &merr):
+		return "tape"
+	case errors.As(err, &werr):
+		return "wrap"
+	}
+	return ""
+}
+
+// diffBytes describes where b first differs from a, or returns "" if
+// they are the same.
+func diffBytes(a, b []byte) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	if i == len(a) || i == len(b) {
+		return fmt.Sprintf("is %d bytes unoptimized, %d optimized", len(a), len(b))
+	}
+	return fmt.Sprintf("byte %d is %d unoptimized, %d optimized", i, a[i], b[i])
+}
+
+// diffPrefix is diffBytes for runs that may have stopped at different
+// places, comparing only as much as the shorter wrote.
+func diffPrefix(a, b []byte) string {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	return diffBytes(a[:n], b[:n])
+}
+
+// diffTapes describes the first logical cell that differs between the
+// tapes of a and b, counting cells one tape never reached as zero, or
+// returns "" if they are the same.
+func diffTapes(a, b *Runtime) string {
+	lo := -a.origin
+	if -b.origin < lo {
+		lo = -b.origin
+	}
+	hi := a.size() - a.origin
+	if n := b.size() - b.origin; n > hi {
+		hi = n
+	}
+	zero := new(big.Int)
+	for i := lo; i < hi; i++ {
+		x, y := a.Cell(i), b.Cell(i)
+		if x == nil {
+			x = zero
+		}
+		if y == nil {
+			y = zero
+		}
+		if x.Cmp(y) != 0 {
+			return fmt.Sprintf("cell %d is %s unoptimized, %s optimized", i, x, y)
+		}
+	}
+	return ""
+}
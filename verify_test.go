@@ -0,0 +1,168 @@
+package bf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// randomProgram returns a program of n commands, or a few more to
+// close its loops, made from r. Its brackets always balance.
+func randomProgram(r *rand.Rand, n int) string {
+	var b strings.Builder
+	depth := 0
+	for i := 0; i < n; i++ {
+		switch k := r.Intn(12); {
+		case k == 0:
+			b.WriteByte('[')
+			depth++
+		case k == 1 && depth > 0:
+			b.WriteByte(']')
+			depth--
+		default:
+			b.WriteByte("+-<>.,"[r.Intn(6)])
+		}
+	}
+	b.WriteString(strings.Repeat("]", depth))
+	return b.String()
+}
+
+// verifyRuntimes returns a newRuntime for Verify that makes Runtimes
+// with opts, a step limit and a small tape.
+func verifyRuntimes(opts ...Option) func(io.Reader, io.Writer) (*Runtime, error) {
+	return func(in io.Reader, out io.Writer) (*Runtime, error) {
+		return NewRuntime(append([]Option{WithInput(in), WithOutput(out), WithTapeSize(32), WithMaxSteps(20000)}, opts...)...)
+	}
+}
+
+// verify runs Verify on src optimized by passes, failing the test if
+// the runs differ.
+func verify(t *testing.T, src, input string, passes Pass, opts ...Option) {
+	t.Helper()
+	err := Verify(parse(t, src), passes, verifyRuntimes(opts...), []byte(input), io.Discard)
+	if errors.Is(err, ErrMismatch) {
+		t.Errorf("%q: %v", src, err)
+	}
+}
+
+func TestVerifyCorpus(t *testing.T) {
+	for _, p := range corpus(t, "*.bf") {
+		var out bytes.Buffer
+		err := Verify(parse(t, p.src), AllPasses, verifyRuntimes(WithTapeSize(30000), WithMaxSteps(0)), []byte(p.input), &out)
+		if errors.Is(err, ErrMismatch) || out.String() != expected(t, p.name + ".out") {
+			t.Errorf("%s: output %q, error %v", p.name, out.String(), err)
+		}
+	}
+}
+
+func TestVerifyRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	n := 2000
+	if testing.Short() {
+		n = 200
+	}
+	modes := []struct {
+		passes Pass
+		opts []Option
+	}{
+		{AllPasses, nil},
+		{AllPasses, []Option{WithTapeMode("wrap", 32)}},
+		{AllPasses, []Option{WithTapeMode("grow", 64)}},
+		{AllPasses, []Option{WithTapeMode("infinite", 1024)}},
+		{AllPasses, []Option{WithCellWidth(16)}},
+		{StrictPasses, []Option{WithStrictCells()}},
+		{ExactPasses, []Option{WithBigCells(true)}},
+	}
+	for i := 0; i < n; i++ {
+		src := randomProgram(r, 1 + r.Intn(40))
+		m := modes[i % len(modes)]
+		verify(t, src, "ab\x00c", m.passes, m.opts...)
+	}
+}
+
+func TestVerifyMismatch(t *testing.T) {
+	// Runtimes that differ stand in for a miscompile
+	calls := 0
+	newRuntime := func(in io.Reader, out io.Writer) (*Runtime, error) {
+		calls++
+		if calls == 1 {
+			return NewRuntime(WithInput(in), WithOutput(out), WithEOFMode("0"))
+		}
+		return NewRuntime(WithInput(in), WithOutput(out))
+	}
+	err := Verify(parse(t, ",."), AllPasses, newRuntime, nil, io.Discard)
+	if !errors.Is(err, ErrMismatch) || err.Error() != "optimized run differs from the unoptimized one: output byte 0 is 0 unoptimized, 255 optimized" {
+		t.Errorf("error %v", err)
+	}
+}
+
+func TestVerifyOffTape(t *testing.T) {
+	// the merged moves never reach cell -1, so only the unoptimized
+	// run fails, but the output before it must still agree
+	err := Verify(parse(t, "+.<>."), AllPasses, verifyRuntimes(), nil, io.Discard)
+	if err != nil {
+		t.Errorf("error %v", err)
+	}
+	calls := 0
+	newRuntime := func(in io.Reader, out io.Writer) (*Runtime, error) {
+		calls++
+		if calls == 1 {
+			return NewRuntime(WithInput(in), WithOutput(out))
+		}
+		return NewRuntime(WithInput(in), WithOutput(out), WithCellWidth(16), WithNumericOutput(" "))
+	}
+	err = Verify(parse(t, "+.<>."), AllPasses, newRuntime, nil, io.Discard)
+	if !errors.Is(err, ErrMismatch) {
+		t.Errorf("different output before the failure: error %v", err)
+	}
+}
+
+func TestFailure(t *testing.T) {
+	tests := []struct {
+		src string
+		opts []Option
+		want string
+	}{
+		{"<", nil, "tape"},
+		{"+[<+]", nil, "tape"},
+		{">>>>", []Option{WithTapeSize(2), WithTapeMode("grow", 3)}, "tape"},
+		{"<<<<", []Option{WithTapeSize(2), WithTapeMode("infinite", 3)}, "tape"},
+		{"-", []Option{WithStrictCells()}, "wrap"},
+		{"+[-[-]]-", []Option{WithStrictCells()}, "wrap"},
+		{"+", []Option{WithMaxSteps(0)}, ""},
+		{"+[]", []Option{WithMaxSteps(10)}, ""},
+	}
+	for _, tt := range tests {
+		_, _, err := runProgram(t, tt.src, "", 0, tt.opts...)
+		if err == nil && tt.want != "" {
+			t.Errorf("%q: no error", tt.src)
+			continue
+		}
+		if err != nil && failure(err) != tt.want {
+			t.Errorf("%q: %v is %q, want %q", tt.src, err, failure(err), tt.want)
+		}
+	}
+}
+
+func FuzzVerify(f *testing.F) {
+	for _, p := range corpus(f, "*.bf") {
+		f.Add(p.src)
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		f.Add(randomProgram(r, 30))
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		prog, err := ParseBytes([]byte(src))
+		if err != nil {
+			return
+		}
+		err = Verify(prog, AllPasses, verifyRuntimes(), []byte("ab\x00c"), io.Discard)
+		if errors.Is(err, ErrMismatch) {
+			t.Errorf("%q: %v", src, err)
+		}
+	})
+}
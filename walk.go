@@ -0,0 +1,162 @@
+package bf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Walk calls fn for r and then, if fn returns true, for each command
+// in r's block or loop or procedure body, in source order.
+func Walk(r Runner, fn func(Runner) bool) {
+	if !fn(r) {
+		return
+	}
+	switch x := r.(type) {
+	case *Block:
+		for _, cmd := range x.seq {
+			Walk(cmd, fn)
+		}
+	case *Loop:
+		Walk(x.block, fn)
+	case *DefineProc:
+		Walk(x.block, fn)
+	}
+}
+
+// CountNodes returns how many nodes of each kind prog has, keyed by
+// type name such as "Loop" or "Update". Each loop or procedure body
+// is counted as a Block.
+func CountNodes(prog Runner) map[string]int {
+	counts := map[string]int{}
+	Walk(prog, func(r Runner) bool {
+		counts[strings.TrimPrefix(fmt.Sprintf("%T", r), "*bf.")]++
+		return true
+	})
+	return counts
+}
+
+// Pos returns the position of the block, which is that of its loop's
+// [ or the start of the program.
+func (r *Block) Pos() Pos {
+	return r.pos
+}
+
+// Commands returns the commands of the block.
+func (r *Block) Commands() []Runner {
+	return r.seq
+}
+
+// Pos returns the position of the loop's [.
+func (r *Loop) Pos() Pos {
+	return r.pos
+}
+
+// End returns the position of the loop's ].
+func (r *Loop) End() Pos {
+	return r.end
+}
+
+// Body returns the block the loop runs.
+func (r *Loop) Body() *Block {
+	return r.block
+}
+
+// Pos returns the position of the procedure's (.
+func (r *DefineProc) Pos() Pos {
+	return r.pos
+}
+
+// End returns the position of the procedure's ).
+func (r *DefineProc) End() Pos {
+	return r.end
+}
+
+// Body returns the block the procedure runs when called.
+func (r *DefineProc) Body() *Block {
+	return r.block
+}
+
+// Pos returns the position of the : command.
+func (r *CallProc) Pos() Pos {
+	return r.pos
+}
+
+// Pos returns the position of the Y command.
+func (r *Fork) Pos() Pos {
+	return r.pos
+}
+
+// Pos returns the position of the @ command.
+func (r *Halt) Pos() Pos {
+	return r.pos
+}
+
+// Pos returns the position of the $ command.
+func (r *Store) Pos() Pos {
+	return r.pos
+}
+
+// Pos returns the position of the ! command.
+func (r *Retrieve) Pos() Pos {
+	return r.pos
+}
+
+// Pos returns the position of the hanging loop's [.
+func (r *Hang) Pos() Pos {
+	return r.pos
+}
+
+// Pos returns the position of the move's first command.
+func (r *Move) Pos() Pos {
+	return r.pos
+}
+
+// Dir returns how many cells the move goes, negative for left.
+func (r *Move) Dir() int {
+	return r.dir
+}
+
+// Pos returns the position of the scan's [.
+func (r *Scan) Pos() Pos {
+	return r.pos
+}
+
+// Dir returns how many cells each step of the scan goes.
+func (r *Scan) Dir() int {
+	return r.dir
+}
+
+// Pos returns the position of the update's first command.
+func (r *Update) Pos() Pos {
+	return r.pos
+}
+
+// Delta returns how much the update adds to its cell.
+func (r *Update) Delta() int {
+	return r.n
+}
+
+// Pos returns the position of the [ of the loop the set replaced.
+func (r *Set) Pos() Pos {
+	return r.pos
+}
+
+// Pos returns the position of the [ of the loop the MulAdd replaced.
+func (r *MulAdd) Pos() Pos {
+	return r.pos
+}
+
+// Pos returns the position of the , command.
+func (r *Getchar) Pos() Pos {
+	return r.pos
+}
+
+// Pos returns the position of the . command.
+func (r *Putchar) Pos() Pos {
+	return r.pos
+}
+
+// Pos returns the position of the # command.
+func (r *Dump) Pos() Pos {
+	return r.pos
+}
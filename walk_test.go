@@ -0,0 +1,86 @@
+package bf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWalkOrder(t *testing.T) {
+	prog := parse(t, "+[>[-]\n<],.")
+	var visits []string
+	Walk(prog, func(r Runner) bool {
+		visits = append(visits, fmt.Sprintf("%T %v", r, r.(interface{ Pos() Pos }).Pos()))
+		return true
+	})
+	want := []string{
+		"*bf.Block 1:0",
+		"*bf.Update 1:1",
+		"*bf.Loop 1:2",
+		"*bf.Block 1:2",
+		"*bf.Move 1:3",
+		"*bf.Loop 1:4",
+		"*bf.Block 1:4",
+		"*bf.Update 1:5",
+		"*bf.Move 2:1",
+		"*bf.Getchar 2:3",
+		"*bf.Putchar 2:4",
+	}
+	if strings.Join(visits, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(visits, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWalkSkip(t *testing.T) {
+	// returning false for a loop skips its body
+	var n int
+	Walk(parse(t, "+[>[-]<]."), func(r Runner) bool {
+		n++
+		_, loop := r.(*Loop)
+		return !loop
+	})
+	if n != 4 {
+		t.Errorf("visited %d nodes, want the block, +, the loop and .", n)
+	}
+	counts := CountNodes(parse(t, "+[>[-]<]."))
+	if got := fmt.Sprint(counts); got != "map[Block:3 Loop:2 Move:2 Putchar:1 Update:2]" {
+		t.Errorf("counts %s", got)
+	}
+}
+
+// source rebuilds the commands of an unoptimized program.
+func source(r Runner) string {
+	switch x := r.(type) {
+	case *Block:
+		var b strings.Builder
+		for _, cmd := range x.Commands() {
+			b.WriteString(source(cmd))
+		}
+		return b.String()
+	case *Loop:
+		return "[" + source(x.Body()) + "]"
+	case *Update:
+		return repeat('+', '-', x.Delta())
+	case *Move:
+		return repeat('>', '<', x.Dir())
+	case *Getchar:
+		return ","
+	case *Putchar:
+		return "."
+	}
+	return "?"
+}
+
+func TestWalkSource(t *testing.T) {
+	for _, p := range corpus(t, "*.bf") {
+		want := strings.Map(func(r rune) rune {
+			if strings.ContainsRune("+-<>[],.", r) {
+				return r
+			}
+			return -1
+		}, p.src)
+		if got := source(parse(t, p.src)); got != want {
+			t.Errorf("%s: rebuilt %q, want %q", p.name, got, want)
+		}
+	}
+}
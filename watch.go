@@ -0,0 +1,58 @@
+package bf
+
+import (
+	"fmt"
+	"io"
+)
+
+// Watchpoint reports changes to one tape cell. old is the value
+// before the instruction being run.
+type Watchpoint struct {
+	Cell int // logical index
+	old string
+}
+
+// Watch reports to w each change any command makes to the given
+// logical cells, and pauses in the attached Debugger if there is one.
+// Positions are only exact for an unoptimized program.
+func (rt *Runtime) Watch(cells []int, w io.Writer) {
+	for _, c := range cells {
+		rt.watches = append(rt.watches, &Watchpoint{Cell: c})
+	}
+	rt.watchOut = w
+}
+
+// watchValue returns the value of a logical cell, which on a growing
+// tape may not have been allocated yet.
+func (rt *Runtime) watchValue(cell int) string {
+	i := cell + rt.origin
+	if i < 0 || i >= rt.size() {
+		return "0"
+	}
+	return rt.cellString(i)
+}
+
+// snapshotWatches records the watched cells before in runs.
+func (rt *Runtime) snapshotWatches() {
+	for _, wp := range rt.watches {
+		wp.old = rt.watchValue(wp.Cell)
+	}
+}
+
+// checkWatches reports the watched cells in changed.
+func (rt *Runtime) checkWatches(in *Instruction) error {
+	for _, wp := range rt.watches {
+		v := rt.watchValue(wp.Cell)
+		if v == wp.old {
+			continue
+		}
+		rt.flush()
+		fmt.Fprintf(rt.watchOut, "watch cell %d changed from %s to %s by %s at %s, step %d\n", wp.Cell, wp.old, v, in.String(), lineCol(in.pos), rt.steps)
+		if rt.debug != nil {
+			if err := rt.debug.pause(nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
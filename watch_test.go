@@ -0,0 +1,40 @@
+package bf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWatch(t *testing.T) {
+	var log strings.Builder
+	_, _, err := runProgram(t, "++>+>+<<[->>+<<]>>,", "\x09", 0, func(rt *Runtime) error {
+		rt.Watch([]int{2}, &log)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"watch cell 2 changed from 0 to 1 by + at 1:6, step 6\n" +
+		"watch cell 2 changed from 1 to 2 by + at 1:13, step 13\n" +
+		"watch cell 2 changed from 2 to 3 by + at 1:13, step 20\n" +
+		"watch cell 2 changed from 3 to 9 by , at 1:19, step 26\n"
+	if got := log.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWatchOptimized(t *testing.T) {
+	// the MulAdd writes cell 2 from cell 0, two cells from the pointer
+	var log strings.Builder
+	_, _, err := runProgram(t, "++[->>+++<<]", "", AllPasses, func(rt *Runtime) error {
+		rt.Watch([]int{2, 5}, &log)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := log.String(); !strings.HasPrefix(got, "watch cell 2 changed from 0 to 6 by [- +2*3] at 1:3") || strings.Count(got, "\n") != 1 {
+		t.Errorf("got:\n%s", got)
+	}
+}